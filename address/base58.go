@@ -0,0 +1,88 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var bigRadix = big.NewInt(58)
+
+// base58Decode decodes a Base58 string (the Bitcoin alphabet, in which each
+// leading '1' is a leading zero byte).
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	zeros := 0
+	for i, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid Base58 character %q at position %d", c, i)
+		}
+		if digit == 0 && zeros == i {
+			zeros++
+		}
+		n.Mul(n, bigRadix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// base58Encode is the inverse of base58Decode.
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	var digits []byte
+	mod := new(big.Int)
+	for n.Sign() > 0 {
+		n.DivMod(n, bigRadix, mod)
+		digits = append(digits, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		digits = append(digits, base58Alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// checksum returns the Base58Check checksum of the payload: the first four
+// bytes of its SHA256d.
+func checksum(payload []byte) []byte {
+	digest := sha256.Sum256(payload)
+	digest = sha256.Sum256(digest[:])
+	return digest[:4]
+}
+
+// base58CheckDecode decodes a Base58Check string, returning its payload
+// (the data before the checksum).
+func base58CheckDecode(s string) ([]byte, error) {
+	data, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errors.New("too short for a Base58Check checksum")
+	}
+	payload := data[:len(data)-4]
+	if !bytes.Equal(checksum(payload), data[len(data)-4:]) {
+		return nil, errors.New("checksum mismatch")
+	}
+	return payload, nil
+}
+
+// base58CheckEncode is the inverse of base58CheckDecode.
+func base58CheckEncode(payload []byte) string {
+	return base58Encode(append(payload[:len(payload):len(payload)], checksum(payload)...))
+}
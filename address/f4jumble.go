@@ -0,0 +1,78 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package address
+
+import (
+	"encoding/binary"
+
+	"github.com/zcash/lightwalletd/internal/blake2b"
+)
+
+// F4Jumble (ZIP 316) is the unkeyed four-round Feistel construction that
+// unified address encodings apply, so that changing any part of the
+// encoding changes all of it. It's defined for 48 to 4194368 bytes.
+
+const (
+	f4jumbleMinLen = 48
+	f4jumbleMaxLen = 4194368
+)
+
+// f4jumbleH returns H_i(u), a BLAKE2b digest of size bytes.
+func f4jumbleH(i byte, u []byte, size int) []byte {
+	var personal [16]byte
+	copy(personal[:], "UA_F4Jumble_H")
+	personal[13] = i
+	return blake2b.Sum(size, personal, u)
+}
+
+// f4jumbleG returns G_i(u): size bytes of concatenated BLAKE2b-512 digests.
+func f4jumbleG(i byte, u []byte, size int) []byte {
+	var personal [16]byte
+	copy(personal[:], "UA_F4Jumble_G")
+	personal[13] = i
+	r := make([]byte, 0, size+63)
+	for j := 0; len(r) < size; j++ {
+		binary.LittleEndian.PutUint16(personal[14:], uint16(j))
+		r = append(r, blake2b.Sum(64, personal, u)...)
+	}
+	return r[:size]
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// f4jumbleSplit returns the lengths of the left and right halves of a
+// message of n bytes.
+func f4jumbleSplit(n int) (int, int) {
+	left := min(64, n/2)
+	return left, n - left
+}
+
+// f4jumble returns F4Jumble(m); m must have a valid length.
+func f4jumble(m []byte) []byte {
+	leftLen, rightLen := f4jumbleSplit(len(m))
+	r := append([]byte(nil), m...)
+	a, b := r[:leftLen], r[leftLen:]
+	xorInto(b, f4jumbleG(0, a, rightLen)) // x
+	xorInto(a, f4jumbleH(0, b, leftLen))  // y
+	xorInto(b, f4jumbleG(1, a, rightLen)) // d
+	xorInto(a, f4jumbleH(1, b, leftLen))  // c
+	return r
+}
+
+// f4jumbleInv returns F4Jumble⁻¹(m); m must have a valid length.
+func f4jumbleInv(m []byte) []byte {
+	leftLen, rightLen := f4jumbleSplit(len(m))
+	r := append([]byte(nil), m...)
+	c, d := r[:leftLen], r[leftLen:]
+	xorInto(c, f4jumbleH(1, d, leftLen))  // y
+	xorInto(d, f4jumbleG(1, c, rightLen)) // x
+	xorInto(c, f4jumbleH(0, d, leftLen))  // a
+	xorInto(d, f4jumbleG(0, c, rightLen)) // b
+	return r
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+// Package address decodes and validates Zcash addresses.
+package address
+
+import (
+	"fmt"
+
+	"github.com/zcash/lightwalletd/chainparams"
+)
+
+// Kind is the kind of a transparent address.
+type Kind int
+
+const (
+	P2PKH Kind = iota + 1 // pay to public key hash ("t1" on mainnet, "tm" on testnet)
+	P2SH                  // pay to script hash ("t3" on mainnet, "t2" on testnet)
+)
+
+func (k Kind) String() string {
+	switch k {
+	case P2PKH:
+		return "P2PKH"
+	case P2SH:
+		return "P2SH"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// maxTransparentLen is the length of a Base58Check transparent address.
+const maxTransparentLen = 35
+
+// Transparent is a decoded transparent address.
+type Transparent struct {
+	Kind Kind
+	Hash [20]byte // the public key hash or script hash
+}
+
+// checkNetwork returns an error if the chain (as named by getblockchaininfo)
+// is known, isn't darkside, and doesn't use the address's encoding n;
+// matches compares encodings.
+func checkNetwork(chainName string, n *chainparams.AddressEncoding, matches func(want *chainparams.AddressEncoding) bool) error {
+	params := chainparams.Lookup(chainName)
+	if params != nil && params.Addresses != nil && !matches(params.Addresses) {
+		return fmt.Errorf("is a %s address, but the chain is %s", n.NetworkName, params.Addresses.NetworkName)
+	}
+	return nil
+}
+
+// DecodeTransparent decodes and validates a Base58Check transparent address
+// for the given chain (as named by getblockchaininfo). Addresses of other
+// networks are rejected; for chains that aren't known (such as darkside),
+// addresses of any network are accepted. Regtest uses the testnet prefixes.
+func DecodeTransparent(addr string, chainName string) (*Transparent, error) {
+	t, _, err := decodeTransparent(addr, chainName)
+	return t, err
+}
+
+func decodeTransparent(addr string, chainName string) (*Transparent, *chainparams.AddressEncoding, error) {
+	// Base58 decoding takes time quadratic in the length, so reject anything
+	// longer than a transparent address before decoding it.
+	if len(addr) > maxTransparentLen {
+		return nil, nil, fmt.Errorf("is %d characters long, want at most %d", len(addr), maxTransparentLen)
+	}
+	payload, err := base58CheckDecode(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(payload) != 22 {
+		return nil, nil, fmt.Errorf("decodes to %d bytes, want 22", len(payload))
+	}
+	prefix := [2]byte(payload[:2])
+	for _, n := range chainparams.AddressEncodings() {
+		var kind Kind
+		switch prefix {
+		case n.P2PKHPrefix:
+			kind = P2PKH
+		case n.P2SHPrefix:
+			kind = P2SH
+		default:
+			continue
+		}
+		err := checkNetwork(chainName, n, func(want *chainparams.AddressEncoding) bool {
+			return want.P2PKHPrefix == n.P2PKHPrefix && want.P2SHPrefix == n.P2SHPrefix
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return &Transparent{Kind: kind, Hash: [20]byte(payload[2:])}, n, nil
+	}
+	return nil, nil, fmt.Errorf("unknown address prefix %x", prefix)
+}
+
+// Encode returns the Base58Check encoding of the address for the given chain;
+// chains without address encodings (such as darkside) use the mainnet
+// prefixes.
+func (t *Transparent) Encode(chainName string) string {
+	n := chainparams.MainnetAddresses
+	if params := chainparams.Lookup(chainName); params != nil && params.Addresses != nil {
+		n = params.Addresses
+	}
+	return t.encode(n)
+}
+
+func (t *Transparent) encode(n *chainparams.AddressEncoding) string {
+	prefix := n.P2PKHPrefix
+	if t.Kind == P2SH {
+		prefix = n.P2SHPrefix
+	}
+	return base58CheckEncode(append(prefix[:], t.Hash[:]...))
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package address
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTransparent(t *testing.T) {
+	for _, tt := range []struct {
+		addr      string
+		chainName string
+		kind      Kind
+		hash      string
+	}{
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC", "main", P2PKH, "8286bf790866805397e3a947640b77a43f0b43a5"},
+		{"t1Hsc1LR8yKnbbe3twRp88p6vFfC5t7DLbs", "main", P2PKH, "0000000000000000000000000000000000000000"},
+		{"t3Vz22vK5z2LcKEdg16Yv4FFneEL1zg9ojd", "main", P2SH, "7d46a730d31f97b1930d3368a967c309bd4d136a"},
+		{"tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb", "test", P2PKH, "53c0307d6851aa0ce7825ba883c6bd9ad242b486"},
+		{"tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb", "regtest", P2PKH, "53c0307d6851aa0ce7825ba883c6bd9ad242b486"},
+		// Chains without known prefixes accept any network's addresses.
+		{"tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb", "darkside", P2PKH, "53c0307d6851aa0ce7825ba883c6bd9ad242b486"},
+		{"t3Vz22vK5z2LcKEdg16Yv4FFneEL1zg9ojd", "darkside", P2SH, "7d46a730d31f97b1930d3368a967c309bd4d136a"},
+	} {
+		taddr, err := DecodeTransparent(tt.addr, tt.chainName)
+		if err != nil {
+			t.Fatal(tt.addr, err)
+		}
+		if taddr.Kind != tt.kind || hex.EncodeToString(taddr.Hash[:]) != tt.hash {
+			t.Fatalf("%s decoded to %v %x", tt.addr, taddr.Kind, taddr.Hash)
+		}
+		if tt.chainName != "darkside" && taddr.Encode(tt.chainName) != tt.addr {
+			t.Fatalf("%s encoded to %s", tt.addr, taddr.Encode(tt.chainName))
+		}
+	}
+}
+
+func TestDecodeTransparentInvalid(t *testing.T) {
+	p2sh := append([]byte{0x1c, 0xbd}, make([]byte, 20)...)
+	for _, tt := range []struct {
+		addr      string
+		chainName string
+		err       string
+	}{
+		{"", "main", "too short"},
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yc", "main", "checksum mismatch"},
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby90C", "main", `invalid Base58 character '0' at position 33`},
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9y ", "main", `invalid Base58 character ' ' at position 34`},
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC1", "main", "is 36 characters long, want at most 35"},
+		{strings.Repeat("1", 1<<22), "main", "is 4194304 characters long, want at most 35"},
+		{"tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb", "main", "is a testnet address, but the chain is mainnet"},
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC", "regtest", "is a mainnet address, but the chain is regtest"},
+		{base58CheckEncode(p2sh[:21]), "main", "decodes to 21 bytes, want 22"},
+		{base58CheckEncode(append(p2sh, 0)), "main", "is 37 characters long, want at most 35"},
+		{base58CheckEncode(append([]byte{0x1c, 0xb9}, p2sh[2:]...)), "main", "unknown address prefix 1cb9"},
+	} {
+		_, err := DecodeTransparent(tt.addr, tt.chainName)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("%q: got error %v, want %q", tt.addr, err, tt.err)
+		}
+	}
+}
+
+func TestBase58RoundTrip(t *testing.T) {
+	for _, s := range []string{"", "00", "0000ff", "1cbd" + strings.Repeat("ab", 20)} {
+		data, _ := hex.DecodeString(s)
+		decoded, err := base58Decode(base58Encode(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(decoded) != s {
+			t.Fatalf("%s round-tripped to %x", s, decoded)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package address
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zcash/lightwalletd/chainparams"
+)
+
+// Receiver typecodes of unified addresses (ZIP 316).
+const (
+	TypecodeP2PKH   = 0x00
+	TypecodeP2SH    = 0x01
+	TypecodeSapling = 0x02
+	TypecodeOrchard = 0x03
+)
+
+// maxUnifiedLen is the length of the longest unified address that ZIP 316
+// allows: the longest human-readable part Bech32 allows, the separator, an
+// F4Jumble input of f4jumbleMaxLen bytes in 5-bit groups, and the checksum.
+// Longer input is rejected before it is decoded.
+const maxUnifiedLen = 83 + 1 + (f4jumbleMaxLen*8+4)/5 + 6
+
+// maxTEXLen is Bech32's length limit, which TEX addresses (ZIP 320), unlike
+// unified addresses, keep.
+const maxTEXLen = 90
+
+// metadataTypecodes is the first of the typecodes that ZIP 316 reserves for
+// metadata items rather than receivers.
+const metadataTypecodes = 0xc0
+
+// ErrNoTransparentReceiver is returned when a unified address has no
+// transparent (P2PKH or P2SH) receiver.
+var ErrNoTransparentReceiver = errors.New("unified address has no transparent receiver")
+
+// Receiver is one item of a unified address.
+type Receiver struct {
+	Typecode uint64
+	Data     []byte
+}
+
+// Unified is a decoded unified address, its receivers in typecode order.
+type Unified struct {
+	Receivers []Receiver
+}
+
+// receiverLengths are the lengths of the receivers of known typecodes;
+// others may be any length.
+var receiverLengths = map[uint64]int{
+	TypecodeP2PKH:   20,
+	TypecodeP2SH:    20,
+	TypecodeSapling: 43,
+	TypecodeOrchard: 43,
+}
+
+// DecodeUnified decodes and validates a unified address (ZIP 316) for the
+// given chain (as named by getblockchaininfo), which, as for
+// DecodeTransparent, must be the address's if it's known.
+func DecodeUnified(addr string, chainName string) (*Unified, error) {
+	u, _, err := decodeUnified(addr, chainName)
+	return u, err
+}
+
+func decodeUnified(addr string, chainName string) (*Unified, *chainparams.AddressEncoding, error) {
+	if len(addr) > maxUnifiedLen {
+		return nil, nil, fmt.Errorf("is %d characters long, want at most %d", len(addr), maxUnifiedLen)
+	}
+	hrp, data, err := bech32mDecode(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := networkOfHRP(hrp, chainName, func(n *chainparams.AddressEncoding) string { return n.UnifiedHRP })
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < f4jumbleMinLen || len(data) > f4jumbleMaxLen {
+		return nil, nil, fmt.Errorf("unified address encoding has %d bytes, want %d to %d",
+			len(data), f4jumbleMinLen, f4jumbleMaxLen)
+	}
+	data = f4jumbleInv(data)
+	var padding [16]byte
+	copy(padding[:], hrp)
+	if string(data[len(data)-16:]) != string(padding[:]) {
+		return nil, nil, errors.New("invalid unified address padding")
+	}
+	data = data[:len(data)-16]
+
+	u := &Unified{}
+	shielded := false
+	for len(data) > 0 {
+		var typecode, length uint64
+		if typecode, data, err = readCompactSize(data); err != nil {
+			return nil, nil, fmt.Errorf("receiver %d typecode: %w", len(u.Receivers), err)
+		}
+		if length, data, err = readCompactSize(data); err != nil {
+			return nil, nil, fmt.Errorf("receiver %d length: %w", len(u.Receivers), err)
+		}
+		if length > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("receiver %d has length %d, but only %d bytes remain",
+				len(u.Receivers), length, len(data))
+		}
+		if want, ok := receiverLengths[typecode]; ok && int(length) != want {
+			return nil, nil, fmt.Errorf("receiver of typecode %d has length %d, want %d", typecode, length, want)
+		}
+		if k := len(u.Receivers); k > 0 && typecode <= u.Receivers[k-1].Typecode {
+			return nil, nil, fmt.Errorf("receiver typecodes aren't in increasing order (%d after %d)",
+				typecode, u.Receivers[k-1].Typecode)
+		}
+		if typecode == TypecodeP2SH && len(u.Receivers) > 0 && u.Receivers[0].Typecode == TypecodeP2PKH {
+			return nil, nil, errors.New("unified address has both P2PKH and P2SH receivers")
+		}
+		if typecode > TypecodeP2SH && typecode < metadataTypecodes {
+			shielded = true
+		}
+		u.Receivers = append(u.Receivers, Receiver{Typecode: typecode, Data: data[:length]})
+		data = data[length:]
+	}
+	if !shielded {
+		return nil, nil, errors.New("unified address has no shielded receiver")
+	}
+	return u, n, nil
+}
+
+// Transparent returns the address's transparent receiver, or an error
+// wrapping ErrNoTransparentReceiver if it has none.
+func (u *Unified) Transparent() (*Transparent, error) {
+	for _, r := range u.Receivers {
+		switch r.Typecode {
+		case TypecodeP2PKH:
+			return &Transparent{Kind: P2PKH, Hash: [20]byte(r.Data)}, nil
+		case TypecodeP2SH:
+			return &Transparent{Kind: P2SH, Hash: [20]byte(r.Data)}, nil
+		}
+	}
+	return nil, ErrNoTransparentReceiver
+}
+
+// DecodeTEX decodes and validates a TEX (transparent-source-only) address
+// (ZIP 320) for the given chain, returning its P2PKH address.
+func DecodeTEX(addr string, chainName string) (*Transparent, error) {
+	t, _, err := decodeTEX(addr, chainName)
+	return t, err
+}
+
+func decodeTEX(addr string, chainName string) (*Transparent, *chainparams.AddressEncoding, error) {
+	if len(addr) > maxTEXLen {
+		return nil, nil, fmt.Errorf("is %d characters long, want at most %d", len(addr), maxTEXLen)
+	}
+	hrp, data, err := bech32mDecode(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := networkOfHRP(hrp, chainName, func(n *chainparams.AddressEncoding) string { return n.TEXHRP })
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) != 20 {
+		return nil, nil, fmt.Errorf("TEX address decodes to %d bytes, want 20", len(data))
+	}
+	return &Transparent{Kind: P2PKH, Hash: [20]byte(data)}, n, nil
+}
+
+// networkOfHRP returns the network whose human-readable part (as hrpOf
+// returns it) is hrp, checking that it's the chain's.
+func networkOfHRP(hrp string, chainName string, hrpOf func(*chainparams.AddressEncoding) string) (*chainparams.AddressEncoding, error) {
+	for _, n := range chainparams.AddressEncodings() {
+		if hrpOf(n) == hrp {
+			if err := checkNetwork(chainName, n, func(want *chainparams.AddressEncoding) bool { return want == n }); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown human-readable part %q", hrp)
+}
+
+// readCompactSize reads a canonically encoded CompactSize integer.
+func readCompactSize(data []byte) (uint64, []byte, error) {
+	if len(data) < 1 {
+		return 0, nil, errors.New("truncated CompactSize")
+	}
+	var size int
+	var minValue uint64
+	switch data[0] {
+	case 0xfd:
+		size, minValue = 2, 0xfd
+	case 0xfe:
+		size, minValue = 4, 0x10000
+	case 0xff:
+		size, minValue = 8, 0x100000000
+	default:
+		return uint64(data[0]), data[1:], nil
+	}
+	if len(data) < 1+size {
+		return 0, nil, errors.New("truncated CompactSize")
+	}
+	var buf [8]byte
+	copy(buf[:], data[1:1+size])
+	v := binary.LittleEndian.Uint64(buf[:])
+	if v < minValue {
+		return 0, nil, errors.New("non-canonical CompactSize")
+	}
+	return v, data[1+size:], nil
+}
+
+// TransparentReceiver returns the Base58Check transparent address that addr
+// pays to, for the given chain (as named by getblockchaininfo): addr itself
+// if it's a transparent address, the transparent receiver of a unified
+// address, or the P2PKH address of a TEX address. The error explains why an
+// address is invalid; for a unified address without a transparent receiver
+// it wraps ErrNoTransparentReceiver.
+func TransparentReceiver(addr string, chainName string) (string, error) {
+	var t *Transparent
+	var n *chainparams.AddressEncoding
+	var err error
+	hrp := strings.ToLower(addr[:max(0, strings.LastIndexByte(addr, '1'))])
+	switch {
+	case slices.ContainsFunc(chainparams.AddressEncodings(), func(n *chainparams.AddressEncoding) bool { return n.UnifiedHRP == hrp }):
+		var u *Unified
+		if u, n, err = decodeUnified(addr, chainName); err == nil {
+			t, err = u.Transparent()
+		}
+	case slices.ContainsFunc(chainparams.AddressEncodings(), func(n *chainparams.AddressEncoding) bool { return n.TEXHRP == hrp }):
+		t, n, err = decodeTEX(addr, chainName)
+	default:
+		t, n, err = decodeTransparent(addr, chainName)
+	}
+	if err != nil {
+		return "", err
+	}
+	return t.encode(n), nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encodeUnified returns the unified address of the receivers, padded with
+// padHRP (normally the same as hrp).
+func encodeUnified(hrp, padHRP string, receivers ...Receiver) string {
+	var data []byte
+	for _, r := range receivers {
+		data = append(data, byte(r.Typecode), byte(len(r.Data)))
+		data = append(data, r.Data...)
+	}
+	var padding [16]byte
+	copy(padding[:], padHRP)
+	return bech32mEncode(hrp, f4jumble(append(data, padding[:]...)))
+}
+
+// readTestVectors returns the rows of a file from zcash-test-vectors, after
+// its two header rows (the source and the column names).
+func readTestVectors(t *testing.T, name string) [][]any {
+	t.Helper()
+	s, err := os.ReadFile("../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]any
+	if err := json.Unmarshal(s, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) < 3 {
+		t.Fatal(name, " has too few rows")
+	}
+	return rows[2:]
+}
+
+// vectorBytes decodes a hex column of a test vector; null is nil.
+func vectorBytes(t *testing.T, v any) []byte {
+	t.Helper()
+	if v == nil {
+		return nil
+	}
+	b, err := hex.DecodeString(v.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestF4Jumble(t *testing.T) {
+	// ZIP 316's test vectors, from zcash-test-vectors' f4jumble.py.
+	for _, row := range readTestVectors(t, "f4jumble.json") {
+		normal, jumbled := vectorBytes(t, row[0]), vectorBytes(t, row[1])
+		if !bytes.Equal(f4jumble(normal), jumbled) {
+			t.Fatalf("f4jumble of %d bytes doesn't match the test vector", len(normal))
+		}
+		if !bytes.Equal(f4jumbleInv(jumbled), normal) {
+			t.Fatalf("f4jumbleInv of %d bytes doesn't match the test vector", len(jumbled))
+		}
+	}
+
+	// And the round trip at lengths around the split points.
+	for _, n := range []int{48, 83, 127, 128, 129, 200, 1000} {
+		m := make([]byte, n)
+		for i := range m {
+			m[i] = byte(i * 7)
+		}
+		jumbled := f4jumble(m)
+		if bytes.Equal(jumbled, m) || jumbled[0] == m[0] && jumbled[n-1] == m[n-1] {
+			t.Fatalf("f4jumble didn't change all of %d bytes", n)
+		}
+		if !bytes.Equal(f4jumbleInv(jumbled), m) {
+			t.Fatalf("f4jumbleInv isn't the inverse of f4jumble for %d bytes", n)
+		}
+	}
+}
+
+func TestUnifiedAddressVectors(t *testing.T) {
+	// ZIP 316's test vectors, from zcash-test-vectors' unified_address.py.
+	// Its columns are p2pkh_bytes, p2sh_bytes, sapling_raw_addr,
+	// orchard_raw_addr, unknown_typecode, unknown_bytes, and unified_addr
+	// (then the key derivation inputs); all are mainnet addresses.
+	for _, row := range readTestVectors(t, "unified_address.json") {
+		addr := row[6].(string)
+		var want []Receiver
+		for i, typecode := range []uint64{TypecodeP2PKH, TypecodeP2SH, TypecodeSapling, TypecodeOrchard} {
+			if data := vectorBytes(t, row[i]); data != nil {
+				want = append(want, Receiver{typecode, data})
+			}
+		}
+		if row[4] != nil {
+			want = append(want, Receiver{uint64(row[4].(float64)), vectorBytes(t, row[5])})
+		}
+		u, err := DecodeUnified(addr, "main")
+		if err != nil {
+			t.Fatal(addr, err)
+		}
+		if !reflect.DeepEqual(u.Receivers, want) {
+			t.Fatalf("%s: got receivers %v, want %v", addr, u.Receivers, want)
+		}
+
+		got, err := TransparentReceiver(addr, "main")
+		switch {
+		case row[0] != nil:
+			if taddr := (&Transparent{Kind: P2PKH, Hash: [20]byte(vectorBytes(t, row[0]))}).Encode("main"); got != taddr {
+				t.Fatalf("%s: got %s, want %s", addr, got, taddr)
+			}
+		case row[1] != nil:
+			if taddr := (&Transparent{Kind: P2SH, Hash: [20]byte(vectorBytes(t, row[1]))}).Encode("main"); got != taddr {
+				t.Fatalf("%s: got %s, want %s", addr, got, taddr)
+			}
+		case !errors.Is(err, ErrNoTransparentReceiver):
+			t.Fatalf("%s: got %q, %v, want ErrNoTransparentReceiver", addr, got, err)
+		}
+		if _, err := TransparentReceiver(addr, "test"); err == nil {
+			t.Fatal(addr, " was accepted for testnet")
+		}
+	}
+}
+
+func TestBech32m(t *testing.T) {
+	// BIP 350 test vectors.
+	for _, s := range []string{"a1lqfn3a", "A1LQFN3A", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"} {
+		if _, _, err := bech32mDecode(s); err != nil {
+			t.Fatal(s, err)
+		}
+	}
+	for _, s := range []string{"a1lqfn3A", "a1lqfn3q", "1lqfn3a", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryb"} {
+		if _, _, err := bech32mDecode(s); err == nil {
+			t.Fatal("decoded invalid Bech32m", s)
+		}
+	}
+}
+
+func TestTransparentReceiver(t *testing.T) {
+	p2pkh := Receiver{TypecodeP2PKH, bytes.Repeat([]byte{0x11}, 20)}
+	p2sh := Receiver{TypecodeP2SH, bytes.Repeat([]byte{0x22}, 20)}
+	sapling := Receiver{TypecodeSapling, bytes.Repeat([]byte{0x33}, 43)}
+	orchard := Receiver{TypecodeOrchard, bytes.Repeat([]byte{0x44}, 43)}
+	unknown := Receiver{0x7f, []byte{0x55}}
+	p2pkhAddr := (&Transparent{Kind: P2PKH, Hash: [20]byte(p2pkh.Data)}).Encode("main")
+	p2shTestAddr := (&Transparent{Kind: P2SH, Hash: [20]byte(p2sh.Data)}).Encode("test")
+
+	for _, tt := range []struct {
+		addr      string
+		chainName string
+		want      string
+	}{
+		{"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC", "main", "t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC"},
+		// ZIP 320's example.
+		{"tex1s2rt77ggv6q989lr49rkgzmh5slsksa9khdgte", "main", "t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC"},
+		{strings.ToUpper("tex1s2rt77ggv6q989lr49rkgzmh5slsksa9khdgte"), "main", "t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC"},
+		{bech32mEncode("textest", p2pkh.Data), "test", (&Transparent{Kind: P2PKH, Hash: [20]byte(p2pkh.Data)}).Encode("test")},
+		{encodeUnified("u", "u", p2pkh, sapling, orchard), "main", p2pkhAddr},
+		{encodeUnified("u", "u", p2pkh, orchard, unknown), "main", p2pkhAddr},
+		{encodeUnified("utest", "utest", p2sh, sapling), "test", p2shTestAddr},
+		{encodeUnified("uregtest", "uregtest", p2sh, orchard), "regtest", p2shTestAddr},
+		// Chains without known networks take the address's.
+		{encodeUnified("utest", "utest", p2sh, sapling), "darkside", p2shTestAddr},
+	} {
+		got, err := TransparentReceiver(tt.addr, tt.chainName)
+		if err != nil {
+			t.Fatal(tt.addr, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: got %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+
+	ua := encodeUnified("u", "u", p2pkh, orchard)
+	for _, tt := range []struct {
+		addr      string
+		chainName string
+		err       string
+	}{
+		{encodeUnified("u", "u", sapling, orchard), "main", "no transparent receiver"},
+		{encodeUnified("u", "u", p2pkh, p2sh, orchard), "main", "both P2PKH and P2SH"},
+		{encodeUnified("u", "u", p2pkh, Receiver{0xe0, make([]byte, 20)}), "main", "no shielded receiver"},
+		{encodeUnified("u", "u", orchard, p2pkh), "main", "increasing order"},
+		{encodeUnified("u", "u", p2pkh, orchard, orchard), "main", "increasing order"},
+		{encodeUnified("u", "u", p2pkh, Receiver{TypecodeOrchard, orchard.Data[1:]}), "main", "has length 42, want 43"},
+		{encodeUnified("u", "utest", p2pkh, orchard), "main", "padding"},
+		{encodeUnified("utest", "utest", p2pkh, orchard), "main", "is a testnet address, but the chain is mainnet"},
+		{encodeUnified("uregtest", "uregtest", p2pkh, orchard), "test", "is a regtest address, but the chain is testnet"},
+		{"tex1s2rt77ggv6q989lr49rkgzmh5slsksa9khdgte", "test", "is a mainnet address, but the chain is testnet"},
+		{bech32mEncode("tex", p2pkh.Data[1:]), "main", "decodes to 19 bytes, want 20"},
+		{bech32mEncode("u", make([]byte, 47)), "main", "has 47 bytes"},
+		{ua[:len(ua)-1] + "q", "main", "checksum mismatch"},
+		{"u1" + strings.Repeat("q", maxUnifiedLen), "main", "want at most"},
+		{"tex1" + strings.Repeat("q", maxTEXLen), "main", "want at most 90"},
+		{"u1" + ua[2:len(ua)/2] + strings.ToUpper(ua[len(ua)/2:]), "main", "mixed-case"},
+		{"u1" + ua[2:len(ua)/2] + "b" + ua[len(ua)/2+1:], "main", "invalid Bech32m character 'b'"},
+	} {
+		_, err := TransparentReceiver(tt.addr, tt.chainName)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("%s: got error %v, want %q", tt.addr, err, tt.err)
+		}
+	}
+
+	_, err := TransparentReceiver(encodeUnified("u", "u", sapling, orchard), "main")
+	if !errors.Is(err, ErrNoTransparentReceiver) {
+		t.Fatal("unexpected error", err)
+	}
+}
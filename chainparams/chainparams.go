@@ -0,0 +1,227 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+// Package chainparams holds the consensus and encoding parameters of each
+// Zcash network that lightwalletd needs: network upgrade activation heights
+// and consensus branch IDs, address prefixes and human-readable parts,
+// proof-of-work limits and checkpoints.
+package chainparams
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Consensus branch IDs of the network upgrades (ZIP 200).
+const (
+	OverwinterBranchID uint32 = 0x5ba81b19
+	SaplingBranchID    uint32 = 0x76b809bb
+	BlossomBranchID    uint32 = 0x2bb40e60
+	HeartwoodBranchID  uint32 = 0xf5b9230b
+	CanopyBranchID     uint32 = 0xe9ff75a6
+	NU5BranchID        uint32 = 0xc2d6d0b4
+	NU6BranchID        uint32 = 0xc8e71055
+	NU6_1BranchID      uint32 = 0x4dec4df0
+	NU6_3BranchID      uint32 = 0x37a5165b
+)
+
+// Upgrade is a network upgrade. ActivationHeight is zero if the upgrade
+// isn't scheduled on the network (or, on regtest and darkside, if it's
+// configured rather than fixed).
+type Upgrade struct {
+	Name             string
+	BranchID         uint32
+	ActivationHeight int
+}
+
+// Checkpoint is a block the chain is known to contain. The hash is in
+// big-endian (display) hex, as zcashd and zebrad report it.
+type Checkpoint struct {
+	Height int
+	Hash   string
+}
+
+// AddressEncoding holds the encodings of a network's addresses: the two-byte
+// Base58Check version prefixes of its transparent addresses, and the Bech32m
+// human-readable parts of its unified (ZIP 316) and TEX (ZIP 320) addresses.
+type AddressEncoding struct {
+	NetworkName string
+	P2PKHPrefix [2]byte
+	P2SHPrefix  [2]byte
+	UnifiedHRP  string
+	TEXHRP      string
+}
+
+// Params are the parameters of a network.
+type Params struct {
+	// Name is the chain name, as getblockchaininfo reports it.
+	Name string
+	// Upgrades are the network upgrades, in activation order.
+	Upgrades []Upgrade
+	// Addresses is nil if addresses of any network are accepted (darkside).
+	Addresses *AddressEncoding
+	// PowLimit is the proof-of-work limit (the easiest allowed target), in
+	// big-endian hex, or "" if it isn't checked.
+	PowLimit string
+	// Checkpoints are in increasing height order.
+	Checkpoints []Checkpoint
+}
+
+// The address encodings of each network.
+var (
+	MainnetAddresses = &AddressEncoding{
+		NetworkName: "mainnet",
+		P2PKHPrefix: [2]byte{0x1c, 0xb8},
+		P2SHPrefix:  [2]byte{0x1c, 0xbd},
+		UnifiedHRP:  "u",
+		TEXHRP:      "tex",
+	}
+	TestnetAddresses = &AddressEncoding{
+		NetworkName: "testnet",
+		P2PKHPrefix: [2]byte{0x1d, 0x25},
+		P2SHPrefix:  [2]byte{0x1c, 0xba},
+		UnifiedHRP:  "utest",
+		TEXHRP:      "textest",
+	}
+	// Regtest uses the testnet transparent prefixes.
+	RegtestAddresses = &AddressEncoding{
+		NetworkName: "regtest",
+		P2PKHPrefix: [2]byte{0x1d, 0x25},
+		P2SHPrefix:  [2]byte{0x1c, 0xba},
+		UnifiedHRP:  "uregtest",
+		TEXHRP:      "texregtest",
+	}
+)
+
+// AddressEncodings returns the address encodings of all networks.
+func AddressEncodings() []*AddressEncoding {
+	return []*AddressEncoding{MainnetAddresses, TestnetAddresses, RegtestAddresses}
+}
+
+// Mainnet is the Zcash main network.
+var Mainnet = &Params{
+	Name: "main",
+	Upgrades: []Upgrade{
+		{"Overwinter", OverwinterBranchID, 347500},
+		{"Sapling", SaplingBranchID, 419200},
+		{"Blossom", BlossomBranchID, 653600},
+		{"Heartwood", HeartwoodBranchID, 903000},
+		{"Canopy", CanopyBranchID, 1046400},
+		{"NU5", NU5BranchID, 1687104},
+		{"NU6", NU6BranchID, 2726400},
+		{"NU6.1", NU6_1BranchID, 3146400},
+		{"NU6.3", NU6_3BranchID, 0},
+	},
+	Addresses: MainnetAddresses,
+	PowLimit:  "0007ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	Checkpoints: []Checkpoint{
+		{0, "00040fe8ec8471911baa1db1266ea15dd06b4a8a5c453883c000b031973dce08"},
+		{419200, "00000000025a57200d898ac7f21e26bf29028bbe96ec46e05b2c17cc9db9e4f3"},
+	},
+}
+
+// Testnet is the Zcash test network.
+var Testnet = &Params{
+	Name: "test",
+	Upgrades: []Upgrade{
+		{"Overwinter", OverwinterBranchID, 207500},
+		{"Sapling", SaplingBranchID, 280000},
+		{"Blossom", BlossomBranchID, 584000},
+		{"Heartwood", HeartwoodBranchID, 903800},
+		{"Canopy", CanopyBranchID, 1028500},
+		{"NU5", NU5BranchID, 1842420},
+		{"NU6", NU6BranchID, 2976000},
+		{"NU6.1", NU6_1BranchID, 3536500},
+		{"NU6.3", NU6_3BranchID, 0},
+	},
+	Addresses: TestnetAddresses,
+	PowLimit:  "07ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	Checkpoints: []Checkpoint{
+		{280000, "000420e7fcc3a49d729479fb0b560dd7b8617b178a08e9e389620a9d1dd6361a"},
+		{380640, "000a5e44b3b238d0cc36de7c0cb1ae5ac6e16f8727173abd295a83ebfa073b91"},
+	},
+}
+
+// Regtest is a local regression-test network; its activation heights are
+// configured by the node.
+var Regtest = &Params{
+	Name:      "regtest",
+	Upgrades:  unscheduled(Mainnet.Upgrades),
+	Addresses: RegtestAddresses,
+	PowLimit:  "0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f",
+}
+
+// Darkside is lightwalletd's own test mode, whose chain is whatever the test
+// driver stages; its Sapling activation height and branch ID are given to
+// DarksideReset.
+var Darkside = &Params{
+	Name:     "darkside",
+	Upgrades: unscheduled(Mainnet.Upgrades),
+}
+
+func unscheduled(upgrades []Upgrade) []Upgrade {
+	r := make([]Upgrade, len(upgrades))
+	for i, u := range upgrades {
+		r[i] = Upgrade{Name: u.Name, BranchID: u.BranchID}
+	}
+	return r
+}
+
+var networks = map[string]*Params{
+	Mainnet.Name:  Mainnet,
+	Testnet.Name:  Testnet,
+	Regtest.Name:  Regtest,
+	Darkside.Name: Darkside,
+}
+
+// Lookup returns the parameters of the named chain (as getblockchaininfo
+// names it, or "darkside"), or nil if it isn't known.
+func Lookup(chainName string) *Params {
+	return networks[chainName]
+}
+
+// Upgrade returns the named network upgrade.
+func (p *Params) Upgrade(name string) (Upgrade, bool) {
+	for _, u := range p.Upgrades {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return Upgrade{}, false
+}
+
+// SaplingActivationHeight returns the Sapling activation height, or zero if
+// the network doesn't fix it.
+func (p *Params) SaplingActivationHeight() int {
+	u, _ := p.Upgrade("Sapling")
+	return u.ActivationHeight
+}
+
+// BranchIDAt returns the consensus branch ID in effect at the given height:
+// that of the last upgrade activated at or below it, or zero (Sprout) if
+// none is. Only upgrades with fixed activation heights are considered.
+func (p *Params) BranchIDAt(height int) uint32 {
+	var branchID uint32
+	for _, u := range p.Upgrades {
+		if u.ActivationHeight > 0 && u.ActivationHeight <= height {
+			branchID = u.BranchID
+		}
+	}
+	return branchID
+}
+
+// PowLimitInt returns the proof-of-work limit, or nil if it isn't checked.
+func (p *Params) PowLimitInt() *big.Int {
+	if p.PowLimit == "" {
+		return nil
+	}
+	n, _ := new(big.Int).SetString(p.PowLimit, 16)
+	return n
+}
+
+// BranchIDString returns the branch ID in the form getblockchaininfo uses
+// for its upgrades and consensus fields (eight lowercase hex digits).
+func BranchIDString(branchID uint32) string {
+	return fmt.Sprintf("%08x", branchID)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package chainparams
+
+import (
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"main", "test", "regtest", "darkside"} {
+		p := Lookup(name)
+		if p == nil || p.Name != name {
+			t.Fatalf("Lookup(%q) = %v", name, p)
+		}
+	}
+	if p := Lookup("mainnet"); p != nil {
+		t.Fatal("unexpected params for unknown chain", p.Name)
+	}
+	if Darkside.Addresses != nil {
+		t.Fatal("darkside should accept addresses of any network")
+	}
+}
+
+func TestSaplingActivationHeight(t *testing.T) {
+	tests := []struct {
+		params *Params
+		want   int
+	}{
+		{Mainnet, 419200},
+		{Testnet, 280000},
+		{Regtest, 0},
+		{Darkside, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.params.SaplingActivationHeight(); got != tt.want {
+			t.Errorf("%s: SaplingActivationHeight() = %d, want %d", tt.params.Name, got, tt.want)
+		}
+	}
+}
+
+func TestBranchIDAt(t *testing.T) {
+	tests := []struct {
+		height int
+		want   uint32
+	}{
+		{0, 0},
+		{347499, 0},
+		{347500, OverwinterBranchID},
+		{419199, OverwinterBranchID},
+		{419200, SaplingBranchID},
+		{1687104, NU5BranchID},
+		{3146400, NU6_1BranchID},
+	}
+	for _, tt := range tests {
+		if got := Mainnet.BranchIDAt(tt.height); got != tt.want {
+			t.Errorf("BranchIDAt(%d) = %08x, want %08x", tt.height, got, tt.want)
+		}
+	}
+	if got := Regtest.BranchIDAt(1000000); got != 0 {
+		t.Errorf("regtest BranchIDAt = %08x, want 0", got)
+	}
+}
+
+func TestPowLimitInt(t *testing.T) {
+	if Mainnet.PowLimitInt() == nil || Testnet.PowLimitInt() == nil || Regtest.PowLimitInt() == nil {
+		t.Fatal("missing proof-of-work limit")
+	}
+	if Darkside.PowLimitInt() != nil {
+		t.Fatal("darkside shouldn't check proof of work")
+	}
+	if Mainnet.PowLimitInt().Cmp(Testnet.PowLimitInt()) >= 0 {
+		t.Fatal("mainnet limit should be harder than testnet's")
+	}
+}
+
+func TestBranchIDString(t *testing.T) {
+	if s := BranchIDString(SaplingBranchID); s != "76b809bb" {
+		t.Fatal("unexpected Sapling branch ID string", s)
+	}
+}
@@ -68,6 +68,19 @@ var rootCmd = &cobra.Command{
 			PingEnable:          viper.GetBool("ping-very-insecure"),
 			Darkside:            viper.GetBool("darkside-very-insecure"),
 			DarksideTimeout:     viper.GetUint64("darkside-timeout"),
+			RawArchive:          viper.GetBool("raw-archive"),
+			RawArchiveBlocks:    viper.GetInt("raw-archive-blocks"),
+			TaddrIndex:          viper.GetBool("taddr-index"),
+			TaddrTxConcurrency:  viper.GetInt("taddr-tx-fetch-concurrency"),
+			TaddrTxTimeout:      viper.GetInt("taddr-tx-timeout"),
+			MaxReorgDepth:       viper.GetInt("max-reorg-depth"),
+			QuarantineFailFast:  viper.GetBool("quarantine-fail-fast"),
+			ResolvePrevouts:     viper.GetBool("resolve-prevouts"),
+			PrevoutStoreSize:    viper.GetInt("prevout-store-size"),
+			VerifyPoW:           viper.GetBool("verify-pow"),
+			VerifyMerkleRoot:    viper.GetBool("verify-merkle-root"),
+			VerifyBackendJSON:   viper.GetBool("verify-against-backend"),
+			RejectMismatches:    viper.GetBool("reject-backend-mismatches"),
 		}
 
 		common.Log.Debugf("Options: %#v\n", opts)
@@ -315,11 +328,28 @@ func startServer(opts *common.Options) error {
 		os.Stderr.WriteString(fmt.Sprintf("\n  ** Can't create db directory: %s\n\n", dbPath))
 		os.Exit(1)
 	}
+	if opts.ResolvePrevouts {
+		common.Prevouts = common.NewPrevoutStore(opts.PrevoutStoreSize)
+	}
+	common.VerifyMerkleRoots = opts.VerifyMerkleRoot
+	frontend.TaddrTxFetchConcurrency = max(opts.TaddrTxConcurrency, 1)
+	if opts.TaddrTxTimeout > 0 {
+		frontend.TaddrTxTimeout = time.Duration(opts.TaddrTxTimeout) * time.Second
+	}
+	common.VerifyAgainstBackend = opts.VerifyBackendJSON && !opts.Darkside
+	common.RejectBackendMismatches = opts.RejectMismatches
+	if opts.VerifyPoW {
+		common.ProofOfWorkLimit = common.PowLimit(chainName)
+		if common.ProofOfWorkLimit == nil {
+			common.Log.Warning("no proof-of-work limit for chain ", chainName, ", not verifying proof of work")
+		}
+	}
 	var cache *common.BlockCache
 	if opts.NoCache {
 		lengthsName, blocksName := common.DbFileNames(dbPath, chainName)
 		os.Remove(lengthsName)
 		os.Remove(blocksName)
+		os.RemoveAll(common.RawArchiveDir(dbPath, chainName))
 	} else {
 		syncFromHeight := opts.SyncFromHeight
 		if opts.Redownload {
@@ -328,6 +358,15 @@ func startServer(opts *common.Options) error {
 		// Previously, we started the cache at the Sapling activation height,
 		// because earlier blocks weren't relevant; now we start at height 0.
 		cache = common.NewBlockCache(dbPath, chainName, 0, syncFromHeight)
+		cache.SetMaxReorgDepth(opts.MaxReorgDepth)
+		cache.Quarantine().SetFailFast(opts.QuarantineFailFast)
+		http.Handle("/quarantine", cache.Quarantine())
+		if opts.RawArchive {
+			cache.SetRawArchive(common.NewRawBlockArchive(dbPath, chainName, opts.RawArchiveBlocks))
+		}
+		if opts.TaddrIndex {
+			cache.SetTaddrIndex(common.NewTaddrIndex())
+		}
 	}
 	if !opts.Darkside {
 		if !opts.NoCache {
@@ -428,6 +467,19 @@ func init() {
 	rootCmd.Flags().Bool("darkside-very-insecure", false, "run with GRPC-controllable mock zebrad for integration testing (shuts down after 30 minutes)")
 	rootCmd.Flags().Int("darkside-timeout", 30, "override 30 minute default darkside timeout")
 	rootCmd.Flags().String("donation-address", "", "Zcash UA address to accept donations for operating this server")
+	rootCmd.Flags().Bool("raw-archive", false, "also keep the raw (full) blocks on disk, alongside the compact blocks cache (without it, an existing archive is left in place but not updated)")
+	rootCmd.Flags().Int("raw-archive-blocks", 0, "number of most recent raw blocks to keep (0 means all); requires --raw-archive")
+	rootCmd.Flags().Bool("taddr-index", false, "build an in-memory transparent address index from the cached blocks, so the transparent address RPCs don't need the backend's address index")
+	rootCmd.Flags().Int("taddr-tx-fetch-concurrency", frontend.DefaultTaddrTxFetchConcurrency, "number of transactions GetTaddressTransactions fetches in parallel")
+	rootCmd.Flags().Int("taddr-tx-timeout", int(frontend.DefaultTaddrTxTimeout/time.Second), "time limit, in seconds, for a GetTaddressTransactions or GetTaddressHistory call")
+	rootCmd.Flags().Int("max-reorg-depth", common.DefaultMaxReorgDepth, "halt block ingestion rather than apply a deeper reorg (0 means no limit)")
+	rootCmd.Flags().Bool("resolve-prevouts", false, "include the value and script of the output each transparent input spends in compact blocks, and their fees")
+	rootCmd.Flags().Int("prevout-store-size", common.DefaultPrevoutStoreSize, "number of recent transparent outputs to keep for resolving prevouts; requires --resolve-prevouts")
+	rootCmd.Flags().Bool("verify-pow", false, "verify the Equihash solution and proof of work of each block, rejecting and quarantining blocks that fail")
+	rootCmd.Flags().Bool("verify-merkle-root", false, "verify each block's merkle root against its transactions, rejecting and quarantining blocks that fail")
+	rootCmd.Flags().Bool("verify-against-backend", false, "compare the parse of each block with the backend's decoded transactions (from getblock with verbosity 2), quarantining a copy of blocks that differ")
+	rootCmd.Flags().Bool("reject-backend-mismatches", false, "reject, rather than add to the cache, blocks that differ from the backend's decoded transactions; requires --verify-against-backend")
+	rootCmd.Flags().Bool("quarantine-fail-fast", false, "exit, rather than keep retrying, when a block can't be parsed (it's quarantined either way)")
 
 	viper.BindPFlag("grpc-bind-addr", rootCmd.Flags().Lookup("grpc-bind-addr"))
 	viper.SetDefault("grpc-bind-addr", "127.0.0.1:9067")
@@ -470,6 +522,32 @@ func init() {
 	viper.BindPFlag("darkside-timeout", rootCmd.Flags().Lookup("darkside-timeout"))
 	viper.SetDefault("darkside-timeout", 30)
 	viper.BindPFlag("donation-address", rootCmd.Flags().Lookup("donation-address"))
+	viper.BindPFlag("raw-archive", rootCmd.Flags().Lookup("raw-archive"))
+	viper.SetDefault("raw-archive", false)
+	viper.BindPFlag("raw-archive-blocks", rootCmd.Flags().Lookup("raw-archive-blocks"))
+	viper.SetDefault("raw-archive-blocks", 0)
+	viper.BindPFlag("taddr-index", rootCmd.Flags().Lookup("taddr-index"))
+	viper.SetDefault("taddr-index", false)
+	viper.BindPFlag("taddr-tx-fetch-concurrency", rootCmd.Flags().Lookup("taddr-tx-fetch-concurrency"))
+	viper.SetDefault("taddr-tx-fetch-concurrency", frontend.DefaultTaddrTxFetchConcurrency)
+	viper.BindPFlag("taddr-tx-timeout", rootCmd.Flags().Lookup("taddr-tx-timeout"))
+	viper.SetDefault("taddr-tx-timeout", int(frontend.DefaultTaddrTxTimeout/time.Second))
+	viper.BindPFlag("max-reorg-depth", rootCmd.Flags().Lookup("max-reorg-depth"))
+	viper.SetDefault("max-reorg-depth", common.DefaultMaxReorgDepth)
+	viper.BindPFlag("quarantine-fail-fast", rootCmd.Flags().Lookup("quarantine-fail-fast"))
+	viper.SetDefault("quarantine-fail-fast", false)
+	viper.BindPFlag("resolve-prevouts", rootCmd.Flags().Lookup("resolve-prevouts"))
+	viper.SetDefault("resolve-prevouts", false)
+	viper.BindPFlag("prevout-store-size", rootCmd.Flags().Lookup("prevout-store-size"))
+	viper.SetDefault("prevout-store-size", common.DefaultPrevoutStoreSize)
+	viper.BindPFlag("verify-pow", rootCmd.Flags().Lookup("verify-pow"))
+	viper.SetDefault("verify-pow", false)
+	viper.BindPFlag("verify-merkle-root", rootCmd.Flags().Lookup("verify-merkle-root"))
+	viper.SetDefault("verify-merkle-root", false)
+	viper.BindPFlag("verify-against-backend", rootCmd.Flags().Lookup("verify-against-backend"))
+	viper.SetDefault("verify-against-backend", false)
+	viper.BindPFlag("reject-backend-mismatches", rootCmd.Flags().Lookup("reject-backend-mismatches"))
+	viper.SetDefault("reject-backend-mismatches", false)
 
 	logger.SetFormatter(&logrus.TextFormatter{
 		//DisableColors:          true,
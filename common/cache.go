@@ -22,13 +22,69 @@ import (
 type BlockCache struct {
 	lengthsName, blocksName string // pathnames
 	lengthsFile, blocksFile *os.File
-	starts                  []int64  // Starting offset of each block within blocksFile
-	firstBlock              int      // height of the first block in the cache (usually Sapling activation)
-	nextBlock               int      // height of the first block not in the cache
-	latestHash              hash32.T // hash of the most recent (highest height) block, for detecting reorgs.
+	starts                  []int64          // Starting offset of each block within blocksFile
+	firstBlock              int              // height of the first block in the cache (usually Sapling activation)
+	nextBlock               int              // height of the first block not in the cache
+	latestHash              hash32.T         // hash of the most recent (highest height) block, for detecting reorgs.
+	archive                 *RawBlockArchive // optional archive of raw blocks, nil if disabled
+	taddrIndex              *TaddrIndex      // optional transparent address index, nil if disabled
+	journal                 *ReorgJournal    // record of the reorgs the ingestor has observed
+	quarantine              *Quarantine      // blocks the ingestor could not parse
+	checkpoints             []Checkpoint     // blocks a reorg must never remove
+	maxReorgDepth           int              // deepest reorg the ingestor applies, 0 means no limit
 	mutex                   sync.RWMutex
 }
 
+// SetRawArchive attaches a raw block archive to the cache; the block
+// ingestor then archives each block's raw bytes as it adds the block, and
+// reorgs are applied to both. Archived blocks the cache doesn't have (or
+// has replaced) are discarded first; the ingestor fetches the blocks the
+// archive is missing below the cache's top.
+// (No locking here, we assume this is single-threaded.)
+func (c *BlockCache) SetRawArchive(a *RawBlockArchive) {
+	a.matchCache(c)
+	c.archive = a
+}
+
+// SetTaddrIndex attaches a transparent address index to the cache; the
+// block ingestor then builds it from the cached blocks and keeps it up to
+// date as it adds blocks, and reorgs are applied to both.
+// (No locking here, we assume this is single-threaded.)
+func (c *BlockCache) SetTaddrIndex(x *TaddrIndex) {
+	c.taddrIndex = x
+	x.setUndoDepth(c.maxReorgDepth)
+}
+
+// TaddrIndex returns the transparent address index, or nil if there isn't one.
+func (c *BlockCache) TaddrIndex() *TaddrIndex {
+	return c.taddrIndex
+}
+
+// Quarantine returns the directory of blocks the ingestor could not parse.
+func (c *BlockCache) Quarantine() *Quarantine {
+	return c.quarantine
+}
+
+// SetMaxReorgDepth sets the deepest reorg (number of blocks removed) the
+// block ingestor applies; a deeper one halts ingestion. Zero means no limit.
+// (No locking here, we assume this is single-threaded.)
+func (c *BlockCache) SetMaxReorgDepth(depth int) {
+	c.maxReorgDepth = depth
+	if c.taddrIndex != nil {
+		c.taddrIndex.setUndoDepth(depth)
+	}
+}
+
+// RawArchive returns the raw block archive, or nil if there isn't one.
+func (c *BlockCache) RawArchive() *RawBlockArchive {
+	return c.archive
+}
+
+// ReorgJournal returns the record of the reorgs the ingestor has observed.
+func (c *BlockCache) ReorgJournal() *ReorgJournal {
+	return c.journal
+}
+
 // GetNextHeight returns the height of the lowest unobtained block.
 func (c *BlockCache) GetNextHeight() int {
 	c.mutex.RLock()
@@ -145,6 +201,13 @@ func (c *BlockCache) setLatestHash() {
 // Reset is used only for darkside testing.
 func (c *BlockCache) Reset(startHeight int) {
 	c.clearDbFiles() // empty the cache
+	if c.archive != nil {
+		c.archive.Reset()
+	}
+	if c.taddrIndex != nil {
+		c.taddrIndex.Reset()
+	}
+	c.journal.Reset()
 	c.firstBlock = startHeight
 	c.nextBlock = startHeight
 }
@@ -161,6 +224,10 @@ func NewBlockCache(dbPath string, chainName string, startHeight int, syncFromHei
 	if err := os.MkdirAll(filepath.Join(dbPath, chainName), 0755); err != nil {
 		Log.Fatal("mkdir ", dbPath, " failed: ", err)
 	}
+	c.journal = NewReorgJournal(dbPath, chainName)
+	c.quarantine = NewQuarantine(dbPath, chainName)
+	c.checkpoints = Checkpoints(chainName)
+	c.maxReorgDepth = DefaultMaxReorgDepth
 	c.blocksFile, err = os.OpenFile(c.blocksName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
 	if err != nil {
 		Log.Fatal("open ", c.blocksName, " failed: ", err)
@@ -313,6 +380,12 @@ func (c *BlockCache) Reorg(height int) {
 		// Timing window, ignore this request
 		return
 	}
+	if c.archive != nil {
+		c.archive.Reorg(height)
+	}
+	if c.taddrIndex != nil {
+		c.taddrIndex.Reorg(height)
+	}
 	// Remove the end of the cache.
 	c.nextBlock = height
 	newCacheLen := height - c.firstBlock
@@ -364,6 +437,9 @@ func (c *BlockCache) GetLatestHeight() int {
 func (c *BlockCache) Sync() {
 	c.lengthsFile.Sync()
 	c.blocksFile.Sync()
+	if c.archive != nil {
+		c.archive.Sync()
+	}
 }
 
 // Close is Currently used only for testing.
@@ -377,4 +453,8 @@ func (c *BlockCache) Close() {
 		c.blocksFile.Close()
 		c.blocksFile = nil
 	}
+	if c.archive != nil {
+		c.archive.Close()
+	}
+	c.journal.Close()
 }
@@ -0,0 +1,76 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/zcash/lightwalletd/chainparams"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+var ingestorHalted = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "lightwalletd_ingestor_halted",
+	Help: "Set to 1 if the block ingestor has halted rather than apply an unsafe reorg.",
+})
+
+// DefaultMaxReorgDepth is the deepest reorg the block ingestor applies by
+// default; zcashd itself refuses to reorg more than 99 blocks.
+const DefaultMaxReorgDepth = 100
+
+// Checkpoint is a block the chain is known to contain.
+type Checkpoint = chainparams.Checkpoint
+
+// Checkpoints returns the built-in checkpoints for the given chain (none for
+// regtest, darkside or unknown chains).
+func Checkpoints(chainName string) []Checkpoint {
+	if params := chainparams.Lookup(chainName); params != nil {
+		return params.Checkpoints
+	}
+	return nil
+}
+
+// checkReorg returns an error if the block ingestor must not remove the block
+// at the given height, which would make the reorg in progress the given depth
+// (number of blocks removed).
+func (c *BlockCache) checkReorg(height int, depth int) error {
+	if c.maxReorgDepth > 0 && depth > c.maxReorgDepth {
+		return fmt.Errorf("reorg depth %d exceeds the maximum of %d (--max-reorg-depth)",
+			depth, c.maxReorgDepth)
+	}
+	for _, cp := range c.checkpoints {
+		if cp.Height >= height {
+			return fmt.Errorf("reorg would remove block %d, at or below checkpoint %d %s",
+				height, cp.Height, cp.Hash)
+		}
+	}
+	return nil
+}
+
+// checkCheckpoint returns an error if there is a checkpoint at the given
+// height and the block (hash in display hex) doesn't match it.
+func (c *BlockCache) checkCheckpoint(height int, hash string) error {
+	for _, cp := range c.checkpoints {
+		if cp.Height == height && cp.Hash != hash {
+			return fmt.Errorf("block %d %s does not match checkpoint %s",
+				height, hash, cp.Hash)
+		}
+	}
+	return nil
+}
+
+// haltIngestor reports that the block ingestor has stopped rather than
+// rewrite the cache. The cache continues to serve the blocks it has; an
+// operator must investigate the backend before restarting lightwalletd.
+func haltIngestor(c *BlockCache, err error) {
+	ingestorHalted.Set(1)
+	Log.WithFields(logrus.Fields{
+		"error":  err,
+		"height": c.GetNextHeight() - 1,
+	}).Error("HALTING block ingestion: the backend's chain conflicts with this server's history")
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/zcash/lightwalletd/chainparams"
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser"
 	"github.com/zcash/lightwalletd/walletrpc"
@@ -71,8 +72,27 @@ type Options struct {
 	PingEnable          bool   `json:"ping_enable"`
 	Darkside            bool   `json:"darkside"`
 	DarksideTimeout     uint64 `json:"darkside_timeout"`
+	RawArchive          bool   `json:"raw_archive"`
+	RawArchiveBlocks    int    `json:"raw_archive_blocks"`
+	TaddrIndex          bool   `json:"taddr_index"`
+	TaddrTxConcurrency  int    `json:"taddr_tx_fetch_concurrency"`
+	TaddrTxTimeout      int    `json:"taddr_tx_timeout"`
+	MaxReorgDepth       int    `json:"max_reorg_depth"`
+	QuarantineFailFast  bool   `json:"quarantine_fail_fast"`
+	ResolvePrevouts     bool   `json:"resolve_prevouts"`
+	PrevoutStoreSize    int    `json:"prevout_store_size"`
+	VerifyPoW           bool   `json:"verify_pow"`
+	VerifyMerkleRoot    bool   `json:"verify_merkle_root"`
+	VerifyBackendJSON   bool   `json:"verify_against_backend"`
+	RejectMismatches    bool   `json:"reject_backend_mismatches"`
 }
 
+// VerifyMerkleRoots makes getBlockFromRPC verify that each block's
+// hashMerkleRoot is the root of its txids, which must also match the txids
+// that getblock reports. Blocks that fail are rejected and quarantined. It's
+// false unless lightwalletd is run with --verify-merkle-root.
+var VerifyMerkleRoots bool
+
 // RawRequest points to the function to send an RPC request to zcashd;
 // in production, it points to frontend.NewContextRawRequest();
 // in unit tests it points to a function to mock RPCs to zcashd.
@@ -169,6 +189,21 @@ type (
 		Balance int64
 	}
 
+	// zcashd rpc "getaddressdeltas"
+	ZcashdRpcRequestGetaddressdeltas struct {
+		Addresses []string `json:"addresses"`
+		Start     uint64   `json:"start"`
+		End       uint64   `json:"end"`
+	}
+	ZcashdRpcReplyGetaddressdeltas struct {
+		Address    string
+		Txid       string
+		Index      uint32 // output index for a receive, input index for a spend
+		Satoshis   int64  // negative for a spend
+		Height     int
+		BlockIndex int // index of the transaction within its block
+	}
+
 	// zcashd rpc "getaddressutxos"
 	ZcashdRpcRequestGetaddressutxos struct {
 		Addresses []string `json:"addresses"`
@@ -305,10 +340,13 @@ func GetLightdInfo() (*walletrpc.LightdInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	// If the sapling consensus branch doesn't exist, it must be regtest
+	// If the sapling consensus branch doesn't exist, it must be regtest;
+	// otherwise fall back to the network's fixed activation height.
 	var saplingHeight int
-	if saplingJSON, ok := getblockchaininfoReply.Upgrades["76b809bb"]; ok { // Sapling ID
+	if saplingJSON, ok := getblockchaininfoReply.Upgrades[chainparams.BranchIDString(chainparams.SaplingBranchID)]; ok {
 		saplingHeight = saplingJSON.ActivationHeight
+	} else if params := chainparams.Lookup(getblockchaininfoReply.Chain); params != nil {
+		saplingHeight = params.SaplingActivationHeight()
 	}
 
 	// Find the name and activation height of the next pending network upgrade,
@@ -347,11 +385,101 @@ func GetLightdInfo() (*walletrpc.LightdInfo, error) {
 	}, nil
 }
 
-func getBlockFromRPC(ctx context.Context, height int) (*walletrpc.CompactBlock, error) {
-	// `block.ParseFromSlice` correctly parses blocks containing v5
-	// transactions, but incorrectly computes the IDs of the v5 transactions.
-	// We temporarily paper over this bug by fetching the correct txids via a
-	// verbose getblock RPC call, which returns the txids.
+// getBlockHeaderFromRPC returns the serialized header of the block at the
+// given height, using the backend's getblockhash and getblockheader rather
+// than fetching and parsing the whole block. It returns nil if there's no
+// block at the height.
+func getBlockHeaderFromRPC(ctx context.Context, height int) ([]byte, error) {
+	heightJSON, err := json.Marshal(height)
+	if err != nil {
+		return nil, err
+	}
+	blockHash, rpcErr := RawRequest(ctx, "getblockhash", []json.RawMessage{heightJSON})
+	if rpcErr != nil {
+		// Check to see if we are requesting a height the zcashd doesn't have yet
+		if (strings.Split(rpcErr.Error(), ":"))[0] == "-8" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error requesting block hash: %w", rpcErr)
+	}
+	// The reply is the hash as a JSON string, as getblockheader takes it.
+	params := []json.RawMessage{blockHash, json.RawMessage("false")}
+	result, rpcErr := RawRequest(ctx, "getblockheader", params)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("error requesting block header: %w", rpcErr)
+	}
+	var headerHex string
+	if err := json.Unmarshal(result, &headerHex); err != nil {
+		return nil, fmt.Errorf("error reading block header: %w", err)
+	}
+	header, err := hex.DecodeString(headerHex)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding block header: %w", err)
+	}
+	return header, nil
+}
+
+// getRawBlockFromRPC returns the raw (non-verbose) block with the given
+// hash (display order, as getblock reports it).
+func getRawBlockFromRPC(ctx context.Context, hash string) ([]byte, error) {
+	blockHash, err := json.Marshal(hash)
+	if err != nil {
+		Log.Fatal("getRawBlockFromRPC bad block hash", hash)
+	}
+	params := []json.RawMessage{blockHash, json.RawMessage("0")}
+	result, rpcErr := RawRequest(ctx, "getblock", params)
+
+	// For some reason, the error responses are not JSON
+	if rpcErr != nil {
+		return nil, fmt.Errorf("error requesting block: %w", rpcErr)
+	}
+
+	var blockDataHex string
+	err = json.Unmarshal(result, &blockDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JSON response: %w", err)
+	}
+
+	blockData, err := hex.DecodeString(blockDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding getblock output: %w", err)
+	}
+	return blockData, nil
+}
+
+// catchUpRawArchive adds to the raw block archive up to maxBlocks of the
+// cached blocks it's missing, fetching them from the backend by hash. These
+// are the blocks the cache added while lightwalletd ran without
+// --raw-archive. An empty archive instead starts with the next block the
+// ingestor adds.
+func catchUpRawArchive(c *BlockCache, archive *RawBlockArchive, maxBlocks int) {
+	for range maxBlocks {
+		height := archive.GetNextHeight()
+		if height == archive.GetFirstHeight() || height >= c.GetNextHeight() {
+			return
+		}
+		block := c.Get(height)
+		if block == nil {
+			return
+		}
+		data, err := getRawBlockFromRPC(context.Background(), displayHash(hash32.FromSlice(block.Hash)))
+		if err != nil {
+			Log.Warning("raw block archive catch-up at height ", height, " failed: ", err)
+			return
+		}
+		if err = archive.Add(height, data); err != nil {
+			Log.Fatal("Raw block archive add failed:", err)
+		}
+	}
+}
+
+// getBlockFromRPC returns the compact block at the given height, along with
+// the raw (serialized) block it was derived from, or nil (and nil) if the
+// backend doesn't have a block at that height yet.
+func getBlockFromRPC(ctx context.Context, height int) (*walletrpc.CompactBlock, []byte, error) {
+	// The txids come from a verbose getblock RPC call, since lightwalletd
+	// can't compute those of v6 transactions (the ones it computes are
+	// checked against them with --verify-merkle-root).
 	//
 	// Unfortunately, this RPC doesn't return the raw hex for the block,
 	// so a second getblock RPC (non-verbose) is needed (below).
@@ -372,83 +500,106 @@ func getBlockFromRPC(ctx context.Context, height int) (*walletrpc.CompactBlock,
 	if rpcErr != nil {
 		// Check to see if we are requesting a height the zcashd doesn't have yet
 		if (strings.Split(rpcErr.Error(), ":"))[0] == "-8" {
-			return nil, nil
+			return nil, nil, nil
 		}
-		return nil, fmt.Errorf("error requesting verbose block: %w", rpcErr)
+		return nil, nil, fmt.Errorf("error requesting verbose block: %w", rpcErr)
 	}
 	var block1 ZcashRpcReplyGetblock1
 	err = json.Unmarshal(result, &block1)
 	if err != nil {
 		Log.Fatal("getBlockFromRPC: Can't unmarshal block:", err)
 	}
-	blockHash, err := json.Marshal(block1.Hash)
-	if err != nil {
-		Log.Fatal("getBlockFromRPC bad block hash", block1.Hash)
-	}
 	if err := ctx.Err(); err != nil {
-		return nil, err
-	}
-	// non-verbose (raw hex) version of block
-	params = []json.RawMessage{blockHash, json.RawMessage("0")}
-	result, rpcErr = RawRequest(ctx, "getblock", params)
-
-	// For some reason, the error responses are not JSON
-	if rpcErr != nil {
-		return nil, fmt.Errorf("error requesting block: %w", rpcErr)
+		return nil, nil, err
 	}
-
-	var blockDataHex string
-	err = json.Unmarshal(result, &blockDataHex)
-	if err != nil {
-		return nil, fmt.Errorf("error reading JSON response: %w", err)
-	}
-
-	blockData, err := hex.DecodeString(blockDataHex)
+	blockData, err := getRawBlockFromRPC(ctx, block1.Hash)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding getblock output: %w", err)
+		return nil, nil, err
 	}
 
 	block := parser.NewBlock()
 	rest, err := block.ParseFromSlice(blockData)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing block: %w", err)
+		return nil, nil, &BlockParseError{Height: height, Hash: block1.Hash, Data: blockData, Err: err}
 	}
 	if len(rest) != 0 {
-		return nil, errors.New("received overlong message")
+		return nil, nil, &BlockParseError{Height: height, Hash: block1.Hash, Data: blockData,
+			Err: errors.New("received overlong message")}
 	}
 	if block.GetHeight() != height {
-		return nil, errors.New("received unexpected height block")
+		return nil, nil, errors.New("received unexpected height block")
+	}
+	if ProofOfWorkLimit != nil {
+		if err := block.VerifyProofOfWork(ProofOfWorkLimit); err != nil {
+			return nil, nil, &BlockParseError{Height: height, Hash: block1.Hash, Data: blockData,
+				Err: fmt.Errorf("invalid proof of work: %w", err)}
+		}
+	}
+	if len(block1.Tx) != block.GetTxCount() {
+		return nil, nil, &BlockParseError{Height: height, Hash: block1.Hash, Data: blockData,
+			Err: fmt.Errorf("getblock reports %d transactions, block has %d", len(block1.Tx), block.GetTxCount())}
 	}
 	for i, t := range block.Transactions() {
 		txidBigEndian, err := hash32.Decode(block1.Tx[i])
 		if err != nil {
-			return nil, fmt.Errorf("error decoding getblock txid: %w", err)
+			return nil, nil, fmt.Errorf("error decoding getblock txid: %w", err)
 		}
 		// convert from big-endian
 		t.SetTxID(hash32.Reverse(txidBigEndian))
 	}
+	// Darkside blocks' merkle roots are verified when they're staged.
+	if VerifyMerkleRoots && !DarksideEnabled {
+		if err := block.VerifyMerkleRoot(); err != nil {
+			return nil, nil, &BlockParseError{Height: height, Hash: block1.Hash, Data: blockData, Err: err}
+		}
+	}
 	r := block.ToCompact()
 	r.ChainMetadata.SaplingCommitmentTreeSize = block1.Trees.Sapling.Size
 	r.ChainMetadata.OrchardCommitmentTreeSize = block1.Trees.Orchard.Size
 	r.ChainMetadata.IronwoodCommitmentTreeSize = block1.Trees.Ironwood.Size
-	return r, nil
+	if Prevouts != nil {
+		Prevouts.resolve(ctx, block, r)
+	}
+	return r, blockData, nil
 }
 
 var (
 	ingestorRunning  bool
 	stopIngestorChan = make(chan struct{})
+	ingestorDone     chan struct{} // closed when the started ingestor returns
+)
+
+// The block ingestor waits ingestorRetryDelay before retrying a block it
+// couldn't get; while the block keeps failing to parse, it doubles the wait
+// each time, up to maxQuarantineRetryDelay.
+const (
+	ingestorRetryDelay      = 8 * time.Second
+	maxQuarantineRetryDelay = 10 * time.Minute
 )
 
 func startIngestor(c *BlockCache) {
 	if !ingestorRunning {
 		ingestorRunning = true
-		go BlockIngestor(c, 0)
+		done := make(chan struct{})
+		ingestorDone = done
+		go func() {
+			BlockIngestor(c, 0)
+			close(done)
+		}()
 	}
 }
+
+// stopIngestor stops the ingestor that startIngestor started, which may
+// already have returned by halting (see haltIngestor); either way, the
+// ingestor is then no longer halted.
 func stopIngestor() {
 	if ingestorRunning {
 		ingestorRunning = false
-		stopIngestorChan <- struct{}{}
+		select {
+		case stopIngestorChan <- struct{}{}:
+		case <-ingestorDone:
+		}
+		ingestorHalted.Set(0)
 	}
 }
 
@@ -457,6 +608,8 @@ func stopIngestor() {
 func BlockIngestor(c *BlockCache, rep int) {
 	lastLog := Time.Now()
 	lastHeightLogged := 0
+	var reorg reorgTracker
+	retryDelay := ingestorRetryDelay
 
 	// Start listening for new blocks
 	for i := 0; rep == 0 || i < rep; i++ {
@@ -486,6 +639,16 @@ func BlockIngestor(c *BlockCache, rep int) {
 		height := c.GetNextHeight()
 		if lastBestBlockHashBE == hash32.Reverse(c.GetLatestHash()) {
 			// Synced
+			if event := reorg.finish(); event != nil {
+				// The new chain is shorter than the one it replaced.
+				recordReorg(c, event)
+			}
+			if archive := c.RawArchive(); archive != nil {
+				catchUpRawArchive(c, archive, rawArchiveCatchUpBlocks)
+			}
+			if index := c.TaddrIndex(); index != nil {
+				index.CatchUp(c, taddrIndexCatchUpBlocks)
+			}
 			c.Sync()
 			if lastHeightLogged != height-1 {
 				lastHeightLogged = height - 1
@@ -496,16 +659,59 @@ func BlockIngestor(c *BlockCache, rep int) {
 			continue
 		}
 		var block *walletrpc.CompactBlock
-		block, err = getBlockFromRPC(context.Background(), height)
+		var rawBlock []byte
+		block, rawBlock, err = getBlockFromRPC(context.Background(), height)
+		if err == nil && block != nil && VerifyAgainstBackend {
+			err = checkAgainstBackend(context.Background(), c, height, rawBlock)
+		}
+		var parseErr *BlockParseError
+		if errors.As(err, &parseErr) {
+			// Retrying won't help unless the block is reorged away, so
+			// save it where a developer can reproduce the failure.
+			name := c.Quarantine().Add(parseErr)
+			Log.WithFields(logrus.Fields{
+				"height": parseErr.Height,
+				"hash":   parseErr.Hash,
+				"error":  parseErr.Err,
+				"file":   name,
+			}).Error("block quarantined: lightwalletd can't parse it")
+			if c.Quarantine().FailFast() {
+				Log.Fatal("exiting because of the unparsable block (--quarantine-fail-fast)")
+			}
+		}
 		if err != nil {
-			Log.Info("getblock ", height, " failed, will retry: ", err)
-			Time.Sleep(8 * time.Second)
+			delay := ingestorRetryDelay
+			if parseErr != nil {
+				// Parsing the same block again will likely fail too.
+				delay = retryDelay
+				retryDelay = min(2*retryDelay, maxQuarantineRetryDelay)
+			}
+			Log.Info("getblock ", height, " failed, will retry in ", delay, ": ", err)
+			Time.Sleep(delay)
 			continue
 		}
+		retryDelay = ingestorRetryDelay
 		if block != nil && c.HashMatch(hash32.FromSlice(block.PrevHash)) {
+			if err = c.checkCheckpoint(height, displayHash(hash32.FromSlice(block.Hash))); err != nil {
+				haltIngestor(c, err)
+				return
+			}
 			if err = c.Add(height, block); err != nil {
 				Log.Fatal("Cache add failed:", err)
 			}
+			// An archive that's behind the cache is caught up once the
+			// ingestor is synced; adding this block would leave a gap.
+			if archive := c.RawArchive(); archive != nil && !archive.behind(height) {
+				if err = archive.Add(height, rawBlock); err != nil {
+					Log.Fatal("Raw block archive add failed:", err)
+				}
+			}
+			if index := c.TaddrIndex(); index != nil {
+				index.CatchUp(c, 1)
+			}
+			if event := reorg.add(height, displayHash(hash32.FromSlice(block.Hash))); event != nil {
+				recordReorg(c, event)
+			}
 			// Don't log these too often.
 			if DarksideEnabled || Time.Now().Sub(lastLog).Seconds() >= 4 {
 				lastLog = Time.Now()
@@ -520,18 +726,32 @@ func BlockIngestor(c *BlockCache, rep int) {
 			Time.Sleep(120 * time.Second)
 			continue
 		}
+		if err = c.checkReorg(height-1, reorg.depth()+1); err != nil {
+			haltIngestor(c, err)
+			return
+		}
 		Log.Info("REORG: dropping block ", height-1, " ", displayHash(c.GetLatestHash()))
+		reorg.orphan(height-1, displayHash(c.GetLatestHash()))
 		c.Reorg(height - 1)
 	}
 }
 
+// recordReorg adds a completed reorg to the cache's reorg journal.
+func recordReorg(c *BlockCache, event *ReorgEvent) {
+	Log.WithFields(logrus.Fields{
+		"depth":    event.Depth,
+		"orphaned": event.Orphaned,
+		"new":      event.New,
+	}).Warning("REORG: complete")
+	c.ReorgJournal().Record(event)
+}
+
 // GetBlock returns the compact block at the requested height, first by querying
 // the cache, then, if not found, will request the block from zcashd. It returns
 // nil if no block exists at this height.
 // This returns gRPC-compatible errors.
 func GetBlock(ctx context.Context, cache *BlockCache, height int) (*walletrpc.CompactBlock, error) {
 	// First, check the cache to see if we have the block
-	var block *walletrpc.CompactBlock
 	if cache != nil {
 		block := cache.Get(height)
 		if block != nil {
@@ -540,7 +760,7 @@ func GetBlock(ctx context.Context, cache *BlockCache, height int) (*walletrpc.Co
 	}
 
 	// Not in the cache
-	block, err := getBlockFromRPC(ctx, height)
+	block, _, err := getBlockFromRPC(ctx, height)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument,
 			"GetBlock: getblock failed, error: %s", err.Error())
@@ -553,6 +773,55 @@ func GetBlock(ctx context.Context, cache *BlockCache, height int) (*walletrpc.Co
 	return block, nil
 }
 
+// GetBlockHeader returns a compact block with no transactions or chain
+// metadata, only the height, hashes, time, and serialized header of the block
+// at the requested height. The compact block cache doesn't store headers, so
+// the header comes from the raw block archive if it has the block, else from
+// zcashd (without fetching the whole block).
+// This returns gRPC-compatible errors.
+func GetBlockHeader(ctx context.Context, cache *BlockCache, height int) (*walletrpc.CompactBlock, error) {
+	var header []byte
+	if cache != nil && cache.RawArchive() != nil {
+		if data := cache.RawArchive().Get(height); data != nil {
+			header = rawBlockHeader(data)
+		}
+	}
+	if header == nil {
+		var err error
+		header, err = getBlockHeaderFromRPC(ctx, height)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable,
+				"GetBlockHeader: getblockheader failed, error: %s", err.Error())
+		}
+		if header == nil {
+			return nil, status.Errorf(codes.OutOfRange,
+				"GetBlockHeader: block %d is newer than the latest block", height)
+		}
+	}
+	hdr := parser.NewBlockHeader()
+	if rest, err := hdr.ParseFromSlice(header); err != nil || len(rest) > 0 {
+		return nil, status.Errorf(codes.Internal,
+			"GetBlockHeader: can't parse the header of block %d", height)
+	}
+	return &walletrpc.CompactBlock{
+		Height:   uint64(height),
+		Hash:     hash32.ToSlice(hdr.GetEncodableHash()),
+		PrevHash: hash32.ToSlice(hdr.HashPrevBlock),
+		Time:     hdr.Time,
+		Header:   header,
+	}, nil
+}
+
+// rawBlockHeader returns the serialized header at the start of the raw block,
+// or nil if it can't be parsed.
+func rawBlockHeader(data []byte) []byte {
+	rest, err := parser.NewBlockHeader().ParseFromSlice(data)
+	if err != nil {
+		return nil
+	}
+	return data[:len(data)-len(rest)]
+}
+
 // FilterTxPool returns a new transaction that is a subset of the argument tx
 // (which is not modified), with only those parts that are requested by the
 // pool type argument. Returns nil if the tx ends up with no components.
@@ -623,6 +892,33 @@ func GetBlockRange(ctx context.Context, cache *BlockCache, blockOut chan<- *wall
 		}
 		return
 	}
+	sendBlockRange(ctx, "GetBlockRange", span, blockOut, errOut, func(height int) (*walletrpc.CompactBlock, error) {
+		block, err := GetBlock(ctx, cache, height)
+		if err != nil {
+			return nil, err
+		}
+		// Note that we do want to return blocks that have had all of its transactions filtered,
+		// as we have done in the past.
+		block.Vtx = filterBlockPool(block.Vtx, span.PoolTypes)
+		return block, nil
+	})
+}
+
+// GetBlockHeaderRange is GetBlockRange for the header-only blocks that
+// GetBlockHeader returns, so that clients can verify the chain's proof of
+// work and linkage without downloading its transactions.
+func GetBlockHeaderRange(ctx context.Context, cache *BlockCache, blockOut chan<- *walletrpc.CompactBlock, errOut chan<- error, span *walletrpc.BlockRange) {
+	sendBlockRange(ctx, "GetBlockHeaderRange", span, blockOut, errOut, func(height int) (*walletrpc.CompactBlock, error) {
+		return GetBlockHeader(ctx, cache, height)
+	})
+}
+
+// sendBlockRange sends the blocks that getBlock returns for each height in
+// the span to blockOut, in the span's order, then nil (or the first error) to
+// errOut. The method name is used in errors.
+func sendBlockRange(ctx context.Context, method string, span *walletrpc.BlockRange,
+	blockOut chan<- *walletrpc.CompactBlock, errOut chan<- error,
+	getBlock func(height int) (*walletrpc.CompactBlock, error)) {
 	// Go over [start, end] inclusive
 	low := int(span.Start.Height)
 	high := int(span.End.Height)
@@ -643,7 +939,7 @@ func GetBlockRange(ctx context.Context, cache *BlockCache, blockOut chan<- *wall
 			j = high - (i - low)
 		}
 
-		block, err := GetBlock(ctx, cache, j)
+		block, err := getBlock(j)
 		if err != nil {
 			select {
 			case errOut <- err:
@@ -661,16 +957,12 @@ func GetBlockRange(ctx context.Context, cache *BlockCache, blockOut chan<- *wall
 			// state the node is in while the ingestor repairs a reorg. Fail
 			// rather than serve a sequence of blocks that can't exist.
 			select {
-			case errOut <- status.Error(codes.Aborted,
-				"GetBlockRange: chain discontinuity during reorg repair"):
+			case errOut <- status.Errorf(codes.Aborted,
+				"%s: chain discontinuity during reorg repair", method):
 			case <-ctx.Done():
 			}
 			return
 		}
-		block.Vtx = filterBlockPool(block.Vtx, span.PoolTypes)
-
-		// Note that we do want to return blocks that have had all of its transactions filtered,
-		// as we have done in the past.
 		select {
 		case blockOut <- block:
 		case <-ctx.Done():
@@ -124,6 +124,7 @@ func resetGlobals() {
 	g_lastTime = time.Time{}
 	g_txidSeen = map[txid]struct{}{}
 	g_txList = nil
+	countedMismatchBlocks.blocks = nil
 }
 
 // ------------------------------------------ GetLightdInfo()
@@ -435,13 +436,74 @@ func TestBlockIngestor(t *testing.T) {
 	Time.Now = nowStub
 	os.RemoveAll(unitTestPath)
 	testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, -1)
+	archive := NewRawBlockArchive(unitTestPath, unitTestChain, 0)
+	testcache.SetRawArchive(archive)
 	BlockIngestor(testcache, 11)
 	if step != 24 {
 		t.Error("unexpected final step", step)
 	}
+	// The raw block archive follows the cache, including through the reorgs.
+	if archive.GetFirstHeight() != 380640 || archive.GetNextHeight() != testcache.GetNextHeight() {
+		t.Error("raw block archive does not match the cache")
+	}
+	for i := 0; i < 2; i++ {
+		var blockHex string
+		json.Unmarshal(blocks[i], &blockHex)
+		blockData, _ := hex.DecodeString(blockHex)
+		if !bytes.Equal(archive.Get(380640+i), blockData) {
+			t.Error("unexpected raw block at height", 380640+i)
+		}
+	}
+	// The first (one-block) reorg completed; the second is still in progress.
+	events := testcache.ReorgJournal().Events()
+	if len(events) != 1 {
+		t.Fatal("unexpected number of recorded reorgs", len(events))
+	}
+	if events[0].Depth != 1 || len(events[0].Orphaned) != 1 || events[0].Orphaned[0].Height != 380642 ||
+		len(events[0].New) != 1 || events[0].New[0].Height != 380642 {
+		t.Errorf("unexpected reorg event %+v", events[0])
+	}
+	testcache.Close()
 	os.RemoveAll(unitTestPath)
 }
 
+func TestBlockIngestorHalts(t *testing.T) {
+	block41 := "0001f0720f39cc3fcc6394134ea26f6332bb697ba1dcdb3ded9209181e099338"
+	for _, tt := range []struct {
+		name          string
+		maxReorgDepth int
+		checkpoints   []Checkpoint
+		finalStep     int
+		nextHeight    int
+	}{
+		// The second reorg in blockIngestorStub removes two blocks.
+		{"max depth", 1, nil, 21, 380642},
+		{"reorg crosses checkpoint", 0, []Checkpoint{{Height: 380641, Hash: block41}}, 21, 380642},
+		{"checkpoint mismatch", 0, []Checkpoint{{Height: 380641, Hash: testBlockid41}}, 6, 380641},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testT = t
+			RawRequest = blockIngestorStub
+			defer resetGlobals()
+			Time.Sleep = sleepStub
+			Time.Now = nowStub
+			os.RemoveAll(unitTestPath)
+			testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, -1)
+			testcache.SetMaxReorgDepth(tt.maxReorgDepth)
+			testcache.checkpoints = tt.checkpoints
+			BlockIngestor(testcache, 11)
+			if step != tt.finalStep {
+				t.Error("unexpected final step", step)
+			}
+			if testcache.GetNextHeight() != tt.nextHeight {
+				t.Error("unexpected next height", testcache.GetNextHeight())
+			}
+			testcache.Close()
+			os.RemoveAll(unitTestPath)
+		})
+	}
+}
+
 // ------------------------------------------ GetBlockRange()
 
 // There are four test blocks, 0..3
@@ -729,6 +791,122 @@ func TestGetBlockRangeContiguousReverse(t *testing.T) {
 	}
 }
 
+func TestGetBlockHeaderRange(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	os.RemoveAll(unitTestPath)
+	defer os.RemoveAll(unitTestPath)
+	testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, 0)
+	archive := NewRawBlockArchive(unitTestPath, unitTestChain, 0)
+	testcache.SetRawArchive(archive)
+
+	var rawBlocks [][]byte
+	for _, b := range blocks[:2] {
+		var blockHex string
+		if err := json.Unmarshal(b, &blockHex); err != nil {
+			t.Fatal("could not unmarshal test block:", err)
+		}
+		blockBytes, err := hex.DecodeString(blockHex)
+		if err != nil {
+			t.Fatal("could not decode test block:", err)
+		}
+		rawBlocks = append(rawBlocks, blockBytes)
+	}
+	// The header of 380640 comes from the raw archive; that of 380641 comes
+	// from the backend, which must not be asked for the whole block.
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(rawBlocks[0]); err != nil {
+		t.Fatal("could not parse test block:", err)
+	}
+	if err := testcache.Add(380640, block.ToCompact()); err != nil {
+		t.Fatal("cache.Add failed:", err)
+	}
+	if err := archive.Add(380640, rawBlocks[0]); err != nil {
+		t.Fatal("archive.Add failed:", err)
+	}
+	block = parser.NewBlock()
+	if _, err := block.ParseFromSlice(rawBlocks[1]); err != nil {
+		t.Fatal("could not parse test block:", err)
+	}
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "getblockhash":
+			if string(params[0]) != "380641" {
+				testT.Fatal("unexpected getblockhash height:", string(params[0]))
+			}
+			return json.Marshal(block.GetDisplayHashString())
+		case "getblockheader":
+			var hashStr string
+			json.Unmarshal(params[0], &hashStr)
+			if hashStr != block.GetDisplayHashString() || string(params[1]) != "false" {
+				testT.Fatal("unexpected getblockheader params")
+			}
+			return json.Marshal(hex.EncodeToString(rawBlockHeader(rawBlocks[1])))
+		}
+		testT.Fatal("unexpected method:", method)
+		return nil, nil
+	}
+
+	blockChan := make(chan *walletrpc.CompactBlock)
+	errChan := make(chan error)
+	blockRange := &walletrpc.BlockRange{
+		Start: &walletrpc.BlockID{Height: 380640},
+		End:   &walletrpc.BlockID{Height: 380641},
+	}
+	go GetBlockHeaderRange(context.Background(), testcache, blockChan, errChan, blockRange)
+
+	var prevHash []byte
+	for i, height := range []uint64{380640, 380641} {
+		select {
+		case err := <-errChan:
+			t.Fatal("unexpected error:", err)
+		case cBlock := <-blockChan:
+			if cBlock.Height != height {
+				t.Fatal("unexpected Height:", cBlock.Height)
+			}
+			if len(cBlock.Header) == 0 || !bytes.HasPrefix(rawBlocks[i], cBlock.Header) {
+				t.Fatal("unexpected Header:", hex.EncodeToString(cBlock.Header))
+			}
+			hdr := parser.NewBlockHeader()
+			if rest, err := hdr.ParseFromSlice(cBlock.Header); err != nil || len(rest) != 0 {
+				t.Fatal("header doesn't parse:", err)
+			}
+			if !bytes.Equal(hdr.HashPrevBlock[:], cBlock.PrevHash) {
+				t.Fatal("header doesn't match PrevHash")
+			}
+			if prevHash != nil && !bytes.Equal(cBlock.PrevHash, prevHash) {
+				t.Fatal("headers aren't linked")
+			}
+			if len(cBlock.Vtx) != 0 || cBlock.ChainMetadata != nil {
+				t.Fatal("header-only block has transactions or chain metadata")
+			}
+			prevHash = cBlock.Hash
+		}
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// GetBlock leaves the header unset.
+	RawRequest = discontinuityStub
+	step = 0
+	cBlock, err := GetBlock(context.Background(), nil, 380641)
+	if err != nil {
+		t.Fatal("GetBlock failed:", err)
+	}
+	if cBlock.Header != nil {
+		t.Fatal("GetBlock returned a header")
+	}
+
+	// A backend failure isn't the client's fault.
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("-28: Loading block index...")
+	}
+	if _, err := GetBlockHeader(context.Background(), testcache, 380641); status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected GetBlockHeader error:", err)
+	}
+}
+
 func TestGetBlockRangeCancelsInFlightRPC(t *testing.T) {
 	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
 		<-ctx.Done()
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/zcash/lightwalletd/chainparams"
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser"
 	"github.com/zcash/lightwalletd/walletrpc"
@@ -121,13 +122,26 @@ type darksideProtocolSubtreeRoots struct {
 var DarksideEnabled bool
 
 func darksideSetTxID(tx *parser.Transaction) {
-	// SHA256d
-	// This is correct for V4 transactions, but not for V5, but in this test
-	// environment, it's harmless (the incorrect txid calculation can't be
-	// detected). This will be fixed when lightwalletd calculates txids correctly .
-	digest := sha256.Sum256(tx.Bytes())
-	digest = sha256.Sum256(digest[:])
-	tx.SetTxID(digest)
+	txid, err := tx.ComputeTxID()
+	if err != nil {
+		// lightwalletd can't compute the txids of v6 transactions; use
+		// SHA256d, which in this test environment is harmless as long as
+		// it's used consistently.
+		txid = sha256.Sum256(tx.Bytes())
+		txid = sha256.Sum256(txid[:])
+	}
+	tx.SetTxID(txid)
+}
+
+// darksideVerifyMerkleRoot returns an error if the block's hashMerkleRoot
+// isn't the root of its transactions' txids, unless some of those can't be
+// computed.
+func darksideVerifyMerkleRoot(block *parser.Block) error {
+	err := block.VerifyMerkleRoot()
+	if errors.Is(err, parser.ErrTxIDUnknown) {
+		return nil
+	}
+	return err
 }
 
 func darksideSetBlockTxID(block *parser.Block) {
@@ -311,35 +325,31 @@ func DarksideApplyStaged(height int) error {
 		if tx.height >= state.startHeight+len(state.activeBlocks) {
 			return errors.New("transaction height too high")
 		}
-		block := state.activeBlocks[tx.height-state.startHeight].bytes
-		// The next one or 3 bytes encode the number of transactions to follow,
-		// little endian.
-		nTxFirstByte := block[1487]
-		switch {
-		case nTxFirstByte < 252:
-			block[1487]++
-		case nTxFirstByte == 252:
-			// incrementing to 253, requires "253" followed by 2-byte length,
-			// extend the block by two bytes, shift existing transaction bytes
-			block = append(block, 0, 0)
-			copy(block[1490:], block[1488:len(block)-2])
-			block[1487] = 253
-			block[1488] = 253
-			block[1489] = 0
-		case nTxFirstByte == 253:
-			block[1488]++
-			if block[1488] == 0 {
-				// wrapped around
-				block[1489]++
-			}
-		default:
-			// no need to worry about more than 64k transactions
-			Log.Fatal("unexpected compact transaction count ", nTxFirstByte,
-				", can't support more than 64k transactions in a block")
+		active := state.activeBlocks[tx.height-state.startHeight]
+		block := parser.NewBlock()
+		if _, err := block.ParseFromSlice(active.bytes); err != nil {
+			return err
 		}
-		block[68]++ // hack HashFinalSaplingRoot to mod the block hash
-		block = append(block, tx.bytes...)
-		state.activeBlocks[tx.height-state.startHeight].bytes = block
+		stagedTx := parser.NewTransaction()
+		if _, err := stagedTx.ParseFromSlice(tx.bytes); err != nil {
+			return err
+		}
+		vtx := append(slices.Clone(block.Transactions()), stagedTx)
+		for _, tx := range vtx {
+			darksideSetTxID(tx)
+		}
+		hdr := block.RawHeader()
+		// The new merkle root also changes the block hash.
+		merkleRoot, err := parser.MerkleRoot(vtx)
+		if err != nil {
+			return err
+		}
+		hdr.HashMerkleRoot = merkleRoot
+		blockBytes, err := parser.NewBlockFromTransactions(&hdr, vtx).MarshalBinary()
+		if err != nil {
+			return err
+		}
+		active.bytes = blockBytes
 		// Now increment this and every subsequent block's commitment tree sizes.
 		for _, b := range state.activeBlocks[tx.height-state.startHeight:] {
 			b.saplingTreeSize += uint32(tx.saplingOutputs)
@@ -387,6 +397,10 @@ func darksideStageBlock(caller string, b []byte) error {
 	if len(rest) != 0 {
 		return errors.New("block serialization is too long")
 	}
+	if err := darksideVerifyMerkleRoot(block); err != nil {
+		Log.Error("stage block error: ", err)
+		return fmt.Errorf("block %d: %w", block.GetHeight(), err)
+	}
 	Log.Info(caller, "DarksideStageBlock(height=", block.GetHeight(), ")")
 	if block.GetHeight() < state.startHeight {
 		return errors.New(fmt.Sprint("block height ", block.GetHeight(),
@@ -479,28 +493,31 @@ func DarksideStageBlocksCreate(height int32, nonce int32, count int32) error {
 			Log.Fatal(err)
 		}
 
-		hashOfTxnsAndHeight := sha256.Sum256([]byte(string(nonce) + "#" + string(height)))
-		blockHeader := &parser.BlockHeader{
-			RawBlockHeader: &parser.RawBlockHeader{
-				Version:              4,                   // start: 0
-				HashPrevBlock:        hash32.Nil,          // start: 4
-				HashMerkleRoot:       hashOfTxnsAndHeight, // start: 36
-				HashFinalSaplingRoot: hash32.Nil,          // start: 68
-				Time:                 1,                   // start: 100
-				NBitsBytes:           [4]byte{},           // start: 104
-				Nonce:                hash32.Nil,          // start: 108
-				Solution:             make([]byte, 1344),  // starts: 140, 143
-			}, // length: 1487
+		coinbase := parser.NewTransaction()
+		if _, err := coinbase.ParseFromSlice(fakeCoinbaseBytes); err != nil {
+			Log.Fatal(err)
+		}
+		merkleRoot, err := parser.MerkleRoot([]*parser.Transaction{coinbase})
+		if err != nil {
+			Log.Fatal(err)
 		}
 
-		headerBytes, err := blockHeader.MarshalBinary()
+		// The header nonce makes blocks created with different nonces
+		// (at the same height) differ.
+		hashOfNonceAndHeight := sha256.Sum256([]byte(string(nonce) + "#" + string(height)))
+		blockBytes, err := parser.NewBlockFromTransactions(&parser.RawBlockHeader{
+			Version:              4,
+			HashPrevBlock:        hash32.Nil,
+			HashMerkleRoot:       merkleRoot,
+			HashFinalSaplingRoot: hash32.Nil,
+			Time:                 1,
+			NBitsBytes:           [4]byte{},
+			Nonce:                hashOfNonceAndHeight,
+			Solution:             make([]byte, 1344),
+		}, []*parser.Transaction{coinbase}).MarshalBinary()
 		if err != nil {
 			Log.Fatal(err)
 		}
-		blockBytes := make([]byte, 0)
-		blockBytes = append(blockBytes, headerBytes...)
-		blockBytes = append(blockBytes, byte(1))
-		blockBytes = append(blockBytes, fakeCoinbaseBytes...)
 		if err = darksideStageBlock("DarksideStageBlockCreate", blockBytes); err != nil {
 			// This should never fail since we created the block ourselves.
 			return err
@@ -533,7 +550,7 @@ func darksideRawRequest(method string, params []json.RawMessage) (json.RawMessag
 		blockchaininfo := &ZcashdRpcReplyGetblockchaininfo{
 			Chain: state.chainName,
 			Upgrades: map[string]Upgradeinfo{
-				"76b809bb": {ActivationHeight: state.startHeight},
+				chainparams.BranchIDString(chainparams.SaplingBranchID): {ActivationHeight: state.startHeight},
 			},
 			Blocks:        state.latestHeight,
 			Consensus:     ConsensusInfo{state.branchID, state.branchID},
@@ -640,6 +657,33 @@ func darksideRawRequest(method string, params []json.RawMessage) (json.RawMessag
 		block.ParseFromSlice(state.activeBlocks[index].bytes)
 		return json.Marshal(block.GetDisplayHashString())
 
+	case "getblockhash":
+		var height int
+		if err := json.Unmarshal(params[0], &height); err != nil {
+			return nil, errors.New("failed to parse getblockhash request")
+		}
+		index := height - state.startHeight
+		if height > state.latestHeight || index < 0 || index >= len(state.activeBlocks) {
+			return nil, errors.New("-8: Block height out of range")
+		}
+		block := parser.NewBlock()
+		block.ParseFromSlice(state.activeBlocks[index].bytes)
+		return json.Marshal(block.GetDisplayHashString())
+
+	case "getblockheader":
+		var hashStr string
+		if err := json.Unmarshal(params[0], &hashStr); err != nil {
+			return nil, errors.New("failed to parse getblockheader request")
+		}
+		for _, b := range state.activeBlocks {
+			block := parser.NewBlock()
+			block.ParseFromSlice(b.bytes)
+			if hashStr == block.GetDisplayHashString() {
+				return json.Marshal(hex.EncodeToString(rawBlockHeader(b.bytes)))
+			}
+		}
+		return nil, errors.New(fmt.Sprint("getblockheader: hash ", hashStr, " not found"))
+
 	case "getaddresstxids":
 		var req ZcashdRpcRequestGetaddresstxids
 		err := json.Unmarshal(params[0], &req)
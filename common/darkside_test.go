@@ -3,11 +3,14 @@ package common
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser"
 	"github.com/zcash/lightwalletd/walletrpc"
@@ -223,3 +226,96 @@ func TestDarksideGetSubtreeRootsZeroMaxEntriesReturnsAll(t *testing.T) {
 		t.Fatal("limited subtree roots response was incorrect")
 	}
 }
+
+// TestDarksideStageBlockVerifiesMerkleRoot checks that staged blocks must
+// commit to their transactions, and that created blocks do.
+func TestDarksideStageBlockVerifiesMerkleRoot(t *testing.T) {
+	cache := NewBlockCache(t.TempDir(), unitTestChain, 100, 0)
+	mutex.Lock()
+	state.cache = cache
+	mutex.Unlock()
+	if err := DarksideReset(100, "cafe", "test", 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DarksideStageBlocksCreate(100, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, blockBytes := range state.stagedBlocks {
+		block := parser.NewBlock()
+		if _, err := block.ParseFromSlice(blockBytes); err != nil {
+			t.Fatal(err)
+		}
+		if err := block.VerifyMerkleRoot(); err != nil {
+			t.Fatal("created block", block.GetHeight(), err)
+		}
+	}
+
+	badBlock := bytes.Clone(state.stagedBlocks[0])
+	badBlock[36] ^= 1 // the first byte of hashMerkleRoot
+	err := DarksideStageBlockStream(hex.EncodeToString(badBlock))
+	if err == nil || !strings.Contains(err.Error(), "merkle root") {
+		t.Fatal("unexpected error staging a block with the wrong merkle root:", err)
+	}
+	if len(state.stagedBlocks) != 2 {
+		t.Fatal("the block with the wrong merkle root shouldn't be staged")
+	}
+}
+
+func TestDarksideResetAfterIngestorHalts(t *testing.T) {
+	defer resetGlobals()
+	Time.Sleep = time.Sleep
+	Time.Now = time.Now
+	Time.After = time.After
+	DarksideEnabled = true
+	defer func() { DarksideEnabled = false }()
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		return darksideRawRequest(method, params)
+	}
+	cache := NewBlockCache(t.TempDir(), unitTestChain, 100, 0)
+	defer cache.Close()
+	mutex.Lock()
+	state.cache = cache
+	mutex.Unlock()
+	if err := DarksideReset(100, "cafe", "test", 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := DarksideStageBlocksCreate(100, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The darkside chain's block 100 doesn't match this checkpoint, so the
+	// ingestor halts as soon as it gets the block.
+	cache.checkpoints = []Checkpoint{{Height: 100, Hash: strings.Repeat("0", 64)}}
+	if err := DarksideApplyStaged(101); err != nil {
+		t.Fatal(err)
+	}
+	var m dto.Metric
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		ingestorHalted.Write(&m)
+		if m.GetGauge().GetValue() == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the ingestor didn't halt")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reset := make(chan error)
+	go func() {
+		reset <- DarksideReset(100, "cafe", "test", 0, 0, 0)
+	}()
+	select {
+	case err := <-reset:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("DarksideReset hung after the ingestor halted")
+	}
+	ingestorHalted.Write(&m)
+	if m.GetGauge().GetValue() != 0 {
+		t.Fatal("the halted gauge wasn't cleared by the reset")
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+)
+
+var (
+	differentialBlocksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightwalletd_differential_blocks_total",
+		Help: "Number of blocks whose parse was compared with the backend's decoded JSON, by result (match, mismatch, or unverified if the JSON couldn't be fetched).",
+	}, []string{"result"})
+	differentialMismatchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightwalletd_differential_mismatches_total",
+		Help: "Number of differences between lightwalletd's parse and the backend's decoded JSON, by field.",
+	}, []string{"field"})
+)
+
+// VerifyAgainstBackend makes the block ingestor compare its parse of each
+// block with the backend's decoded JSON of the block's transactions, from
+// getblock with verbosity 2. A block that differs is quarantined, and still
+// added to the cache unless RejectBackendMismatches is set. It's false
+// unless lightwalletd is run with --verify-against-backend.
+var VerifyAgainstBackend bool
+
+// RejectBackendMismatches makes the block ingestor reject a block that
+// differs from the backend's JSON, as it does a block it can't parse. It's
+// false unless lightwalletd is run with --reject-backend-mismatches.
+var RejectBackendMismatches bool
+
+// BackendMismatchError is the error (wrapped in the BlockParseError that's
+// quarantined) when VerifyAgainstBackend is set and the ingestor's parse of
+// a block differs from the backend's decoded JSON.
+type BackendMismatchError struct {
+	Mismatches []string
+}
+
+func (e *BackendMismatchError) Error() string {
+	return "parse differs from backend: " + strings.Join(e.Mismatches, "; ")
+}
+
+// ZcashdRpcReplyGetblock2 is the part of the reply to getblock with
+// verbosity 2 that the differential check compares.
+type ZcashdRpcReplyGetblock2 struct {
+	Tx []ZcashdRpcReplyGetrawtransactionVerbose
+}
+
+// ZcashdRpcReplyGetrawtransactionVerbose is the part of the JSON of a
+// transaction (as getrawtransaction with verbose=1 and getblock with
+// verbosity 2 return it) that the differential check compares.
+// Pointer fields are ones that some backends omit; they're only compared
+// when present.
+type ZcashdRpcReplyGetrawtransactionVerbose struct {
+	Txid    string
+	Version uint32
+	Vin     []struct {
+		Coinbase string
+		Txid     string
+		Vout     uint32
+	}
+	Vout []struct {
+		ValueZat uint64 `json:"valueZat"`
+	}
+	Vjoinsplit *[]struct {
+		VpubOldZat uint64 `json:"vpub_oldZat"`
+		VpubNewZat uint64 `json:"vpub_newZat"`
+	}
+	ValueBalanceZat *int64             `json:"valueBalanceZat"`
+	VShieldedSpend  *[]json.RawMessage `json:"vShieldedSpend"`
+	VShieldedOutput *[]json.RawMessage `json:"vShieldedOutput"`
+	Orchard         *ZcashdRpcReplyOrchardBundle
+	Ironwood        *ZcashdRpcReplyOrchardBundle
+}
+
+// ZcashdRpcReplyOrchardBundle is the Orchard (or Ironwood) part of a verbose
+// getrawtransaction reply.
+type ZcashdRpcReplyOrchardBundle struct {
+	Actions         []json.RawMessage
+	ValueBalanceZat *int64 `json:"valueBalanceZat"`
+}
+
+// maxCountedMismatchBlocks bounds the number of (most recent) mismatched
+// blocks whose mismatches are remembered as counted.
+const maxCountedMismatchBlocks = 1000
+
+// countedMismatchBlocks lists the height and hash of the blocks whose
+// mismatches have been counted in the metrics, oldest first, so that the
+// block ingestor's retries of a block don't count them again.
+var countedMismatchBlocks struct {
+	mutex  sync.Mutex
+	blocks []string
+}
+
+// differences collects the ways a parsed block differs from the backend's
+// JSON, each described with the path of the field.
+type differences struct {
+	mismatches []string
+	fields     []string // the metrics label of each mismatch
+}
+
+func (d *differences) add(field, path string, format string, args ...any) {
+	d.mismatches = append(d.mismatches, path+" "+fmt.Sprintf(format, args...))
+	d.fields = append(d.fields, field)
+}
+
+// count adds the differences to the metrics, unless those of the block at
+// the given height with the given hash have been counted already.
+func (d *differences) count(height int, hash string) {
+	c := &countedMismatchBlocks
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	block := fmt.Sprint(height, "-", hash)
+	if slices.Contains(c.blocks, block) {
+		return
+	}
+	c.blocks = append(c.blocks, block)
+	if len(c.blocks) > maxCountedMismatchBlocks {
+		c.blocks = slices.Delete(c.blocks, 0, len(c.blocks)-maxCountedMismatchBlocks)
+	}
+	differentialBlocksTotal.WithLabelValues("mismatch").Inc()
+	for _, field := range d.fields {
+		differentialMismatchesTotal.WithLabelValues(field).Inc()
+	}
+}
+
+func (d *differences) compare(field, path string, ours, backend any) {
+	if ours != backend {
+		d.add(field, path, "is %v, backend has %v", ours, backend)
+	}
+}
+
+// checkAgainstBackend compares the block the ingestor got at the given
+// height with the backend's JSON. A block that differs is quarantined and
+// nil is returned, so the ingestor adds it anyway, unless
+// RejectBackendMismatches is set; then the *BlockParseError is returned
+// instead, for the ingestor to quarantine.
+func checkAgainstBackend(ctx context.Context, c *BlockCache, height int, rawBlock []byte) error {
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(rawBlock); err != nil {
+		return err
+	}
+	err := verifyAgainstBackend(ctx, height, block)
+	if err == nil {
+		return nil
+	}
+	parseErr := &BlockParseError{Height: height, Hash: block.GetDisplayHashString(), Data: rawBlock, Err: err}
+	if RejectBackendMismatches {
+		return parseErr
+	}
+	name := c.Quarantine().Add(parseErr)
+	Log.WithFields(logrus.Fields{
+		"height": height,
+		"hash":   parseErr.Hash,
+		"error":  err,
+		"file":   name,
+	}).Warning("block differs from the backend's JSON, quarantined a copy and adding it anyway")
+	return nil
+}
+
+// verifyAgainstBackend compares the parsed block at the given height with
+// the backend's JSON of its transactions. It returns a *BackendMismatchError
+// if they differ. If the backend's JSON can't be fetched the block is
+// counted as unverified, and nil is returned; this check mustn't stop the
+// ingestor.
+func verifyAgainstBackend(ctx context.Context, height int, block *parser.Block) error {
+	hash := block.GetDisplayHashString()
+	hashJSON, err := json.Marshal(hash)
+	if err != nil {
+		return err
+	}
+	params := []json.RawMessage{hashJSON, json.RawMessage("2")}
+	result, rpcErr := RawRequest(ctx, "getblock", params)
+	var backend ZcashdRpcReplyGetblock2
+	if rpcErr == nil {
+		rpcErr = json.Unmarshal(result, &backend)
+	}
+	if rpcErr != nil {
+		Log.WithFields(logrus.Fields{
+			"height": height,
+			"hash":   hash,
+			"error":  rpcErr,
+		}).Warning("can't get the backend's JSON of the block, not verifying it")
+		differentialBlocksTotal.WithLabelValues("unverified").Inc()
+		return nil
+	}
+
+	var diffs differences
+	txs := block.Transactions()
+	if len(txs) != len(backend.Tx) {
+		diffs.add("vtx", "vtx", "has %d transactions, backend has %d", len(txs), len(backend.Tx))
+		txs = nil
+	}
+	for i, tx := range txs {
+		path := fmt.Sprintf("vtx[%d]", i)
+		txid, err := tx.ComputeTxID()
+		if err == nil {
+			diffs.compare("txid", path+".txid", hash32.Encode(hash32.Reverse(txid)), backend.Tx[i].Txid)
+		} else if !errors.Is(err, parser.ErrTxIDUnknown) {
+			return err
+		}
+		compareTransaction(&diffs, path, tx, &backend.Tx[i])
+	}
+	if len(diffs.mismatches) > 0 {
+		diffs.count(height, hash)
+		return &BackendMismatchError{Mismatches: diffs.mismatches}
+	}
+	differentialBlocksTotal.WithLabelValues("match").Inc()
+	return nil
+}
+
+// compareTransaction adds the ways the parsed transaction differs from the
+// backend's JSON of it to diffs.
+func compareTransaction(diffs *differences, path string, tx *parser.Transaction, backend *ZcashdRpcReplyGetrawtransactionVerbose) {
+	diffs.compare("version", path+".version", tx.Version, backend.Version)
+
+	if len(tx.TransparentInputs) != len(backend.Vin) {
+		diffs.add("vin", path+".vin", "has %d inputs, backend has %d", len(tx.TransparentInputs), len(backend.Vin))
+	} else {
+		for i, in := range tx.TransparentInputs {
+			if backend.Vin[i].Coinbase != "" {
+				continue
+			}
+			inPath := fmt.Sprintf("%s.vin[%d]", path, i)
+			diffs.compare("vin", inPath+".txid", hash32.Encode(hash32.Reverse(in.PrevTxHash)), backend.Vin[i].Txid)
+			diffs.compare("vin", inPath+".vout", in.PrevTxOutIndex, backend.Vin[i].Vout)
+		}
+	}
+	if len(tx.TransparentOutputs) != len(backend.Vout) {
+		diffs.add("vout", path+".vout", "has %d outputs, backend has %d", len(tx.TransparentOutputs), len(backend.Vout))
+	} else {
+		for i, out := range tx.TransparentOutputs {
+			diffs.compare("vout", fmt.Sprintf("%s.vout[%d].valueZat", path, i), out.Value, backend.Vout[i].ValueZat)
+		}
+	}
+
+	if backend.Vjoinsplit != nil {
+		if len(tx.JoinSplits) != len(*backend.Vjoinsplit) {
+			diffs.add("sprout", path+".vjoinsplit", "has %d JoinSplits, backend has %d", len(tx.JoinSplits), len(*backend.Vjoinsplit))
+		} else {
+			for i, js := range tx.JoinSplits {
+				jsPath := fmt.Sprintf("%s.vjoinsplit[%d]", path, i)
+				diffs.compare("sprout", jsPath+".vpub_oldZat", js.VpubOld, (*backend.Vjoinsplit)[i].VpubOldZat)
+				diffs.compare("sprout", jsPath+".vpub_newZat", js.VpubNew, (*backend.Vjoinsplit)[i].VpubNewZat)
+			}
+		}
+	}
+
+	if backend.VShieldedSpend != nil {
+		diffs.compare("sapling", path+".vShieldedSpend count", len(tx.SaplingSpends), len(*backend.VShieldedSpend))
+	}
+	if backend.VShieldedOutput != nil {
+		diffs.compare("sapling", path+".vShieldedOutput count", len(tx.SaplingOutputs), len(*backend.VShieldedOutput))
+	}
+	if backend.ValueBalanceZat != nil {
+		diffs.compare("sapling", path+".valueBalanceZat", tx.ValueBalanceSapling, *backend.ValueBalanceZat)
+	}
+
+	compareBundle(diffs, "orchard", path+".orchard", tx.Orchard, backend.Orchard)
+	compareBundle(diffs, "ironwood", path+".ironwood", tx.Ironwood, backend.Ironwood)
+}
+
+// compareBundle compares an Orchard or Ironwood bundle; the parser's is nil
+// if it has no actions, and backends that don't know the pool omit it.
+func compareBundle(diffs *differences, field, path string, ours *parser.OrchardBundle, backend *ZcashdRpcReplyOrchardBundle) {
+	var actions int
+	var valueBalance int64
+	if ours != nil {
+		actions, valueBalance = len(ours.Actions), ours.ValueBalance
+	}
+	if backend == nil {
+		return
+	}
+	diffs.compare(field, path+".actions count", actions, len(backend.Actions))
+	if backend.ValueBalanceZat != nil {
+		diffs.compare(field, path+".valueBalanceZat", valueBalance, *backend.ValueBalanceZat)
+	}
+}
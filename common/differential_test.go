@@ -0,0 +1,165 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/zcash/lightwalletd/parser"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	var m dto.Metric
+	c.Write(&m)
+	return m.GetCounter().GetValue()
+}
+
+// differentialStub returns block 380643 (which has transparent and Sapling
+// transactions) and a stub that serves, for getblock with verbosity 2, the
+// parser's rendering of each of its transactions as changed by modify.
+func differentialStub(t *testing.T, modify func(i int, tx map[string]any)) (*parser.Block, []byte, func(context.Context, string, []json.RawMessage) (json.RawMessage, error)) {
+	var blockHex string
+	if err := json.Unmarshal(blocks[3], &blockHex); err != nil {
+		t.Fatal(err)
+	}
+	blockData, _ := hex.DecodeString(blockHex)
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(blockData); err != nil {
+		t.Fatal(err)
+	}
+	var txs []map[string]any
+	for i, tx := range block.Transactions() {
+		txid, err := tx.ComputeTxID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.SetTxID(txid)
+		j, _ := json.Marshal(tx)
+		var m map[string]any
+		json.Unmarshal(j, &m)
+		modify(i, m)
+		txs = append(txs, m)
+	}
+	return block, blockData, func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		var hash string
+		json.Unmarshal(params[0], &hash)
+		if method != "getblock" || hash != block.GetDisplayHashString() || string(params[1]) != "2" {
+			t.Fatal("unexpected request", method, hash, string(params[1]))
+		}
+		return json.Marshal(map[string]any{"hash": hash, "tx": txs})
+	}
+}
+
+func TestVerifyAgainstBackend(t *testing.T) {
+	defer resetGlobals()
+	ctx := context.Background()
+
+	block, _, stub := differentialStub(t, func(int, map[string]any) {})
+	RawRequest = stub
+	if err := verifyAgainstBackend(ctx, 380643, block); err != nil {
+		t.Fatal("a block that matches the backend's JSON should be accepted:", err)
+	}
+
+	// The backend's JSON is missing the optional fields.
+	block, _, RawRequest = differentialStub(t, func(i int, tx map[string]any) {
+		delete(tx, "vShieldedSpend")
+		delete(tx, "vShieldedOutput")
+		delete(tx, "valueBalanceZat")
+		delete(tx, "vjoinsplit")
+	})
+	if err := verifyAgainstBackend(ctx, 380643, block); err != nil {
+		t.Fatal("fields the backend omits shouldn't be compared:", err)
+	}
+
+	block, _, RawRequest = differentialStub(t, func(i int, tx map[string]any) {
+		if i == 1 {
+			tx["vout"].([]any)[1].(map[string]any)["valueZat"] = 1
+			tx["vShieldedOutput"] = []any{map[string]any{}}
+		}
+	})
+	err := verifyAgainstBackend(ctx, 380643, block)
+	var mismatch *BackendMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatal("a block that differs from the backend's JSON should be reported:", err)
+	}
+	if len(mismatch.Mismatches) != 2 ||
+		!strings.HasPrefix(mismatch.Mismatches[0], "vtx[1].vout[1].valueZat is ") ||
+		!strings.HasSuffix(mismatch.Mismatches[0], ", backend has 1") ||
+		!strings.HasPrefix(mismatch.Mismatches[1], "vtx[1].vShieldedOutput count is ") {
+		t.Fatal("unexpected mismatches", mismatch.Mismatches)
+	}
+	// Checking the block again doesn't count its mismatches again.
+	blocksTotal := counterValue(differentialBlocksTotal.WithLabelValues("mismatch"))
+	voutTotal := counterValue(differentialMismatchesTotal.WithLabelValues("vout"))
+	if err := verifyAgainstBackend(ctx, 380643, block); !errors.As(err, &mismatch) {
+		t.Fatal("the block should still differ:", err)
+	}
+	if counterValue(differentialBlocksTotal.WithLabelValues("mismatch")) != blocksTotal ||
+		counterValue(differentialMismatchesTotal.WithLabelValues("vout")) != voutTotal {
+		t.Fatal("a block's mismatches were counted again")
+	}
+
+	// The backend's txids must match the computed ones.
+	block, _, RawRequest = differentialStub(t, func(i int, tx map[string]any) {
+		if i == 0 {
+			tx["txid"] = strings.Repeat("00", 32)
+		}
+	})
+	err = verifyAgainstBackend(ctx, 380643, block)
+	if !errors.As(err, &mismatch) || len(mismatch.Mismatches) != 1 ||
+		!strings.HasSuffix(mismatch.Mismatches[0], ", backend has "+strings.Repeat("00", 32)) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// A backend that can't return the block's JSON leaves it unverified.
+	unverified := counterValue(differentialBlocksTotal.WithLabelValues("unverified"))
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("-8: Block not found")
+	}
+	if err := verifyAgainstBackend(ctx, 380643, block); err != nil {
+		t.Fatal("an RPC failure shouldn't be an error:", err)
+	}
+	if counterValue(differentialBlocksTotal.WithLabelValues("unverified")) != unverified+1 {
+		t.Fatal("the unverified block wasn't counted")
+	}
+}
+
+func TestCheckAgainstBackend(t *testing.T) {
+	defer resetGlobals()
+	defer func() { RejectBackendMismatches = false }()
+	ctx := context.Background()
+	c := NewBlockCache(t.TempDir(), unitTestChain, 380640, 0)
+	defer c.Close()
+
+	_, rawBlock, stub := differentialStub(t, func(i int, tx map[string]any) {
+		if i == 1 {
+			tx["vout"].([]any)[1].(map[string]any)["valueZat"] = 1
+		}
+	})
+	RawRequest = stub
+	// By default the block is quarantined, but added anyway.
+	if err := checkAgainstBackend(ctx, c, 380643, rawBlock); err != nil {
+		t.Fatal("a block that differs should still be added:", err)
+	}
+	quarantined := c.Quarantine().Blocks()
+	if len(quarantined) != 1 || quarantined[0].Height != 380643 ||
+		!strings.Contains(quarantined[0].Error, "vtx[1].vout[1].valueZat") {
+		t.Fatalf("unexpected quarantine %+v", quarantined)
+	}
+
+	RejectBackendMismatches = true
+	err := checkAgainstBackend(ctx, c, 380643, rawBlock)
+	var parseErr *BlockParseError
+	var mismatch *BackendMismatchError
+	if !errors.As(err, &parseErr) || !errors.As(err, &mismatch) || parseErr.Height != 380643 {
+		t.Fatal("with --reject-backend-mismatches the block should be rejected:", err)
+	}
+}
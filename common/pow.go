@@ -0,0 +1,27 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"math/big"
+
+	"github.com/zcash/lightwalletd/chainparams"
+)
+
+// ProofOfWorkLimit, if not nil, makes getBlockFromRPC verify the Equihash
+// solution of each block it fetches, and that the block's hash meets its
+// target and the target is no easier than this limit. Blocks that fail are
+// rejected and quarantined. It's nil unless lightwalletd is run with
+// --verify-pow.
+var ProofOfWorkLimit *big.Int
+
+// PowLimit returns the proof-of-work limit of the named chain, or nil if it
+// isn't known or isn't checked.
+func PowLimit(chainName string) *big.Int {
+	if params := chainparams.Lookup(chainName); params != nil {
+		return params.PowLimitInt()
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+	"github.com/zcash/lightwalletd/walletrpc"
+)
+
+var (
+	prevoutLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightwalletd_prevout_lookups_total",
+		Help: "Number of transparent prevouts resolved, by source (store, node, or failed).",
+	}, []string{"source"})
+)
+
+// DefaultPrevoutStoreSize is the default number of transparent outputs the
+// prevout store keeps.
+const DefaultPrevoutStoreSize = 1000000
+
+// Prevouts, if not nil, resolves the output spent by each transparent input
+// of the blocks that getBlockFromRPC returns, filling in the CompactTxIn
+// prevoutValue and prevoutScriptPubKey fields and CompactTx.fee. It's nil
+// unless lightwalletd is run with --resolve-prevouts.
+var Prevouts *PrevoutStore
+
+type outpoint struct {
+	txid  hash32.T // little-endian
+	index uint32
+}
+
+// PrevoutStore holds the transparent outputs of recently ingested blocks, so
+// that most prevouts can be resolved without asking the node. Outputs are
+// immutable (a txid commits to its outputs), so an entry never becomes wrong,
+// even across a reorg; at worst a lookup misses and goes to the node.
+//
+// It's bounded by keeping two generations of entries: when the current one
+// fills, it becomes the previous one and the old previous one is dropped.
+type PrevoutStore struct {
+	maxEntries int // per generation
+	current    map[outpoint]*walletrpc.TxOut
+	previous   map[outpoint]*walletrpc.TxOut
+	mutex      sync.Mutex
+}
+
+// NewPrevoutStore returns an empty store that keeps at least the given
+// number of the most recently added outputs.
+func NewPrevoutStore(size int) *PrevoutStore {
+	return &PrevoutStore{
+		maxEntries: size,
+		current:    make(map[outpoint]*walletrpc.TxOut),
+	}
+}
+
+func (p *PrevoutStore) add(op outpoint, out *walletrpc.TxOut) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.current) >= p.maxEntries {
+		p.previous = p.current
+		p.current = make(map[outpoint]*walletrpc.TxOut)
+	}
+	p.current[op] = out
+}
+
+// get returns the output, or nil if it isn't in the store.
+func (p *PrevoutStore) get(op outpoint) *walletrpc.TxOut {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if out, ok := p.current[op]; ok {
+		return out
+	}
+	return p.previous[op]
+}
+
+// lookup returns the given output, from the store if possible, otherwise
+// from the node (which must then be able to look up any transaction by txid,
+// as zebrad can, or zcashd with -txindex).
+func (p *PrevoutStore) lookup(ctx context.Context, op outpoint) (*walletrpc.TxOut, error) {
+	if out := p.get(op); out != nil {
+		prevoutLookupsTotal.WithLabelValues("store").Inc()
+		return out, nil
+	}
+	txidJSON, err := json.Marshal(hash32.Encode(hash32.Reverse(op.txid)))
+	if err != nil {
+		return nil, err
+	}
+	params := []json.RawMessage{txidJSON, json.RawMessage("0")}
+	result, rpcErr := RawRequest(ctx, "getrawtransaction", params)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("error requesting prevout transaction: %w", rpcErr)
+	}
+	var txHex string
+	if err := json.Unmarshal(result, &txHex); err != nil {
+		return nil, fmt.Errorf("error reading JSON response: %w", err)
+	}
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding getrawtransaction output: %w", err)
+	}
+	tx := parser.NewTransaction()
+	if _, err := tx.ParseFromSlice(txBytes); err != nil {
+		return nil, fmt.Errorf("error parsing prevout transaction: %w", err)
+	}
+	vout := tx.ToCompact(0).Vout
+	if int(op.index) >= len(vout) {
+		return nil, errors.New("prevout index is out of range")
+	}
+	prevoutLookupsTotal.WithLabelValues("node").Inc()
+	return vout[op.index], nil
+}
+
+// resolve fills in the prevout fields of the given compact block's
+// transparent inputs, and the fees of transactions whose inputs are all
+// resolved, then adds the block's transparent outputs to the store. The
+// transactions are processed in order, since one may spend an output of
+// an earlier one in the same block.
+func (p *PrevoutStore) resolve(ctx context.Context, block *parser.Block, compact *walletrpc.CompactBlock) {
+	for i, tx := range block.Transactions() {
+		compactTx := compact.Vtx[i]
+		values := make([]uint64, 0, len(compactTx.Vin))
+		for _, in := range compactTx.Vin {
+			op := outpoint{txid: hash32.FromSlice(in.PrevoutTxid), index: in.PrevoutIndex}
+			out, err := p.lookup(ctx, op)
+			if err != nil {
+				prevoutLookupsTotal.WithLabelValues("failed").Inc()
+				Log.Warning("can't resolve prevout of tx ", tx.GetDisplayHashString(),
+					" in block ", block.GetHeight(), ": ", err)
+				continue
+			}
+			in.PrevoutValue = out.Value
+			in.PrevoutScriptPubKey = out.ScriptPubKey
+			values = append(values, out.Value)
+		}
+		if len(values) > 0 && len(values) == len(compactTx.Vin) {
+			if fee, ok := tx.FeeWithPrevouts(values); ok && fee <= math.MaxUint32 {
+				compactTx.Fee = uint32(fee)
+			}
+		}
+		txid := tx.GetEncodableHash()
+		for j, out := range compactTx.Vout {
+			p.add(outpoint{txid: txid, index: uint32(j)}, out)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+	"github.com/zcash/lightwalletd/walletrpc"
+)
+
+// parseTestBlock parses test block 380643, whose second transaction has a
+// transparent input, giving each transaction a distinct txid.
+func parseTestBlock(t *testing.T) (*parser.Block, *walletrpc.CompactBlock) {
+	var blockHex string
+	json.Unmarshal(blocks[3], &blockHex)
+	blockData, _ := hex.DecodeString(blockHex)
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(blockData); err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range block.Transactions() {
+		tx.SetTxID(hash32.T{byte(i + 1)})
+	}
+	return block, block.ToCompact()
+}
+
+func TestPrevoutStoreResolve(t *testing.T) {
+	defer resetGlobals()
+	block, compact := parseTestBlock(t)
+	spender := compact.Vtx[1]
+	if len(spender.Vin) != 1 {
+		t.Fatal("test block should have one transparent input")
+	}
+	var sumOut uint64
+	for _, out := range spender.Vout {
+		sumOut += out.Value
+	}
+	in := spender.Vin[0]
+	op := outpoint{txid: hash32.FromSlice(in.PrevoutTxid), index: in.PrevoutIndex}
+
+	// Resolved from the store (RawRequest is nil, so the node isn't asked).
+	p := NewPrevoutStore(10)
+	p.add(op, &walletrpc.TxOut{Value: sumOut + 1000, ScriptPubKey: []byte{0x51}})
+	p.resolve(context.Background(), block, compact)
+	if in.PrevoutValue != sumOut+1000 || !bytes.Equal(in.PrevoutScriptPubKey, []byte{0x51}) {
+		t.Fatal("prevout not resolved from the store")
+	}
+	if spender.Fee != 1000 {
+		t.Fatal("unexpected fee", spender.Fee)
+	}
+	// The block's outputs are now in the store.
+	coinbaseOut := p.get(outpoint{txid: hash32.T{1}, index: 1})
+	if coinbaseOut == nil || coinbaseOut.Value != compact.Vtx[0].Vout[1].Value {
+		t.Fatal("block outputs not added to the store")
+	}
+
+	// Resolved from the node; the response is the spending transaction
+	// itself, which is as good as any for this purpose.
+	block, compact = parseTestBlock(t)
+	spender, in = compact.Vtx[1], compact.Vtx[1].Vin[0]
+	prevTx := block.Transactions()[1]
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		if method != "getrawtransaction" {
+			t.Fatal("unexpected method", method)
+		}
+		var txid string
+		json.Unmarshal(params[0], &txid)
+		if txid != hash32.Encode(hash32.Reverse(hash32.FromSlice(in.PrevoutTxid))) {
+			t.Fatal("unexpected txid", txid)
+		}
+		return json.Marshal(hex.EncodeToString(prevTx.Bytes()))
+	}
+	in.PrevoutIndex = 1
+	p = NewPrevoutStore(10)
+	p.resolve(context.Background(), block, compact)
+	if in.PrevoutValue != spender.Vout[1].Value || !bytes.Equal(in.PrevoutScriptPubKey, spender.Vout[1].ScriptPubKey) {
+		t.Fatal("prevout not resolved from the node")
+	}
+
+	// An output that can't be resolved leaves the input and fee unset.
+	block, compact = parseTestBlock(t)
+	in = compact.Vtx[1].Vin[0]
+	in.PrevoutIndex = 5
+	p.resolve(context.Background(), block, compact)
+	if in.PrevoutValue != 0 || in.PrevoutScriptPubKey != nil || compact.Vtx[1].Fee != 0 {
+		t.Fatal("unresolvable prevout should be left unset")
+	}
+}
+
+func TestPrevoutStoreBounded(t *testing.T) {
+	p := NewPrevoutStore(2)
+	for i := 0; i < 5; i++ {
+		p.add(outpoint{index: uint32(i)}, &walletrpc.TxOut{Value: uint64(i)})
+	}
+	// Generations: {0, 1} (dropped), {2, 3}, {4}.
+	if p.get(outpoint{index: 1}) != nil {
+		t.Fatal("oldest outputs should have been dropped")
+	}
+	for i := 2; i < 5; i++ {
+		if out := p.get(outpoint{index: uint32(i)}); out == nil || out.Value != uint64(i) {
+			t.Fatal("recent output missing", i)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/zcash/lightwalletd/parser"
+)
+
+var (
+	blockParseErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "lightwalletd_block_parse_errors_total",
+		Help: "Number of distinct blocks the block ingestor failed to parse.",
+	})
+	quarantinedBlocks = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "lightwalletd_quarantined_blocks",
+		Help: "Number of unparsable blocks in the quarantine directory.",
+	})
+	quarantineLastHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "lightwalletd_quarantine_last_height",
+		Help: "Height of the most recently quarantined block.",
+	})
+)
+
+// BlockParseError is returned by getBlockFromRPC when the backend's block
+// can't be parsed or fails verification; it carries the raw block so it can
+// be quarantined.
+type BlockParseError struct {
+	Height int
+	Hash   string // big-endian (display) hex
+	Data   []byte
+	Err    error
+}
+
+func (e *BlockParseError) Error() string {
+	return fmt.Sprintf("error parsing block %d %s: %s", e.Height, e.Hash, e.Err)
+}
+
+func (e *BlockParseError) Unwrap() error {
+	return e.Err
+}
+
+// QuarantinedBlock describes a block in the quarantine directory.
+type QuarantinedBlock struct {
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+	Error  string `json:"error"`
+	File   string `json:"file"`
+}
+
+// Quarantine is a directory of blocks the ingestor could not parse, one
+// file per block, each holding the block's raw hex on a single line (the
+// format of testdata/badblocks), so they can be used to reproduce and test
+// a parser fix. It's also an http.Handler that lists the quarantined blocks
+// as JSON, for the admin (metrics) HTTP server.
+type Quarantine struct {
+	dir      string
+	failFast bool
+	blocks   []QuarantinedBlock
+	mutex    sync.RWMutex
+}
+
+// QuarantineDir returns the directory of quarantined blocks.
+func QuarantineDir(dbPath string, chainName string) string {
+	return filepath.Join(dbPath, chainName, "quarantine")
+}
+
+// quarantineFileName returns the name of the file (within the quarantine
+// directory) holding the given block, "<height>-<hash>".
+func quarantineFileName(height int, hash string) string {
+	return fmt.Sprintf("%d-%s", height, hash)
+}
+
+// NewQuarantine opens the quarantine directory, creating it if necessary,
+// and lists the blocks already in it.
+// (No locking here, we assume this is single-threaded.)
+func NewQuarantine(dbPath string, chainName string) *Quarantine {
+	q := &Quarantine{dir: QuarantineDir(dbPath, chainName)}
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		Log.Fatal("mkdir ", q.dir, " failed: ", err)
+	}
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		Log.Fatal("read quarantine directory ", q.dir, " failed: ", err)
+	}
+	for _, entry := range entries {
+		heightStr, hash, found := strings.Cut(entry.Name(), "-")
+		height, err := strconv.Atoi(heightStr)
+		if !found || err != nil {
+			continue
+		}
+		b := QuarantinedBlock{Height: height, Hash: hash, File: filepath.Join(q.dir, entry.Name())}
+		// The error isn't saved; parsing the block again reproduces it
+		// (or shows that this version of lightwalletd can now parse it).
+		b.Error = "parses successfully"
+		if err := reparse(b.File); err != nil {
+			b.Error = err.Error()
+		}
+		q.blocks = append(q.blocks, b)
+	}
+	slices.SortFunc(q.blocks, func(a, b QuarantinedBlock) int { return a.Height - b.Height })
+	quarantinedBlocks.Set(float64(len(q.blocks)))
+	return q
+}
+
+// reparse returns the error from parsing (and, if enabled, verifying) the
+// quarantined block in the given file.
+func reparse(name string) error {
+	blockHex, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	blockData, err := hex.DecodeString(strings.TrimSpace(string(blockHex)))
+	if err != nil {
+		return err
+	}
+	block := parser.NewBlock()
+	rest, err := block.ParseFromSlice(blockData)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("received overlong message")
+	}
+	if ProofOfWorkLimit != nil {
+		if err := block.VerifyProofOfWork(ProofOfWorkLimit); err != nil {
+			return fmt.Errorf("invalid proof of work: %w", err)
+		}
+	}
+	// The txids that getblock reported aren't saved, so this can only
+	// check the ones lightwalletd can compute.
+	if VerifyMerkleRoots {
+		if err := block.VerifyMerkleRoot(); err != nil && !errors.Is(err, parser.ErrTxIDUnknown) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFailFast sets whether the ingestor exits, rather than retries, once it
+// has quarantined a block.
+// (No locking here, we assume this is single-threaded.)
+func (q *Quarantine) SetFailFast(failFast bool) {
+	q.failFast = failFast
+}
+
+// FailFast returns whether the ingestor should exit after quarantining a block.
+func (q *Quarantine) FailFast() bool {
+	return q.failFast
+}
+
+// Add saves the unparsable block to the quarantine directory, unless it's
+// already there, and returns the name of its file.
+func (q *Quarantine) Add(e *BlockParseError) string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	name := filepath.Join(q.dir, quarantineFileName(e.Height, e.Hash))
+	for _, b := range q.blocks {
+		if b.File == name {
+			return name
+		}
+	}
+	blockParseErrorsTotal.Inc()
+	if err := os.WriteFile(name, []byte(hex.EncodeToString(e.Data)+"\n"), 0644); err != nil {
+		Log.Fatal("write quarantined block ", name, " failed: ", err)
+	}
+	q.blocks = append(q.blocks, QuarantinedBlock{
+		Height: e.Height,
+		Hash:   e.Hash,
+		Error:  e.Err.Error(),
+		File:   name,
+	})
+	quarantinedBlocks.Set(float64(len(q.blocks)))
+	quarantineLastHeight.Set(float64(e.Height))
+	return name
+}
+
+// Blocks returns the quarantined blocks.
+func (q *Quarantine) Blocks() []QuarantinedBlock {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	return slices.Clone(q.blocks)
+}
+
+// ServeHTTP lists the quarantined blocks as a JSON array.
+func (q *Quarantine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	blocks := q.Blocks()
+	if blocks == nil {
+		blocks = []QuarantinedBlock{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
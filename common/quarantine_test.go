@@ -0,0 +1,220 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A truncated block header, from testdata/badblocks.
+const quarantineTestBlock = "040000008a024cebb99e30ff83d5b9f50cc5303351923da95a8dc7fda3e016090000"
+
+func TestQuarantine(t *testing.T) {
+	dbPath := t.TempDir()
+	q := NewQuarantine(dbPath, unitTestChain)
+	if len(q.Blocks()) != 0 {
+		t.Fatal("new quarantine should be empty")
+	}
+	data, _ := hex.DecodeString(quarantineTestBlock)
+	e := &BlockParseError{Height: 380640, Hash: "00ab", Data: data, Err: errors.New("test failure")}
+	errorsTotal := counterValue(blockParseErrorsTotal)
+	name := q.Add(e)
+	if q.Add(e) != name || len(q.Blocks()) != 1 {
+		t.Fatal("a block should be quarantined only once")
+	}
+	if counterValue(blockParseErrorsTotal) != errorsTotal+1 {
+		t.Fatal("a block's parse errors should be counted once")
+	}
+	contents, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != quarantineTestBlock+"\n" {
+		t.Fatal("unexpected quarantined block file contents")
+	}
+	b := q.Blocks()[0]
+	if b.Height != 380640 || b.Hash != "00ab" || b.Error != "test failure" {
+		t.Fatal("unexpected quarantined block", b)
+	}
+
+	// After a restart, the error comes from parsing the block again.
+	q = NewQuarantine(dbPath, unitTestChain)
+	blocks := q.Blocks()
+	if len(blocks) != 1 || blocks[0].Height != 380640 || blocks[0].Hash != "00ab" ||
+		blocks[0].File != name || blocks[0].Error == "test failure" || blocks[0].Error == "parses successfully" {
+		t.Fatal("unexpected quarantined blocks after restart", blocks)
+	}
+
+	w := httptest.NewRecorder()
+	q.ServeHTTP(w, httptest.NewRequest("GET", "/quarantine", nil))
+	var served []QuarantinedBlock
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil {
+		t.Fatal(err)
+	}
+	if len(served) != 1 || served[0] != blocks[0] {
+		t.Fatal("unexpected quarantine list", w.Body.String())
+	}
+}
+
+// badBlockStub returns a block that can't be parsed, as many times as the
+// ingestor asks for it.
+func badBlockStub(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+	step++
+	switch (step-1)%3 + 1 {
+	case 1:
+		r, _ := json.Marshal(strings.Repeat("01", 32))
+		return r, nil
+	case 2:
+		return []byte("{\"Tx\": [\"" + testTxid + "\"], \"Hash\": \"" + testBlockid40 + "\"}"), nil
+	case 3:
+		r, _ := json.Marshal(quarantineTestBlock)
+		return r, nil
+	}
+	return nil, nil
+}
+
+func TestBlockIngestorQuarantine(t *testing.T) {
+	testT = t
+	RawRequest = badBlockStub
+	defer resetGlobals()
+	Time.Sleep = sleepStub
+	Time.Now = nowStub
+	os.RemoveAll(unitTestPath)
+	testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, -1)
+	BlockIngestor(testcache, 3)
+	blocks := testcache.Quarantine().Blocks()
+	if len(blocks) != 1 || blocks[0].Height != 380640 || blocks[0].Hash != testBlockid40 {
+		t.Fatal("unexpected quarantined blocks", blocks)
+	}
+	// The ingestor retries the block later, backing off.
+	if testcache.GetNextHeight() != 380640 || sleepCount != 3 || sleepDuration != (8+16+32)*time.Second {
+		t.Fatal("unexpected retries", sleepCount, sleepDuration)
+	}
+	testcache.Close()
+	os.RemoveAll(unitTestPath)
+}
+
+func TestBlockIngestorQuarantinesInvalidProofOfWork(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	ProofOfWorkLimit = PowLimit("test")
+	defer func() { ProofOfWorkLimit = nil }()
+
+	// Block 380640 with a different nonce, so its solution is invalid.
+	var blockHex string
+	if err := json.Unmarshal(blocks[0], &blockHex); err != nil {
+		t.Fatal(err)
+	}
+	blockData, _ := hex.DecodeString(blockHex)
+	blockData[108] ^= 1 // the first byte of the nonce
+	badBlock, _ := json.Marshal(hex.EncodeToString(blockData))
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		step++
+		switch step {
+		case 1:
+			r, _ := json.Marshal(strings.Repeat("01", 32))
+			return r, nil
+		case 2:
+			return []byte("{\"Tx\": [\"" + testTxid + "\"], \"Hash\": \"" + testBlockid40 + "\"}"), nil
+		case 3:
+			return badBlock, nil
+		}
+		t.Error("RawRequest called too many times")
+		return nil, nil
+	}
+	Time.Sleep = sleepStub
+	Time.Now = nowStub
+	os.RemoveAll(unitTestPath)
+	testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, -1)
+	BlockIngestor(testcache, 1)
+	blocks := testcache.Quarantine().Blocks()
+	if len(blocks) != 1 || blocks[0].Height != 380640 ||
+		!strings.HasPrefix(blocks[0].Error, "invalid proof of work: ") {
+		t.Fatal("unexpected quarantined blocks", blocks)
+	}
+	if testcache.GetNextHeight() != 380640 {
+		t.Fatal("the invalid block shouldn't be added to the cache")
+	}
+
+	// After a restart, verifying the block again reproduces the error.
+	q := NewQuarantine(unitTestPath, unitTestChain)
+	if b := q.Blocks(); len(b) != 1 || b[0].Error != blocks[0].Error {
+		t.Fatal("unexpected quarantined blocks after restart", b)
+	}
+	testcache.Close()
+	os.RemoveAll(unitTestPath)
+}
+
+func TestBlockIngestorQuarantinesInvalidMerkleRoot(t *testing.T) {
+	// The txid of block 380640's only transaction.
+	const txid = "20a2b12ff0973c854aa37fbb302afb238c42b8e3c230ba0e902e2b11a90a458c"
+	var blockHex string
+	if err := json.Unmarshal(blocks[0], &blockHex); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name       string
+		txid       string
+		merkleRoot bool // corrupt the header's hashMerkleRoot
+		want       string
+	}{
+		{"valid", txid, false, ""},
+		{"wrong merkle root", txid, true, "merkle root "},
+		{"wrong txid", testTxid, false, "computing merkle root: vtx[0]: txid " + testTxid},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testT = t
+			defer resetGlobals()
+			VerifyMerkleRoots = true
+			defer func() { VerifyMerkleRoots = false }()
+
+			blockData, _ := hex.DecodeString(blockHex)
+			if tt.merkleRoot {
+				blockData[36] ^= 1
+			}
+			block, _ := json.Marshal(hex.EncodeToString(blockData))
+			RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+				step++
+				switch step {
+				case 1:
+					r, _ := json.Marshal(strings.Repeat("01", 32))
+					return r, nil
+				case 2:
+					return []byte("{\"Tx\": [\"" + tt.txid + "\"], \"Hash\": \"" + testBlockid40 + "\"}"), nil
+				case 3:
+					return block, nil
+				}
+				return nil, errors.New("no more blocks")
+			}
+			Time.Sleep = sleepStub
+			Time.Now = nowStub
+			os.RemoveAll(unitTestPath)
+			testcache = NewBlockCache(unitTestPath, unitTestChain, 380640, -1)
+			defer os.RemoveAll(unitTestPath)
+			defer testcache.Close()
+			BlockIngestor(testcache, 1)
+			quarantined := testcache.Quarantine().Blocks()
+			if tt.want == "" {
+				if len(quarantined) != 0 || testcache.GetNextHeight() != 380641 {
+					t.Fatal("the valid block should be added to the cache", quarantined)
+				}
+				return
+			}
+			if len(quarantined) != 1 || !strings.HasPrefix(quarantined[0].Error, tt.want) {
+				t.Fatal("unexpected quarantined blocks", quarantined)
+			}
+			if testcache.GetNextHeight() != 380640 {
+				t.Fatal("the invalid block shouldn't be added to the cache")
+			}
+		})
+	}
+}
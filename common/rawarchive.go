@@ -0,0 +1,459 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+)
+
+// rawSegmentBlocks is the number of consecutive blocks stored in each
+// segment of the raw block archive. Retention is enforced by removing
+// whole segments, so this is also the granularity of pruning.
+const rawSegmentBlocks = 10000
+
+// rawArchiveCatchUpBlocks is the number of cached blocks the block ingestor
+// fetches into the raw block archive each time it finds the chain tip
+// unchanged, while the archive catches up with the cache.
+const rawArchiveCatchUpBlocks = 1000
+
+// RawBlockArchive contains a consecutive set of recent full (raw, serialized)
+// blocks, as returned by the backend's non-verbose getblock. It is kept
+// alongside the compact block cache, is written by the block ingestor when
+// it adds a block to the cache, and follows the cache through reorgs.
+//
+// The archive is divided into segments of rawSegmentBlocks blocks; each
+// segment has a lengths file and a blocks file laid out the same way as the
+// compact block cache's. The oldest segments are removed once they fall
+// entirely outside the retention window.
+type RawBlockArchive struct {
+	dir         string        // directory holding the segment files
+	retain      int           // number of most recent blocks to keep, 0 means all
+	segmentSize int           // blocks per segment (rawSegmentBlocks except in tests)
+	segments    []*rawSegment // in height order, consecutive
+	firstBlock  int           // height of the first block in the archive
+	nextBlock   int           // height of the first block not in the archive
+	mutex       sync.RWMutex
+}
+
+// rawSegment is one pair of archive files, holding the blocks starting at
+// height start.
+type rawSegment struct {
+	start                   int
+	lengthsName, blocksName string // pathnames
+	lengthsFile, blocksFile *os.File
+	starts                  []int64 // Starting offset of each block within blocksFile
+}
+
+// count returns the number of blocks in the segment.
+func (s *rawSegment) count() int {
+	return len(s.starts) - 1
+}
+
+func (s *rawSegment) close() {
+	s.lengthsFile.Close()
+	s.blocksFile.Close()
+}
+
+func (s *rawSegment) remove() {
+	s.close()
+	os.Remove(s.lengthsName)
+	os.Remove(s.blocksName)
+}
+
+// truncate reduces the segment to its first n blocks.
+func (s *rawSegment) truncate(n int) {
+	s.starts = s.starts[:n+1]
+	if err := s.lengthsFile.Truncate(int64(4 * n)); err != nil {
+		Log.Fatal("truncate raw lengths file failed: ", err)
+	}
+	if err := s.blocksFile.Truncate(s.starts[n]); err != nil {
+		Log.Fatal("truncate raw blocks file failed: ", err)
+	}
+}
+
+// RawArchiveDir returns the directory that holds the raw block archive.
+func RawArchiveDir(dbPath string, chainName string) string {
+	return filepath.Join(dbPath, chainName, "raw")
+}
+
+func rawSegmentFileNames(dir string, start int) (string, string) {
+	return filepath.Join(dir, fmt.Sprintf("lengths-%010d", start)),
+		filepath.Join(dir, fmt.Sprintf("blocks-%010d", start))
+}
+
+// openRawSegment opens (creating if necessary) the segment starting at the
+// given height and reads its lengths file. It returns nil if the segment's
+// files are inconsistent (for example, after a crash during a write).
+func openRawSegment(dir string, start int) *rawSegment {
+	s := &rawSegment{start: start}
+	s.lengthsName, s.blocksName = rawSegmentFileNames(dir, start)
+	var err error
+	s.blocksFile, err = os.OpenFile(s.blocksName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		Log.Fatal("open ", s.blocksName, " failed: ", err)
+	}
+	s.lengthsFile, err = os.OpenFile(s.lengthsName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		Log.Fatal("open ", s.lengthsName, " failed: ", err)
+	}
+	lengths, err := os.ReadFile(s.lengthsName)
+	if err != nil {
+		Log.Fatal("read ", s.lengthsName, " failed: ", err)
+	}
+	info, err := s.blocksFile.Stat()
+	if err != nil {
+		Log.Fatal("stat ", s.blocksName, " failed: ", err)
+	}
+	if len(lengths)%4 != 0 {
+		Log.Warning("raw lengths file ", s.lengthsName, " has a partial entry")
+		return nil
+	}
+	var offset int64
+	s.starts = append(s.starts, 0)
+	for i := 0; i < len(lengths)/4; i++ {
+		length := binary.LittleEndian.Uint32(lengths[i*4 : (i+1)*4])
+		if length == 0 || length > 4*1000*1000 {
+			Log.Warning("raw lengths file ", s.lengthsName, " has impossible value ", length)
+			return nil
+		}
+		offset += int64(length) + 8
+		s.starts = append(s.starts, offset)
+	}
+	if offset != info.Size() {
+		Log.Warning("raw blocks file ", s.blocksName, " does not match its lengths file")
+		return nil
+	}
+	return s
+}
+
+// NewRawBlockArchive returns an instance of a raw block archive, reading any
+// segments already on disk. retain is the number of most recent blocks to
+// keep (0 means keep everything); since whole segments are removed, up to
+// rawSegmentBlocks more than that may be kept.
+// (No locking here, we assume this is single-threaded.)
+func NewRawBlockArchive(dbPath string, chainName string, retain int) *RawBlockArchive {
+	a := &RawBlockArchive{
+		dir:         RawArchiveDir(dbPath, chainName),
+		retain:      retain,
+		segmentSize: rawSegmentBlocks,
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		Log.Fatal("mkdir ", a.dir, " failed: ", err)
+	}
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		Log.Fatal("read dir ", a.dir, " failed: ", err)
+	}
+	var starts []int
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), "blocks-")
+		if !ok {
+			continue
+		}
+		start, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	slices.Sort(starts)
+
+	// Keep the longest run of consistent, consecutive segments from the
+	// start; anything after a damaged or missing segment is discarded.
+	for i, start := range starts {
+		var s *rawSegment
+		if len(a.segments) == 0 || start == a.nextBlock {
+			s = openRawSegment(a.dir, start)
+		}
+		if s == nil {
+			Log.Warning("CORRUPTION detected in raw block archive, discarding from height ", start)
+			for _, start := range starts[i:] {
+				lengthsName, blocksName := rawSegmentFileNames(a.dir, start)
+				os.Remove(lengthsName)
+				os.Remove(blocksName)
+			}
+			break
+		}
+		if len(a.segments) == 0 {
+			a.firstBlock = start
+		}
+		a.segments = append(a.segments, s)
+		a.nextBlock = start + s.count()
+	}
+	Log.Info("Done reading ", a.nextBlock-a.firstBlock, " blocks from raw block archive")
+	return a
+}
+
+// GetFirstHeight returns the height of the lowest block in the archive.
+func (a *RawBlockArchive) GetFirstHeight() int {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.firstBlock
+}
+
+// GetNextHeight returns the height of the lowest block not in the archive.
+func (a *RawBlockArchive) GetNextHeight() int {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.nextBlock
+}
+
+// behind reports whether adding a block at the given height would leave a
+// gap above the top of the (nonempty) archive.
+func (a *RawBlockArchive) behind(height int) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return len(a.segments) > 0 && height > a.nextBlock
+}
+
+// Caller should hold a.mutex.Lock().
+func (a *RawBlockArchive) clear() {
+	for _, s := range a.segments {
+		s.remove()
+	}
+	a.segments = nil
+	a.firstBlock = 0
+	a.nextBlock = 0
+}
+
+// Add adds the given raw block to the archive at the given height. Adding
+// at a height below the top of the archive first discards the blocks at and
+// above that height (as Reorg does); adding at a height that leaves a gap
+// discards the whole archive and starts it again at that height, since the
+// archive must hold consecutive blocks.
+func (a *RawBlockArchive) Add(height int, data []byte) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(a.segments) > 0 && height > a.nextBlock {
+		Log.Warning("raw block archive gap at height ", height, ", restarting the archive")
+		a.clear()
+	}
+	if len(a.segments) > 0 && height < a.nextBlock {
+		a.reorg(height)
+	}
+	if len(a.segments) == 0 {
+		a.firstBlock = height
+		a.nextBlock = height
+	}
+	if len(a.segments) == 0 || a.segments[len(a.segments)-1].count() >= a.segmentSize {
+		// Remove any leftover files from a segment previously discarded
+		// at this height, so the new segment starts out empty.
+		lengthsName, blocksName := rawSegmentFileNames(a.dir, height)
+		os.Remove(lengthsName)
+		os.Remove(blocksName)
+		a.segments = append(a.segments, openRawSegment(a.dir, height))
+	}
+	seg := a.segments[len(a.segments)-1]
+
+	b := append(checksum(height, data), data...)
+	n, err := seg.blocksFile.Write(b)
+	if err != nil {
+		Log.Fatal("raw blocks write failed: ", err)
+	}
+	if n != len(b) {
+		Log.Fatal("raw blocks write incorrect length: expected: ", len(b), "written: ", n)
+	}
+	b = make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(len(data)))
+	n, err = seg.lengthsFile.Write(b)
+	if err != nil {
+		Log.Fatal("raw lengths write failed: ", err)
+	}
+	if n != len(b) {
+		Log.Fatal("raw lengths write incorrect length: expected: ", len(b), "written: ", n)
+	}
+	seg.starts = append(seg.starts, seg.starts[len(seg.starts)-1]+int64(len(data)+8))
+	a.nextBlock++
+	a.prune()
+	return nil
+}
+
+// prune removes the oldest segments that lie entirely outside the
+// retention window. Caller should hold a.mutex.Lock().
+func (a *RawBlockArchive) prune() {
+	if a.retain <= 0 {
+		return
+	}
+	for len(a.segments) > 1 {
+		s := a.segments[0]
+		if s.start+s.count() > a.nextBlock-a.retain {
+			break
+		}
+		s.remove()
+		a.segments = a.segments[1:]
+		a.firstBlock = a.segments[0].start
+	}
+}
+
+// Reorg resets nextBlock (the block that should be Add()ed next)
+// downward to the given height.
+func (a *RawBlockArchive) Reorg(height int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.reorg(height)
+}
+
+// matchCache discards the archived blocks the given cache doesn't have:
+// those above the cache's top, and those the cache has since replaced.
+// This happens when lightwalletd runs for a while without --raw-archive,
+// which leaves the archive in place but no longer updates it. Blocks below
+// the cache's first height can't be checked, and are kept.
+func (a *RawBlockArchive) matchCache(c *BlockCache) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	height := min(a.nextBlock, c.GetNextHeight())
+	for height > a.firstBlock {
+		block := c.Get(height - 1)
+		if block == nil {
+			break
+		}
+		if data := a.get(height - 1); data != nil {
+			hdr := parser.NewBlockHeader()
+			if _, err := hdr.ParseFromSlice(data); err == nil &&
+				hdr.GetEncodableHash() == hash32.FromSlice(block.Hash) {
+				break
+			}
+		}
+		height--
+	}
+	if height < a.nextBlock {
+		Log.Warning("raw block archive doesn't match the cache from height ", height, ", discarding blocks")
+		a.reorg(height)
+	}
+}
+
+// Caller should hold a.mutex.Lock().
+func (a *RawBlockArchive) reorg(height int) {
+	if height >= a.nextBlock {
+		// Timing window, ignore this request
+		return
+	}
+	if height <= a.firstBlock {
+		a.clear()
+		return
+	}
+	for len(a.segments) > 0 {
+		s := a.segments[len(a.segments)-1]
+		if s.start < height {
+			s.truncate(height - s.start)
+			break
+		}
+		s.remove()
+		a.segments = a.segments[:len(a.segments)-1]
+	}
+	a.nextBlock = height
+}
+
+// Get returns the raw block at the requested height if it's in the
+// archive, else nil.
+func (a *RawBlockArchive) Get(height int) []byte {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.get(height)
+}
+
+// Caller should hold a.mutex.RLock() or a.mutex.Lock().
+func (a *RawBlockArchive) get(height int) []byte {
+	if height < a.firstBlock || height >= a.nextBlock {
+		return nil
+	}
+	i, _ := slices.BinarySearchFunc(a.segments, height, func(s *rawSegment, h int) int {
+		if s.start+s.count() <= h {
+			return -1
+		}
+		if s.start > h {
+			return 1
+		}
+		return 0
+	})
+	s := a.segments[i]
+	index := height - s.start
+	blockLen := int(s.starts[index+1]-s.starts[index]) - 8
+	b := make([]byte, blockLen+8)
+	n, err := s.blocksFile.ReadAt(b, s.starts[index])
+	if err != nil || n != len(b) {
+		Log.Warning("raw blocks read offset: ", s.starts[index], " failed: ", n, err)
+		return nil
+	}
+	if !bytes.Equal(checksum(height, b[8:]), b[:8]) {
+		Log.Warning("bad raw block checksum at height: ", height)
+		return nil
+	}
+	return b[8:]
+}
+
+// GetTransaction returns the raw transaction at the given index within the
+// block at the given height if the block is in the archive, else nil.
+func (a *RawBlockArchive) GetTransaction(height int, index int) []byte {
+	txs := a.GetTransactions(height, []int{index})
+	if txs == nil {
+		return nil
+	}
+	return txs[0]
+}
+
+// GetTransactions returns the raw transactions at the given indices within
+// the block at the given height, reading and parsing the block once, if the
+// block is in the archive, else nil. The transaction at an index outside the
+// block is nil.
+func (a *RawBlockArchive) GetTransactions(height int, indices []int) [][]byte {
+	data := a.Get(height)
+	if data == nil {
+		return nil
+	}
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(data); err != nil {
+		Log.Warning("can't parse archived raw block at height ", height, ": ", err)
+		return nil
+	}
+	blockTxs := block.Transactions()
+	txs := make([][]byte, len(indices))
+	for i, index := range indices {
+		if index >= 0 && index < len(blockTxs) {
+			txs[i] = blockTxs[index].Bytes()
+		}
+	}
+	return txs
+}
+
+// Reset empties the archive; it's used only for darkside testing.
+func (a *RawBlockArchive) Reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.clear()
+}
+
+// Sync ensures that the archive files are flushed to disk, can be called unnecessarily.
+func (a *RawBlockArchive) Sync() {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	for _, s := range a.segments {
+		s.lengthsFile.Sync()
+		s.blocksFile.Sync()
+	}
+}
+
+// Close is currently used only for testing.
+func (a *RawBlockArchive) Close() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, s := range a.segments {
+		s.close()
+	}
+	a.segments = nil
+}
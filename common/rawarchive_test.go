@@ -0,0 +1,261 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+)
+
+// rawTestBlock returns distinguishable stand-in block data for the given height.
+func rawTestBlock(height int, fork string) []byte {
+	return []byte(fmt.Sprintf("raw block %d%s", height, fork))
+}
+
+func checkRawArchive(t *testing.T, a *RawBlockArchive, first, next int, fork string) {
+	t.Helper()
+	if a.GetFirstHeight() != first {
+		t.Fatal("unexpected first height ", a.GetFirstHeight(), " expected ", first)
+	}
+	if a.GetNextHeight() != next {
+		t.Fatal("unexpected next height ", a.GetNextHeight(), " expected ", next)
+	}
+	for h := first; h < next; h++ {
+		if !bytes.Equal(a.Get(h), rawTestBlock(h, fork)) {
+			t.Fatal("unexpected block contents at height ", h)
+		}
+	}
+	if a.Get(first-1) != nil || a.Get(next) != nil {
+		t.Fatal("Get outside the archive should return nil")
+	}
+}
+
+func TestRawBlockArchive(t *testing.T) {
+	dbPath := t.TempDir()
+	a := NewRawBlockArchive(dbPath, unitTestChain, 0)
+	a.segmentSize = 4
+
+	// Initially the archive is empty.
+	if a.Get(0) != nil {
+		t.Fatal("unexpected block in empty archive")
+	}
+	// Fill three segments (4 + 4 + 2 blocks).
+	for h := 100; h < 110; h++ {
+		if err := a.Add(h, rawTestBlock(h, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkRawArchive(t, a, 100, 110, "")
+	if len(a.segments) != 3 {
+		t.Fatal("unexpected number of segments ", len(a.segments))
+	}
+
+	// Reorg into the middle segment, then re-add a different fork.
+	a.Reorg(106)
+	checkRawArchive(t, a, 100, 106, "")
+	if len(a.segments) != 2 {
+		t.Fatal("unexpected number of segments after reorg ", len(a.segments))
+	}
+	for h := 106; h < 110; h++ {
+		if err := a.Add(h, rawTestBlock(h, "b")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(a.Get(105), rawTestBlock(105, "")) ||
+		!bytes.Equal(a.Get(106), rawTestBlock(106, "b")) {
+		t.Fatal("unexpected block contents after reorg")
+	}
+
+	// Adding below the top behaves like a reorg to that height.
+	if err := a.Add(104, rawTestBlock(104, "c")); err != nil {
+		t.Fatal(err)
+	}
+	if a.GetNextHeight() != 105 || !bytes.Equal(a.Get(104), rawTestBlock(104, "c")) {
+		t.Fatal("unexpected state after adding below the top")
+	}
+	a.Reorg(104)
+	for h := 104; h < 110; h++ {
+		if err := a.Add(h, rawTestBlock(h, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Simulate a restart to ensure the files are read correctly.
+	a.Close()
+	a = NewRawBlockArchive(dbPath, unitTestChain, 0)
+	a.segmentSize = 4
+	checkRawArchive(t, a, 100, 110, "")
+
+	// Adding with a gap restarts the archive at that height.
+	if err := a.Add(200, rawTestBlock(200, "")); err != nil {
+		t.Fatal(err)
+	}
+	checkRawArchive(t, a, 200, 201, "")
+
+	// Reorg to before the first block empties the archive.
+	a.Reorg(150)
+	if a.GetNextHeight() != 0 || a.Get(200) != nil {
+		t.Fatal("archive should be empty")
+	}
+	a.Close()
+}
+
+func TestRawBlockArchiveRetention(t *testing.T) {
+	dbPath := t.TempDir()
+	a := NewRawBlockArchive(dbPath, unitTestChain, 5)
+	a.segmentSize = 4
+	for h := 0; h < 12; h++ {
+		if err := a.Add(h, rawTestBlock(h, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The last 5 blocks are 7..11; only whole segments are removed, so
+	// the segment holding 4..7 is kept.
+	checkRawArchive(t, a, 4, 12, "")
+	lengthsName, blocksName := rawSegmentFileNames(a.dir, 0)
+	if _, err := os.Stat(lengthsName); !os.IsNotExist(err) {
+		t.Fatal("pruned lengths file should have been removed")
+	}
+	if _, err := os.Stat(blocksName); !os.IsNotExist(err) {
+		t.Fatal("pruned blocks file should have been removed")
+	}
+	a.Close()
+}
+
+func TestRawBlockArchiveCorruption(t *testing.T) {
+	dbPath := t.TempDir()
+	a := NewRawBlockArchive(dbPath, unitTestChain, 0)
+	a.segmentSize = 4
+	for h := 0; h < 10; h++ {
+		if err := a.Add(h, rawTestBlock(h, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Close()
+
+	// Simulate a crash part-way through writing a block to the middle segment.
+	_, blocksName := rawSegmentFileNames(a.dir, 4)
+	f, err := os.OpenFile(blocksName, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("partial"))
+	f.Close()
+
+	// The damaged segment and everything after it are discarded.
+	a = NewRawBlockArchive(dbPath, unitTestChain, 0)
+	checkRawArchive(t, a, 0, 4, "")
+	a.Close()
+}
+
+func TestRawBlockArchiveGetTransaction(t *testing.T) {
+	dbPath := t.TempDir()
+	a := NewRawBlockArchive(dbPath, unitTestChain, 0)
+	defer a.Close()
+	var blockHex string
+	if err := json.Unmarshal(blocks[0], &blockHex); err != nil {
+		t.Fatal(err)
+	}
+	data, err := hex.DecodeString(blockHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add(380640, data); err != nil {
+		t.Fatal(err)
+	}
+	block := parser.NewBlock()
+	if _, err := block.ParseFromSlice(data); err != nil {
+		t.Fatal(err)
+	}
+	txs := block.Transactions()
+	for i, tx := range txs {
+		if !bytes.Equal(a.GetTransaction(380640, i), tx.Bytes()) {
+			t.Fatal("unexpected transaction at index ", i)
+		}
+	}
+	if a.GetTransaction(380640, len(txs)) != nil || a.GetTransaction(380640, -1) != nil ||
+		a.GetTransaction(380641, 0) != nil {
+		t.Fatal("GetTransaction outside the block or archive should return nil")
+	}
+	got := a.GetTransactions(380640, []int{len(txs) - 1, len(txs), 0})
+	if len(got) != 3 || !bytes.Equal(got[0], txs[len(txs)-1].Bytes()) || got[1] != nil ||
+		!bytes.Equal(got[2], txs[0].Bytes()) {
+		t.Fatal("unexpected GetTransactions result")
+	}
+	if a.GetTransactions(380641, []int{0}) != nil {
+		t.Fatal("GetTransactions outside the archive should return nil")
+	}
+}
+
+func TestRawBlockArchiveMatchCache(t *testing.T) {
+	defer resetGlobals()
+	dbPath := t.TempDir()
+	var raw [4][]byte
+	c := NewBlockCache(dbPath, unitTestChain, 380640, 0)
+	defer c.Close()
+	for i := range raw {
+		var blockHex string
+		if err := json.Unmarshal(blocks[i], &blockHex); err != nil {
+			t.Fatal(err)
+		}
+		raw[i], _ = hex.DecodeString(blockHex)
+		if i == 3 {
+			break
+		}
+		block := parser.NewBlock()
+		if _, err := block.ParseFromSlice(raw[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Add(380640+i, block.ToCompact()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The archive was kept while the cache replaced the block at 380642
+	// and dropped the one at 380643.
+	a := NewRawBlockArchive(dbPath, unitTestChain, 0)
+	defer a.Close()
+	for i, data := range [][]byte{raw[0], raw[1], raw[0], raw[3]} {
+		if err := a.Add(380640+i, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.SetRawArchive(a)
+	if a.GetFirstHeight() != 380640 || a.GetNextHeight() != 380642 {
+		t.Fatal("unexpected archive heights ", a.GetFirstHeight(), a.GetNextHeight())
+	}
+	if !a.behind(380643) || a.behind(380642) {
+		t.Fatal("unexpected behind result")
+	}
+
+	// The ingestor fetches the missing block by its hash.
+	RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		var hash string
+		json.Unmarshal(params[0], &hash)
+		if method != "getblock" || hash != displayHash(hash32.FromSlice(c.Get(380642).Hash)) ||
+			string(params[1]) != "0" {
+			t.Fatal("unexpected request ", method, " ", hash, " ", string(params[1]))
+		}
+		return blocks[2], nil
+	}
+	catchUpRawArchive(c, a, 10)
+	if a.GetNextHeight() != 380643 || !bytes.Equal(a.Get(380642), raw[2]) {
+		t.Fatal("raw block archive didn't catch up with the cache")
+	}
+
+	// An archive entirely above the cache is discarded.
+	c.Reorg(380640)
+	c.SetRawArchive(a)
+	if a.GetFirstHeight() != 0 || a.GetNextHeight() != 0 {
+		t.Fatal("unexpected archive heights ", a.GetFirstHeight(), a.GetNextHeight())
+	}
+}
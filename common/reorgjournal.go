@@ -0,0 +1,215 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	reorgsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "lightwalletd_reorgs_total",
+		Help: "Number of chain reorganizations observed by the block ingestor.",
+	})
+	reorgOrphanedBlocksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "lightwalletd_reorg_orphaned_blocks_total",
+		Help: "Number of blocks removed from the cache by chain reorganizations.",
+	})
+	reorgDepth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "lightwalletd_reorg_depth",
+		Help:    "Depth (number of blocks removed) of observed chain reorganizations.",
+		Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+	})
+	reorgLastTime = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "lightwalletd_reorg_last_timestamp_seconds",
+		Help: "Unix time of the most recent observed chain reorganization.",
+	})
+)
+
+// ReorgBlock identifies a block involved in a reorg. The hash is in
+// big-endian (display) hex, as zcashd and zebrad report it.
+type ReorgBlock struct {
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// ReorgEvent records one chain reorganization: the blocks the ingestor
+// removed from the cache (in increasing height order) and the blocks that
+// replaced them. The new chain may be shorter than the orphaned one, in
+// which case there are fewer new blocks than orphaned blocks.
+type ReorgEvent struct {
+	Time     int64        `json:"time"` // Unix epoch seconds
+	Depth    int          `json:"depth"`
+	Orphaned []ReorgBlock `json:"orphaned"`
+	New      []ReorgBlock `json:"new"`
+}
+
+// maxReorgJournalMemory bounds the number of (most recent) events kept in
+// memory and returned by Events(); the file on disk keeps them all.
+const maxReorgJournalMemory = 10000
+
+// ReorgJournal is a persistent, append-only record of the reorgs the block
+// ingestor has observed. Each event is a line of JSON in the journal file.
+type ReorgJournal struct {
+	name   string // pathname
+	file   *os.File
+	events []*ReorgEvent
+	mutex  sync.RWMutex
+}
+
+// ReorgJournalFileName returns the pathname of the reorg journal file.
+func ReorgJournalFileName(dbPath string, chainName string) string {
+	return filepath.Join(dbPath, chainName, "reorgs")
+}
+
+// NewReorgJournal opens (creating if necessary) the reorg journal and reads
+// the events already recorded in it.
+// (No locking here, we assume this is single-threaded.)
+func NewReorgJournal(dbPath string, chainName string) *ReorgJournal {
+	j := &ReorgJournal{name: ReorgJournalFileName(dbPath, chainName)}
+	if err := os.MkdirAll(filepath.Dir(j.name), 0755); err != nil {
+		Log.Fatal("mkdir ", filepath.Dir(j.name), " failed: ", err)
+	}
+	var err error
+	j.file, err = os.OpenFile(j.name, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		Log.Fatal("open ", j.name, " failed: ", err)
+	}
+	scan := bufio.NewScanner(j.file)
+	scan.Buffer(nil, 16*1024*1024)
+	for scan.Scan() {
+		event := &ReorgEvent{}
+		if err := json.Unmarshal(scan.Bytes(), event); err != nil {
+			// Probably a partial line from a crash during a write; the
+			// events before it are still good.
+			Log.Warning("reorg journal ", j.name, " has an unreadable entry: ", err)
+			continue
+		}
+		j.append(event)
+	}
+	return j
+}
+
+// Caller should hold j.mutex.Lock().
+func (j *ReorgJournal) append(event *ReorgEvent) {
+	j.events = append(j.events, event)
+	if len(j.events) > maxReorgJournalMemory {
+		j.events = slices.Delete(j.events, 0, len(j.events)-maxReorgJournalMemory)
+	}
+}
+
+// Record adds the given event to the journal, writing it to disk, and
+// updates the reorg metrics.
+func (j *ReorgJournal) Record(event *ReorgEvent) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		Log.Fatal("reorg journal marshal failed: ", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		Log.Fatal("reorg journal write failed: ", err)
+	}
+	j.file.Sync()
+	j.append(event)
+
+	reorgsTotal.Inc()
+	reorgOrphanedBlocksTotal.Add(float64(event.Depth))
+	reorgDepth.Observe(float64(event.Depth))
+	reorgLastTime.Set(float64(event.Time))
+}
+
+// Events returns the recorded events, oldest first.
+func (j *ReorgJournal) Events() []*ReorgEvent {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return slices.Clone(j.events)
+}
+
+// Reset empties the journal; it's used only for darkside testing.
+func (j *ReorgJournal) Reset() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if err := j.file.Truncate(0); err != nil {
+		Log.Fatal("truncate reorg journal failed: ", err)
+	}
+	j.events = nil
+}
+
+// Close is currently used only for testing.
+func (j *ReorgJournal) Close() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+// reorgTracker accumulates the blocks of a reorg in progress. The ingestor
+// removes orphaned blocks one at a time, then adds the replacements one at a
+// time, so a reorg is complete once the new chain reaches the height of the
+// highest orphaned block, or the ingestor is synced with a shorter new chain.
+type reorgTracker struct {
+	event *ReorgEvent
+}
+
+// orphan records that the block at the given height was removed from the cache.
+func (r *reorgTracker) orphan(height int, hash string) {
+	if r.event == nil {
+		r.event = &ReorgEvent{}
+	}
+	if n := len(r.event.New); n > 0 && r.event.New[n-1].Height == height {
+		// A replacement block was itself replaced before the reorg
+		// completed; it was never part of the chain being reorged away.
+		r.event.New = r.event.New[:n-1]
+		return
+	}
+	r.event.Orphaned = append(r.event.Orphaned, ReorgBlock{Height: height, Hash: hash})
+}
+
+// depth returns the number of blocks the reorg in progress has removed so far.
+func (r *reorgTracker) depth() int {
+	if r.event == nil {
+		return 0
+	}
+	return len(r.event.Orphaned)
+}
+
+// add records that a block was added to the cache; it returns the completed
+// event, if this block completes a reorg.
+func (r *reorgTracker) add(height int, hash string) *ReorgEvent {
+	if r.event == nil {
+		return nil
+	}
+	r.event.New = append(r.event.New, ReorgBlock{Height: height, Hash: hash})
+	// The orphans are appended from the top down.
+	if height < r.event.Orphaned[0].Height {
+		return nil
+	}
+	return r.finish()
+}
+
+// finish returns the reorg in progress, if any, as a completed event.
+func (r *reorgTracker) finish() *ReorgEvent {
+	event := r.event
+	if event == nil {
+		return nil
+	}
+	r.event = nil
+	slices.Reverse(event.Orphaned)
+	event.Depth = len(event.Orphaned)
+	event.Time = Time.Now().Unix()
+	return event
+}
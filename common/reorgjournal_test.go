@@ -0,0 +1,103 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReorgTracker(t *testing.T) {
+	Time.Now = time.Now
+	defer resetGlobals()
+
+	var r reorgTracker
+	if r.add(100, "a100") != nil || r.finish() != nil {
+		t.Fatal("no reorg is in progress")
+	}
+
+	// A two-block reorg replaced by a three-block chain: it completes
+	// when the new chain reaches the highest orphaned height.
+	r.orphan(102, "a102")
+	r.orphan(101, "a101")
+	if r.add(101, "b101") != nil {
+		t.Fatal("reorg should not yet be complete")
+	}
+	event := r.add(102, "b102")
+	if event == nil {
+		t.Fatal("reorg should be complete")
+	}
+	if event.Depth != 2 ||
+		!reflect.DeepEqual(event.Orphaned, []ReorgBlock{{101, "a101"}, {102, "a102"}}) ||
+		!reflect.DeepEqual(event.New, []ReorgBlock{{101, "b101"}, {102, "b102"}}) {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if r.add(103, "b103") != nil {
+		t.Fatal("the reorg should have been finished")
+	}
+
+	// A replacement that is itself replaced is not counted as orphaned.
+	r.orphan(104, "b104")
+	r.orphan(103, "b103")
+	r.add(103, "x103")
+	r.orphan(103, "x103")
+	r.add(103, "c103")
+	event = r.add(104, "c104")
+	if event == nil || event.Depth != 2 ||
+		!reflect.DeepEqual(event.Orphaned, []ReorgBlock{{103, "b103"}, {104, "b104"}}) ||
+		!reflect.DeepEqual(event.New, []ReorgBlock{{103, "c103"}, {104, "c104"}}) {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	// A shorter new chain completes when the ingestor is synced.
+	r.orphan(104, "c104")
+	event = r.finish()
+	if event == nil || event.Depth != 1 || len(event.New) != 0 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestReorgJournal(t *testing.T) {
+	dbPath := t.TempDir()
+	j := NewReorgJournal(dbPath, unitTestChain)
+	if len(j.Events()) != 0 {
+		t.Fatal("new journal should be empty")
+	}
+	events := []*ReorgEvent{
+		{Time: 1000, Depth: 1, Orphaned: []ReorgBlock{{5, "aa"}}, New: []ReorgBlock{{5, "bb"}}},
+		{Time: 2000, Depth: 2, Orphaned: []ReorgBlock{{8, "cc"}, {9, "dd"}}, New: []ReorgBlock{{8, "ee"}}},
+	}
+	for _, e := range events {
+		j.Record(e)
+	}
+	if !reflect.DeepEqual(j.Events(), events) {
+		t.Fatal("unexpected events")
+	}
+
+	// Simulate a restart, with a partial entry written by a crash.
+	j.Close()
+	f, err := os.OpenFile(ReorgJournalFileName(dbPath, unitTestChain), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(`{"time":30`))
+	f.Close()
+	j = NewReorgJournal(dbPath, unitTestChain)
+	if !reflect.DeepEqual(j.Events(), events) {
+		t.Fatal("unexpected events after restart")
+	}
+
+	j.Reset()
+	if len(j.Events()) != 0 {
+		t.Fatal("journal should be empty after Reset")
+	}
+	j.Close()
+	j = NewReorgJournal(dbPath, unitTestChain)
+	if len(j.Events()) != 0 {
+		t.Fatal("journal should be empty after Reset and restart")
+	}
+	j.Close()
+}
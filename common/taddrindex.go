@@ -0,0 +1,451 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package common
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/zcash/lightwalletd/address"
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+	"github.com/zcash/lightwalletd/walletrpc"
+)
+
+// taddrIndexCatchUpBlocks is the number of cached blocks the block ingestor
+// adds to the transparent address index each time it finds the chain tip
+// unchanged, while the index catches up with the cache.
+const taddrIndexCatchUpBlocks = 10000
+
+// TaddrEntry is one appearance of a transparent address in the chain: an
+// output paying it (a receive) or an input spending such an output.
+type TaddrEntry struct {
+	Height   int
+	TxIndex  int      // index of the transaction within its block
+	Txid     hash32.T // little-endian
+	Index    uint32   // index of the output (receive) or input (spend)
+	Spending bool
+	Value    uint64 // zatoshis received or spent
+
+	// The output a spend spends, and its height; zero for a receive.
+	PrevTxid   hash32.T
+	PrevIndex  uint32
+	PrevHeight int
+}
+
+// TaddrUtxo is an unspent output paying a transparent address.
+type TaddrUtxo struct {
+	Address address.Transparent
+	Txid    hash32.T // little-endian
+	Index   uint32
+	Script  []byte
+	Value   uint64
+	Height  int
+}
+
+// taddrOutput is an unspent output in the index.
+type taddrOutput struct {
+	addr   address.Transparent
+	value  uint64
+	height int
+}
+
+// TaddrIndex is an in-memory index of the transparent addresses that the
+// P2PKH and P2SH outputs of the cached blocks pay, built from the vin and
+// vout of the compact blocks. It lets the transparent address RPCs work
+// without the backend's address index (zcashd's -insightexplorer or
+// -lightwalletd). Inputs are attributed to the address of the output they
+// spend, so only spends of outputs the index has seen are recorded.
+//
+// The index isn't stored on disk; on startup it's rebuilt from the cache by
+// the block ingestor, which keeps it up to date as it adds blocks. Reorgs
+// are applied to it by BlockCache.Reorg; only the most recent undoDepth
+// blocks can be undone, and a deeper reorg empties the index, which is then
+// rebuilt.
+type TaddrIndex struct {
+	firstBlock int      // height of the first block in the index
+	nextBlock  int      // height of the first block not in the index
+	latestHash hash32.T // hash of the highest block in the index
+	ready      bool     // the index has caught up with the cache
+
+	entries map[address.Transparent][]TaddrEntry // each in height, then transaction order
+	utxos   map[outpoint]taddrOutput
+
+	// log lists the address of each entry at or above undoBlock, in the
+	// order the entries were added, so that Reorg can remove them in reverse.
+	log       []taddrLogEntry
+	undoBlock int // height of the lowest block whose entries are in the log
+	undoDepth int // number of most recent blocks kept in the log
+	mutex     sync.RWMutex
+}
+
+type taddrLogEntry struct {
+	height int
+	addr   address.Transparent
+}
+
+// NewTaddrIndex returns an empty transparent address index.
+func NewTaddrIndex() *TaddrIndex {
+	x := &TaddrIndex{undoDepth: DefaultMaxReorgDepth}
+	x.clear()
+	return x
+}
+
+// Caller should hold x.mutex.Lock().
+func (x *TaddrIndex) clear() {
+	x.firstBlock = 0
+	x.nextBlock = 0
+	x.latestHash = hash32.Nil
+	x.entries = make(map[address.Transparent][]TaddrEntry)
+	x.utxos = make(map[outpoint]taddrOutput)
+	x.log = nil
+	x.undoBlock = 0
+	x.ready = false
+}
+
+// setUndoDepth sets the number of most recent blocks whose entries Reorg
+// can remove, which should be the deepest reorg the block ingestor applies;
+// zero (no limit) keeps DefaultMaxReorgDepth blocks.
+func (x *TaddrIndex) setUndoDepth(depth int) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if depth <= 0 {
+		depth = DefaultMaxReorgDepth
+	}
+	x.undoDepth = depth
+}
+
+// Ready reports whether the index has caught up with the cache, and so can
+// answer queries.
+func (x *TaddrIndex) Ready() bool {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	return x.ready
+}
+
+// GetNextHeight returns the height of the lowest block not in the index.
+func (x *TaddrIndex) GetNextHeight() int {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	return x.nextBlock
+}
+
+// Add adds the transparent outputs and inputs of the given block to the
+// index; the block should be the one at the index's next height. If it
+// isn't, Add empties the index (for CatchUp to rebuild) and returns false.
+func (x *TaddrIndex) Add(block *walletrpc.CompactBlock) bool {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.add(block)
+}
+
+// Caller should hold x.mutex.Lock().
+func (x *TaddrIndex) add(block *walletrpc.CompactBlock) bool {
+	height := int(block.Height)
+	if x.nextBlock == x.firstBlock {
+		x.firstBlock = height
+		x.nextBlock = height
+		x.undoBlock = height
+	}
+	if height != x.nextBlock {
+		Log.Warning("taddr index add at height ", height, ", expecting ", x.nextBlock, ", rebuilding it")
+		x.clear()
+		return false
+	}
+	for _, tx := range block.Vtx {
+		txid := hash32.FromSlice(tx.Txid)
+		for i, in := range tx.Vin {
+			op := outpoint{txid: hash32.FromSlice(in.PrevoutTxid), index: in.PrevoutIndex}
+			out, ok := x.utxos[op]
+			if !ok {
+				// Not an indexed output (or not one the index has seen).
+				continue
+			}
+			delete(x.utxos, op)
+			x.append(out.addr, TaddrEntry{
+				Height:     height,
+				TxIndex:    int(tx.Index),
+				Txid:       txid,
+				Index:      uint32(i),
+				Spending:   true,
+				Value:      out.value,
+				PrevTxid:   op.txid,
+				PrevIndex:  op.index,
+				PrevHeight: out.height,
+			})
+		}
+		for i, out := range tx.Vout {
+			addr, err := parser.ScriptAddress(out.ScriptPubKey)
+			if err != nil {
+				continue
+			}
+			x.utxos[outpoint{txid: txid, index: uint32(i)}] = taddrOutput{
+				addr:   *addr,
+				value:  out.Value,
+				height: height,
+			}
+			x.append(*addr, TaddrEntry{
+				Height:  height,
+				TxIndex: int(tx.Index),
+				Txid:    txid,
+				Index:   uint32(i),
+				Value:   out.Value,
+			})
+		}
+	}
+	x.latestHash = hash32.FromSlice(block.Hash)
+	x.nextBlock++
+
+	// Drop the log entries of blocks too deep to be reorged.
+	if undoBlock := x.nextBlock - x.undoDepth; undoBlock > x.undoBlock {
+		i := 0
+		for i < len(x.log) && x.log[i].height < undoBlock {
+			i++
+		}
+		x.log = x.log[i:]
+		x.undoBlock = undoBlock
+	}
+	return true
+}
+
+// Caller should hold x.mutex.Lock().
+func (x *TaddrIndex) append(addr address.Transparent, entry TaddrEntry) {
+	x.entries[addr] = append(x.entries[addr], entry)
+	x.log = append(x.log, taddrLogEntry{height: entry.Height, addr: addr})
+}
+
+// Reorg removes the blocks at and above the given height from the index.
+func (x *TaddrIndex) Reorg(height int) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.reorg(height)
+}
+
+// Caller should hold x.mutex.Lock().
+func (x *TaddrIndex) reorg(height int) {
+	if height >= x.nextBlock {
+		// Timing window, ignore this request
+		return
+	}
+	if height <= x.firstBlock {
+		x.clear()
+		return
+	}
+	if height < x.undoBlock {
+		Log.Warning("taddr index reorg to height ", height, " is deeper than its undo log, rebuilding it")
+		x.clear()
+		return
+	}
+	for len(x.log) > 0 && x.log[len(x.log)-1].height >= height {
+		addr := x.log[len(x.log)-1].addr
+		x.log = x.log[:len(x.log)-1]
+		entries := x.entries[addr]
+		entry := entries[len(entries)-1]
+		if len(entries) == 1 {
+			delete(x.entries, addr)
+		} else {
+			x.entries[addr] = entries[:len(entries)-1]
+		}
+		if entry.Spending {
+			x.utxos[outpoint{txid: entry.PrevTxid, index: entry.PrevIndex}] = taddrOutput{
+				addr:   addr,
+				value:  entry.Value,
+				height: entry.PrevHeight,
+			}
+		} else {
+			delete(x.utxos, outpoint{txid: entry.Txid, index: entry.Index})
+		}
+	}
+	x.nextBlock = height
+	// The hash of the new highest block isn't known here; CatchUp reads it
+	// from the cache, whose Reorg calls this one.
+	x.latestHash = hash32.Nil
+}
+
+// Reset empties the index; it's used only for darkside testing.
+func (x *TaddrIndex) Reset() {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.clear()
+}
+
+// CatchUp adds up to maxBlocks of the blocks the cache has but the index
+// doesn't. If the index has blocks the cache no longer has (the cache was
+// rebuilt after corruption), it's rebuilt too. Only the block ingestor
+// calls this, as the index must not change underneath it; the index isn't
+// locked while blocks are read from the cache, since BlockCache.Reorg locks
+// the cache, then the index.
+func (x *TaddrIndex) CatchUp(c *BlockCache, maxBlocks int) {
+	cacheNext := c.GetNextHeight()
+	next := x.GetNextHeight()
+	if next > x.firstBlock {
+		top := c.Get(next - 1)
+		x.mutex.Lock()
+		if next > cacheNext || top == nil ||
+			(x.latestHash != hash32.Nil && hash32.FromSlice(top.Hash) != x.latestHash) {
+			Log.Warning("taddr index doesn't match the cache, rebuilding it")
+			x.clear()
+		} else {
+			x.latestHash = hash32.FromSlice(top.Hash)
+		}
+		next = x.nextBlock
+		x.mutex.Unlock()
+	}
+	if next == x.firstBlock {
+		next = c.GetFirstHeight()
+	}
+	for n := 0; n < maxBlocks && next < cacheNext; n++ {
+		block := c.Get(next)
+		if block == nil {
+			return
+		}
+		if !x.Add(block) {
+			return
+		}
+		next++
+	}
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if x.nextBlock == cacheNext && !x.ready {
+		Log.Info("taddr index caught up with the cache at height ", x.nextBlock-1)
+		x.ready = true
+	}
+}
+
+// Entries returns the entries of the given address at heights start through
+// end (inclusive; end zero means no limit).
+func (x *TaddrIndex) Entries(addr address.Transparent, start int, end int) []TaddrEntry {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	entries := x.entries[addr]
+	i, _ := slices.BinarySearchFunc(entries, start, func(e TaddrEntry, h int) int { return e.Height - h })
+	j := len(entries)
+	if end > 0 {
+		j, _ = slices.BinarySearchFunc(entries, end+1, func(e TaddrEntry, h int) int { return e.Height - h })
+	}
+	if i >= j {
+		return nil
+	}
+	return slices.Clone(entries[i:j])
+}
+
+// TaddrTx is a transaction and its position in the chain.
+type TaddrTx struct {
+	Txid    hash32.T // little-endian
+	Height  int
+	TxIndex int // index of the transaction within its block
+}
+
+// Transactions returns the transactions that pay or spend from the given
+// address at heights start through end (as Entries), in block order.
+func (x *TaddrIndex) Transactions(addr address.Transparent, start int, end int) []TaddrTx {
+	var txs []TaddrTx
+	for _, e := range x.Entries(addr, start, end) {
+		if len(txs) > 0 && txs[len(txs)-1].Txid == e.Txid {
+			continue
+		}
+		txs = append(txs, TaddrTx{Txid: e.Txid, Height: e.Height, TxIndex: e.TxIndex})
+	}
+	return txs
+}
+
+// Txids returns the txids (little-endian) of the Transactions, like the
+// backend's getaddresstxids.
+func (x *TaddrIndex) Txids(addr address.Transparent, start int, end int) []hash32.T {
+	var txids []hash32.T
+	for _, tx := range x.Transactions(addr, start, end) {
+		txids = append(txids, tx.Txid)
+	}
+	return txids
+}
+
+// Balance returns the total value of the unspent outputs paying the given
+// addresses, and the total value ever received by them, like the backend's
+// getaddressbalance.
+func (x *TaddrIndex) Balance(addrs []address.Transparent) (balance uint64, received uint64) {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	for _, addr := range addrs {
+		for _, e := range x.entries[addr] {
+			if e.Spending {
+				balance -= e.Value
+			} else {
+				balance += e.Value
+				received += e.Value
+			}
+		}
+	}
+	return balance, received
+}
+
+// TaddrBalance is the balance of a transparent address as of a block height.
+// Its UTXOs are the outputs paying the address at or below the height that
+// aren't spent at or below it.
+type TaddrBalance struct {
+	Balance   uint64 // total value of the UTXOs
+	Received  uint64 // total value of all outputs paying the address
+	Sent      uint64 // total value of the spent outputs
+	Utxos     int    // number of UTXOs
+	Confirmed uint64 // value of the UTXOs with the requested confirmations
+}
+
+// BalanceAt returns the balance of the given address as of the given height;
+// Confirmed counts only UTXOs with at least minConf confirmations at that
+// height (a UTXO in the block at the height has one).
+func (x *TaddrIndex) BalanceAt(addr address.Transparent, height int, minConf int) TaddrBalance {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	var b TaddrBalance
+	confirmedHeight := height - max(minConf, 1) + 1
+	for _, e := range x.entries[addr] {
+		if e.Height > height {
+			break
+		}
+		if e.Spending {
+			b.Sent += e.Value
+			b.Utxos--
+			if e.PrevHeight <= confirmedHeight {
+				b.Confirmed -= e.Value
+			}
+			continue
+		}
+		b.Received += e.Value
+		b.Utxos++
+		if e.Height <= confirmedHeight {
+			b.Confirmed += e.Value
+		}
+	}
+	b.Balance = b.Received - b.Sent
+	return b
+}
+
+// Utxos returns the unspent outputs paying the given addresses at or above
+// startHeight, in height order (and by address, then transaction and output
+// order within a height), like the backend's getaddressutxos.
+func (x *TaddrIndex) Utxos(addrs []address.Transparent, startHeight int) []TaddrUtxo {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	var utxos []TaddrUtxo
+	for _, addr := range addrs {
+		for _, e := range x.entries[addr] {
+			if e.Spending || e.Height < startHeight {
+				continue
+			}
+			if _, unspent := x.utxos[outpoint{txid: e.Txid, index: e.Index}]; !unspent {
+				continue
+			}
+			utxos = append(utxos, TaddrUtxo{
+				Address: addr,
+				Txid:    e.Txid,
+				Index:   e.Index,
+				Script:  parser.AddressScript(&addr),
+				Value:   e.Value,
+				Height:  e.Height,
+			})
+		}
+	}
+	slices.SortStableFunc(utxos, func(a, b TaddrUtxo) int { return a.Height - b.Height })
+	return utxos
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package common
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/zcash/lightwalletd/address"
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
+	"github.com/zcash/lightwalletd/walletrpc"
+)
+
+var (
+	taddrA = address.Transparent{Kind: address.P2PKH, Hash: [20]byte{0xaa}}
+	taddrB = address.Transparent{Kind: address.P2SH, Hash: [20]byte{0xbb}}
+)
+
+// taddrTestTxid returns a distinguishable txid for the given height,
+// transaction index and fork.
+func taddrTestTxid(height int, index int, fork byte) hash32.T {
+	return hash32.T{byte(height), byte(index), fork}
+}
+
+// taddrTestBlock returns a compact block at the given height whose
+// transactions are txs, each given as its vin and vout.
+func taddrTestBlock(height int, fork byte, txs ...*walletrpc.CompactTx) *walletrpc.CompactBlock {
+	block := &walletrpc.CompactBlock{
+		Height: uint64(height),
+		Hash:   hash32.ToSlice(hash32.T{byte(height), fork, 0xff}),
+		Vtx:    txs,
+	}
+	for i, tx := range txs {
+		tx.Index = uint64(i)
+		tx.Txid = hash32.ToSlice(taddrTestTxid(height, i, fork))
+	}
+	return block
+}
+
+func pay(addr address.Transparent, value uint64) *walletrpc.TxOut {
+	return &walletrpc.TxOut{Value: value, ScriptPubKey: parser.AddressScript(&addr)}
+}
+
+func spend(txid hash32.T, index uint32) *walletrpc.CompactTxIn {
+	return &walletrpc.CompactTxIn{PrevoutTxid: hash32.ToSlice(txid), PrevoutIndex: index}
+}
+
+func checkTaddrBalance(t *testing.T, x *TaddrIndex, addr address.Transparent, balance, received uint64) {
+	t.Helper()
+	b, r := x.Balance([]address.Transparent{addr})
+	if b != balance || r != received {
+		t.Fatalf("balance %d received %d, expected %d %d", b, r, balance, received)
+	}
+}
+
+func TestTaddrIndex(t *testing.T) {
+	x := NewTaddrIndex()
+	x.Add(taddrTestBlock(10, 0,
+		&walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 1000), {Value: 5, ScriptPubKey: []byte{0x6a}}}}))
+	x.Add(taddrTestBlock(11, 0,
+		&walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrB, 7)}},
+		// Spends A's output (and an unknown one), paying B and A (change).
+		&walletrpc.CompactTx{
+			Vin:  []*walletrpc.CompactTxIn{spend(hash32.T{0x99}, 0), spend(taddrTestTxid(10, 0, 0), 0)},
+			Vout: []*walletrpc.TxOut{pay(taddrB, 600), pay(taddrA, 300)},
+		}))
+	x.Add(taddrTestBlock(12, 0))
+	if x.GetNextHeight() != 13 {
+		t.Fatal("unexpected next height", x.GetNextHeight())
+	}
+
+	checkTaddrBalance(t, x, taddrA, 300, 1300)
+	checkTaddrBalance(t, x, taddrB, 607, 607)
+	if b, r := x.Balance([]address.Transparent{taddrA, taddrB}); b != 907 || r != 1907 {
+		t.Fatal("unexpected combined balance", b, r)
+	}
+
+	entries := x.Entries(taddrA, 0, 0)
+	if len(entries) != 3 || !entries[1].Spending || entries[1].Index != 1 ||
+		entries[1].PrevHeight != 10 || entries[1].Value != 1000 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if txids := x.Txids(taddrA, 0, 0); !slices.Equal(txids,
+		[]hash32.T{taddrTestTxid(10, 0, 0), taddrTestTxid(11, 1, 0)}) {
+		t.Fatal("unexpected txids", txids)
+	}
+	if txids := x.Txids(taddrA, 11, 11); len(txids) != 1 {
+		t.Fatal("unexpected txids in range", txids)
+	}
+	if txids := x.Txids(taddrA, 12, 20); len(txids) != 0 {
+		t.Fatal("unexpected txids above the index", txids)
+	}
+
+	utxos := x.Utxos([]address.Transparent{taddrA, taddrB}, 0)
+	// In address order within a height.
+	if len(utxos) != 3 || utxos[0].Address != taddrA || utxos[0].Index != 1 ||
+		utxos[1].Address != taddrB || utxos[1].Height != 11 || utxos[1].Value != 7 {
+		t.Fatalf("unexpected utxos %+v", utxos)
+	}
+	if parser.ClassifyScript(utxos[1].Script) != parser.ScriptP2SH {
+		t.Fatal("unexpected utxo script", utxos[1].Script)
+	}
+
+	// Reorg away block 11; A's output is unspent again.
+	x.Reorg(11)
+	if x.GetNextHeight() != 11 {
+		t.Fatal("unexpected next height after reorg", x.GetNextHeight())
+	}
+	checkTaddrBalance(t, x, taddrA, 1000, 1000)
+	checkTaddrBalance(t, x, taddrB, 0, 0)
+	utxos = x.Utxos([]address.Transparent{taddrA}, 0)
+	if len(utxos) != 1 || utxos[0].Txid != taddrTestTxid(10, 0, 0) || utxos[0].Height != 10 {
+		t.Fatalf("unexpected utxos after reorg %+v", utxos)
+	}
+
+	// A replacement block 11 spends it differently.
+	x.Add(taddrTestBlock(11, 1, &walletrpc.CompactTx{
+		Vin: []*walletrpc.CompactTxIn{spend(taddrTestTxid(10, 0, 0), 0)},
+	}))
+	checkTaddrBalance(t, x, taddrA, 0, 1000)
+	if utxos := x.Utxos([]address.Transparent{taddrA}, 0); len(utxos) != 0 {
+		t.Fatalf("unexpected utxos %+v", utxos)
+	}
+
+	// Reorging to the first block empties the index.
+	x.Reorg(10)
+	if x.GetNextHeight() != 0 || len(x.Entries(taddrA, 0, 0)) != 0 {
+		t.Fatal("index not empty after full reorg")
+	}
+}
+
+func TestTaddrIndexBalanceAt(t *testing.T) {
+	x := NewTaddrIndex()
+	x.Add(taddrTestBlock(10, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 1000)}}))
+	x.Add(taddrTestBlock(11, 0, &walletrpc.CompactTx{
+		Vin:  []*walletrpc.CompactTxIn{spend(taddrTestTxid(10, 0, 0), 0)},
+		Vout: []*walletrpc.TxOut{pay(taddrB, 600), pay(taddrA, 300)},
+	}))
+	x.Add(taddrTestBlock(12, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 50)}}))
+
+	for _, tt := range []struct {
+		height, minConf int
+		want            TaddrBalance
+	}{
+		{9, 1, TaddrBalance{}},
+		{10, 0, TaddrBalance{Balance: 1000, Received: 1000, Utxos: 1, Confirmed: 1000}},
+		{10, 2, TaddrBalance{Balance: 1000, Received: 1000, Utxos: 1}},
+		{12, 1, TaddrBalance{Balance: 350, Received: 1350, Sent: 1000, Utxos: 2, Confirmed: 350}},
+		{12, 2, TaddrBalance{Balance: 350, Received: 1350, Sent: 1000, Utxos: 2, Confirmed: 300}},
+		{12, 3, TaddrBalance{Balance: 350, Received: 1350, Sent: 1000, Utxos: 2}},
+		{20, 1, TaddrBalance{Balance: 350, Received: 1350, Sent: 1000, Utxos: 2, Confirmed: 350}},
+	} {
+		if got := x.BalanceAt(taddrA, tt.height, tt.minConf); got != tt.want {
+			t.Fatalf("BalanceAt(%d, %d) = %+v, expected %+v", tt.height, tt.minConf, got, tt.want)
+		}
+	}
+	if got := x.BalanceAt(taddrB, 11, 1); got != (TaddrBalance{Balance: 600, Received: 600, Utxos: 1, Confirmed: 600}) {
+		t.Fatalf("unexpected balance of B %+v", got)
+	}
+}
+
+func TestTaddrIndexUndoLog(t *testing.T) {
+	x := NewTaddrIndex()
+	x.setUndoDepth(2)
+	for height := 10; height < 15; height++ {
+		if !x.Add(taddrTestBlock(height, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 1)}})) {
+			t.Fatal("Add failed at height", height)
+		}
+	}
+	// Only blocks 13 and 14 can be reorged.
+	if len(x.log) != 2 || x.log[0].height != 13 {
+		t.Fatalf("unexpected log %+v", x.log)
+	}
+	x.Reorg(14)
+	checkTaddrBalance(t, x, taddrA, 4, 4)
+	x.Add(taddrTestBlock(14, 1))
+	x.Reorg(13)
+	checkTaddrBalance(t, x, taddrA, 3, 3)
+
+	// A deeper reorg empties the index, for CatchUp to rebuild.
+	x.Add(taddrTestBlock(13, 1))
+	x.Add(taddrTestBlock(14, 1))
+	x.Reorg(12)
+	if x.GetNextHeight() != 0 || len(x.Entries(taddrA, 0, 0)) != 0 {
+		t.Fatal("index not empty after reorg below the undo log")
+	}
+
+	// So does adding a block at the wrong height.
+	x.Add(taddrTestBlock(10, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 1)}}))
+	if x.Add(taddrTestBlock(12, 0)) {
+		t.Fatal("Add succeeded at the wrong height")
+	}
+	if x.GetNextHeight() != 0 || len(x.Entries(taddrA, 0, 0)) != 0 {
+		t.Fatal("index not empty after Add at the wrong height")
+	}
+}
+
+func TestTaddrIndexCatchUp(t *testing.T) {
+	dbPath := t.TempDir()
+	os.RemoveAll(dbPath)
+	cache := NewBlockCache(dbPath, unitTestChain, 10, 0)
+	defer cache.Close()
+	x := NewTaddrIndex()
+	cache.SetTaddrIndex(x)
+
+	blocks := []*walletrpc.CompactBlock{
+		taddrTestBlock(10, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 1000)}}),
+		taddrTestBlock(11, 0, &walletrpc.CompactTx{
+			Vin:  []*walletrpc.CompactTxIn{spend(taddrTestTxid(10, 0, 0), 0)},
+			Vout: []*walletrpc.TxOut{pay(taddrB, 900)},
+		}),
+		taddrTestBlock(12, 0, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrA, 5)}}),
+	}
+	for _, block := range blocks {
+		if err := cache.Add(int(block.Height), block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The index catches up in steps, and is ready once it has.
+	x.CatchUp(cache, 2)
+	if x.GetNextHeight() != 12 || x.Ready() {
+		t.Fatal("unexpected state after partial catch-up", x.GetNextHeight(), x.Ready())
+	}
+	x.CatchUp(cache, 2)
+	if x.GetNextHeight() != 13 || !x.Ready() {
+		t.Fatal("unexpected state after catch-up", x.GetNextHeight(), x.Ready())
+	}
+	checkTaddrBalance(t, x, taddrA, 5, 1005)
+
+	// The cache applies reorgs to the index.
+	cache.Reorg(11)
+	checkTaddrBalance(t, x, taddrA, 1000, 1000)
+	block := taddrTestBlock(11, 1)
+	if err := cache.Add(11, block); err != nil {
+		t.Fatal(err)
+	}
+	x.CatchUp(cache, 1)
+	if x.GetNextHeight() != 12 {
+		t.Fatal("unexpected next height", x.GetNextHeight())
+	}
+	checkTaddrBalance(t, x, taddrB, 0, 0)
+
+	// An index that doesn't match the cache is rebuilt.
+	x.Reset()
+	x.Add(taddrTestBlock(10, 2, &walletrpc.CompactTx{Vout: []*walletrpc.TxOut{pay(taddrB, 1)}}))
+	x.CatchUp(cache, 10)
+	if x.GetNextHeight() != 12 || !x.Ready() {
+		t.Fatal("unexpected state after rebuild", x.GetNextHeight(), x.Ready())
+	}
+	checkTaddrBalance(t, x, taddrA, 1000, 1000)
+	checkTaddrBalance(t, x, taddrB, 0, 0)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package frontend
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/zcash/lightwalletd/hash32"
+	"google.golang.org/grpc/metadata"
+)
+
+// cursorTrailer is the trailer that carries a continuation cursor; the -bin
+// suffix makes gRPC carry it as binary.
+const cursorTrailer = "continuation-cursor-bin"
+
+// cursorVersion is the first byte of an encoded cursor, so that the
+// encoding, which clients treat as opaque, can change.
+const cursorVersion = 1
+
+// cursor marks where a paginated GetTaddressTransactions or GetAddressUtxos
+// response stopped: the height, txid and output index of the last result it
+// returned. A resumed request skips the results at the cursor's height up to
+// and including that one; if the result is no longer there (after a reorg, or
+// because the UTXO was spent), it starts at the beginning of the height.
+type cursor struct {
+	height uint64
+	txid   hash32.T // little-endian; Nil to resume at the start of height
+	index  uint32   // output index of a UTXO; zero for a transaction
+}
+
+// mempoolCursorHeight is the height of the cursor for an unconfirmed output
+// (whose reported height is 0), which comes after all the mined ones.
+const mempoolCursorHeight = math.MaxUint64
+
+// encode returns the cursor's serialization: the version, then the height,
+// txid and index, little-endian.
+func (c *cursor) encode() []byte {
+	b := make([]byte, 0, 1+8+32+4)
+	b = append(b, cursorVersion)
+	b = binary.LittleEndian.AppendUint64(b, c.height)
+	b = append(b, hash32.ToSlice(c.txid)...)
+	return binary.LittleEndian.AppendUint32(b, c.index)
+}
+
+// decodeCursor parses a cursor that encode returned; it returns nil if b is
+// empty (the request has no cursor).
+func decodeCursor(b []byte) (*cursor, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if len(b) != 1+8+32+4 || b[0] != cursorVersion {
+		return nil, errors.New("malformed continuation cursor")
+	}
+	return &cursor{
+		height: binary.LittleEndian.Uint64(b[1:]),
+		txid:   hash32.FromSlice(b[9:41]),
+		index:  binary.LittleEndian.Uint32(b[41:]),
+	}, nil
+}
+
+// trailer returns the trailer metadata that carries the cursor.
+func (c *cursor) trailer() metadata.MD {
+	return metadata.Pairs(cursorTrailer, string(c.encode()))
+}
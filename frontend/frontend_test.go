@@ -7,21 +7,29 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/zcash/lightwalletd/address"
 	"github.com/zcash/lightwalletd/common"
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/parser"
 	"github.com/zcash/lightwalletd/walletrpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -152,7 +160,7 @@ func TestGetTransaction(t *testing.T) {
 	if err == nil {
 		testT.Fatal("GetTransaction unexpectedly succeeded")
 	}
-	if !strings.Contains(err.Error(), "GetTransaction: specify a txid, not a blockhash+num") {
+	if !strings.Contains(err.Error(), "GetTransaction: block hash has invalid length") {
 		testT.Fatal("GetTransaction unexpected error message")
 	}
 	if rawtx != nil {
@@ -160,6 +168,109 @@ func TestGetTransaction(t *testing.T) {
 	}
 }
 
+func TestGetTransactionByBlockIndex(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, cache := testsetup()
+
+	// Populate the cache with block 380640, whose (only) transaction
+	// is given the txid testTxid by the verbose getblock reply.
+	common.RawRequest = getblockStub
+	block, err := common.GetBlock(context.Background(), nil, 380640)
+	if err != nil {
+		t.Fatal("GetBlock failed:", err)
+	}
+	if err := cache.Add(380640, block); err != nil {
+		t.Fatal("cache.Add failed:", err)
+	}
+
+	var requested []string
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		var arg string
+		if err := json.Unmarshal(params[0], &arg); err != nil {
+			testT.Fatal("could not unmarshal argument")
+		}
+		requested = append(requested, method+" "+arg)
+		switch method {
+		case "getblock":
+			return []byte("{\"Tx\": [\"" + testTxid + "\"], \"Hash\": \"" + testBlockid + "\"}"), nil
+		case "getrawtransaction":
+			return json.Marshal(&common.ZcashdRpcReplyGetrawtransaction{
+				Hex:    hex.EncodeToString(rawTxData[0]),
+				Height: 380640,
+			})
+		}
+		testT.Fatal("unexpected method:", method)
+		return nil, nil
+	}
+
+	// By height, the txid comes from the cache; only the raw fetch is needed.
+	rawtx, err := lwd.GetTransaction(context.Background(),
+		&walletrpc.TxFilter{Block: &walletrpc.BlockID{Height: 380640}, Index: 0})
+	if err != nil {
+		t.Fatal("GetTransaction by height failed:", err)
+	}
+	if !bytes.Equal(rawtx.Data, rawTxData[0]) || rawtx.Height != 380640 {
+		t.Fatal("GetTransaction by height returned unexpected transaction")
+	}
+	if !reflect.DeepEqual(requested, []string{"getrawtransaction " + testTxid}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+
+	// By hash (little-endian, as in CompactBlock.Hash), the txid comes from
+	// the backend's verbose getblock.
+	requested = nil
+	_, err = lwd.GetTransaction(context.Background(),
+		&walletrpc.TxFilter{Block: &walletrpc.BlockID{Hash: block.Hash}, Index: 0})
+	if err != nil {
+		t.Fatal("GetTransaction by hash failed:", err)
+	}
+	if !reflect.DeepEqual(requested, []string{
+		"getblock " + hex.EncodeToString(hash32.ReverseSlice(block.Hash)),
+		"getrawtransaction " + testTxid,
+	}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+
+	// An index beyond the end of the block is rejected.
+	for _, id := range []*walletrpc.BlockID{{Height: 380640}, {Hash: block.Hash}} {
+		_, err = lwd.GetTransaction(context.Background(),
+			&walletrpc.TxFilter{Block: id, Index: 1})
+		if status.Code(err) != codes.OutOfRange {
+			t.Fatal("expected OutOfRange for an index beyond the block, got:", err)
+		}
+	}
+
+	// Height 0 (the genesis block) is a height like any other.
+	cache.Close()
+	os.RemoveAll(unitTestPath)
+	genesisCache := common.NewBlockCache(unitTestPath, unitTestChain, 0, 0)
+	defer genesisCache.Close()
+	genesisTxid := hash32.T{0x01}
+	genesis := &walletrpc.CompactBlock{
+		Height: 0,
+		Hash:   hash32.ToSlice(hash32.T{0x02}),
+		Vtx:    []*walletrpc.CompactTx{{Txid: hash32.ToSlice(genesisTxid)}},
+	}
+	if err := genesisCache.Add(0, genesis); err != nil {
+		t.Fatal("cache.Add failed:", err)
+	}
+	lwd, err = NewLwdStreamer(genesisCache, "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requested = nil
+	if _, err = lwd.GetTransaction(context.Background(),
+		&walletrpc.TxFilter{Block: &walletrpc.BlockID{Height: 0}, Index: 0}); err != nil {
+		t.Fatal("GetTransaction at height 0 failed:", err)
+	}
+	if !reflect.DeepEqual(requested, []string{
+		"getrawtransaction " + hash32.Encode(hash32.Reverse(genesisTxid)),
+	}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+}
+
 func getLatestBlockStub(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
 	step++
 
@@ -236,19 +347,31 @@ func TestGetLatestBlock(t *testing.T) {
 	}
 }
 
-// A valid address starts with "t", followed by 34 alpha characters;
-// these should all be detected as invalid.
+// validTaddr is a valid mainnet (P2PKH) transparent address.
+const validTaddr = "t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC"
+
+// testTaddr returns a distinct valid mainnet transparent address for each i.
+func testTaddr(i int) string {
+	taddr := &address.Transparent{Kind: address.P2PKH}
+	binary.BigEndian.PutUint32(taddr.Hash[:], uint32(i))
+	return taddr.Encode("main")
+}
+
+// These should all be detected as invalid transparent addresses.
 var addressTests = []string{
 	"",                                      // too short
 	"a",                                     // too short
-	"t123456789012345678901234567890123",    // one byte too short
-	"t12345678901234567890123456789012345",  // one byte too long
-	"t123456789012345678901234567890123*",   // invalid "*"
-	"s1234567890123456789012345678901234",   // doesn't start with "t"
-	" t1234567890123456789012345678901234",  // extra stuff before
-	"t1234567890123456789012345678901234 ",  // extra stuff after
-	"\nt1234567890123456789012345678901234", // newline before
-	"t1234567890123456789012345678901234\n", // newline after
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9y",    // one character too short
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yCC",  // one character too long
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9y*",   // invalid "*"
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby90C",   // invalid "0"
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yc",   // bad checksum
+	"tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb",   // testnet address
+	"t1234567890123456789012345678901234",   // not Base58
+	" t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC",  // extra stuff before
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC ",  // extra stuff after
+	"\nt1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC", // newline before
+	"t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC\n", // newline after
 }
 
 func zcashdrpcStub(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
@@ -263,7 +386,7 @@ func zcashdrpcStub(ctx context.Context, method string, params []json.RawMessage)
 		if len(filter.Addresses) != 1 {
 			testT.Fatal("wrong number of addresses")
 		}
-		if filter.Addresses[0] != "t1234567890123456789012345678901234" {
+		if filter.Addresses[0] != validTaddr {
 			testT.Fatal("wrong address")
 		}
 		if filter.Start != 20 {
@@ -336,7 +459,7 @@ func TestGetTaddressBalanceStream(t *testing.T) {
 	defer resetGlobals()
 	lwd, _ := testsetup()
 
-	validAddr := "t1234567890123456789012345678901234"
+	validAddr := validTaddr
 
 	// An invalid address must be rejected immediately, before any zcashd
 	// call, and before the whole (potentially unbounded) stream is buffered.
@@ -400,7 +523,7 @@ func TestGetTaddressBalanceTooManyAddresses(t *testing.T) {
 	// deduplication of the list.
 	addrs := make([]string, maxTaddrsPerRequest+1)
 	for i := range addrs {
-		addrs[i] = fmt.Sprintf("t1%033d", i)
+		addrs[i] = testTaddr(i)
 	}
 
 	// Exactly at the limit: accepted, and the whole list reaches zcashd.
@@ -454,7 +577,7 @@ func TestGetAddressUtxosTooManyAddresses(t *testing.T) {
 	}
 	addrs := make([]string, maxTaddrsPerRequest+1)
 	for i := range addrs {
-		addrs[i] = "t1234567890123456789012345678901234"
+		addrs[i] = validTaddr
 	}
 	_, err := lwd.GetAddressUtxos(context.Background(), &walletrpc.GetAddressUtxosArg{Addresses: addrs})
 	if err == nil {
@@ -470,8 +593,7 @@ func TestGetTaddressBalanceDeduplicates(t *testing.T) {
 	defer resetGlobals()
 	lwd, _ := testsetup()
 
-	taddr := func(c string) string { return "t1" + strings.Repeat(c, 33) }
-	addrA, addrB := taddr("a"), taddr("b")
+	addrA, addrB := testTaddr(1), testTaddr(2)
 
 	// zcashd sums getaddressbalance over the list entries, so a repeated
 	// address inflates the balance it reports; zebrad collapses duplicates
@@ -505,6 +627,43 @@ func TestGetTaddressBalanceDeduplicates(t *testing.T) {
 	}
 }
 
+func TestGetTaddressBalanceUnifiedAndTEX(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, _ := testsetup()
+
+	// A unified address (with validTaddr as its P2PKH receiver, and an
+	// Orchard receiver) and the TEX address of validTaddr (ZIP 320's example).
+	ua := "u1x7zem2ygnm3d06wgmvrwf94xa7dwa5ay5vwy8t3kuyl8aswdcg3nveqzqs54nzfqk27z3wn77jqy44uve77t27fxrmqp6zlhv8fsy99z4y8enzrr0k4ft7gqnly8jhkkph96k7cxqyk"
+	tex := "tex1s2rt77ggv6q989lr49rkgzmh5slsksa9khdgte"
+
+	var forwarded []string
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		var req common.ZcashdRpcRequestGetaddressbalance
+		if err := json.Unmarshal(params[0], &req); err != nil {
+			testT.Fatal("could not unmarshal getaddressbalance request")
+		}
+		forwarded = req.Addresses
+		return json.Marshal(common.ZcashdRpcReplyGetaddressbalance{Balance: 1234})
+	}
+	_, err := lwd.GetTaddressBalance(context.Background(),
+		&walletrpc.AddressList{Addresses: []string{ua, tex, validTaddr, testTaddr(1)}})
+	if err != nil {
+		t.Fatal("GetTaddressBalance failed:", err)
+	}
+	if !reflect.DeepEqual(forwarded, []string{validTaddr, testTaddr(1)}) {
+		t.Fatal("expected the transparent receivers, deduplicated, got:", forwarded)
+	}
+
+	// A unified address with only Sapling and Orchard receivers.
+	shieldedUA := "u1cg224asmelhffclmmdrntfwcvc5uskyysldds9g6d300p6f7lv90qqdrkfegfjpy66r06glgwrw9vqv8m4x992ujyq6376ehp45h3xyw0yg8afsudc7xv4pur6jyn2p2m7rhugkplqh09yuzcjqclcxjt32pue84m5rl75heyc2sza7e"
+	_, err = lwd.GetTaddressBalance(context.Background(),
+		&walletrpc.AddressList{Addresses: []string{shieldedUA}})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "no transparent receiver") {
+		t.Fatal("expected InvalidArgument for a unified address without a transparent receiver, got:", err)
+	}
+}
+
 func TestGetTaddressBalanceEmptyList(t *testing.T) {
 	testT = t
 	defer resetGlobals()
@@ -554,10 +713,8 @@ func TestGetAddressUtxosDeduplicates(t *testing.T) {
 	defer resetGlobals()
 	lwd, _ := testsetup()
 
-	// A distinct valid taddr per letter: "t1" followed by 33 more characters.
-	taddr := func(c string) string { return "t1" + strings.Repeat(c, 33) }
-	addrA, addrB, addrC := taddr("a"), taddr("b"), taddr("c")
-	addrD, addrE, addrF := taddr("d"), taddr("e"), taddr("f")
+	addrA, addrB, addrC := testTaddr(1), testTaddr(2), testTaddr(3)
+	addrD, addrE, addrF := testTaddr(4), testTaddr(5), testTaddr(6)
 
 	// zcashd looks up each entry of the address list independently, so a
 	// repeated address multiplies its backend cost and its UTXOs in the reply.
@@ -630,7 +787,7 @@ func TestGetAddressUtxosPushesDownLimits(t *testing.T) {
 	defer resetGlobals()
 	lwd, _ := testsetup()
 
-	taddr := "t1" + strings.Repeat("a", 33)
+	taddr := testTaddr(1)
 	utxo := func(height int) common.ZcashdRpcReplyGetaddressutxos {
 		return common.ZcashdRpcReplyGetaddressutxos{
 			Address:     taddr,
@@ -726,13 +883,13 @@ func TestGetTaddressTransactions(t *testing.T) {
 		if err == nil {
 			t.Fatal("GetTaddressTransactions should have failed on bad address, case", i)
 		}
-		if !strings.Contains(err.Error(), "invalid characters") {
+		if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "invalid transparent address") {
 			t.Fatal("GetTaddressTransactions incorrect error on bad address, case", i)
 		}
 	}
 
 	// valid address
-	addressBlockFilter.Address = "t1234567890123456789012345678901234"
+	addressBlockFilter.Address = validTaddr
 	err := lwd.GetTaddressTransactions(addressBlockFilter, &testgettx{})
 	if err != nil {
 		t.Fatal("GetTaddressTransactions failed", err)
@@ -813,7 +970,7 @@ func TestGetTaddressTransactionsDefaultsEndToTip(t *testing.T) {
 	}
 
 	filter := &walletrpc.TransparentAddressBlockFilter{
-		Address: "t1234567890123456789012345678901234",
+		Address: validTaddr,
 		Range:   &walletrpc.BlockRange{Start: &walletrpc.BlockID{Height: 100}}, // End omitted
 	}
 	if err := lwd.GetTaddressTransactions(filter, &testgettx{}); err != nil {
@@ -833,7 +990,7 @@ func TestGetTaddressTransactionsRangeTooWide(t *testing.T) {
 		return nil, nil
 	}
 	filter := &walletrpc.TransparentAddressBlockFilter{
-		Address: "t1234567890123456789012345678901234",
+		Address: validTaddr,
 		Range: &walletrpc.BlockRange{
 			Start: &walletrpc.BlockID{Height: 0},
 			End:   &walletrpc.BlockID{Height: maxTaddrTxBlockSpan + 1},
@@ -848,6 +1005,431 @@ func TestGetTaddressTransactionsRangeTooWide(t *testing.T) {
 	}
 }
 
+func TestCursor(t *testing.T) {
+	c := &cursor{height: 380640, txid: hash32.T{0x01, 0x02}, index: 7}
+	got, err := decodeCursor(c.encode())
+	if err != nil || *got != *c {
+		t.Fatalf("cursor round trip: %+v, %v", got, err)
+	}
+	if got, err := decodeCursor(nil); got != nil || err != nil {
+		t.Fatal("an empty cursor should decode to nil")
+	}
+	for _, b := range [][]byte{{cursorVersion}, append([]byte{2}, c.encode()[1:]...)} {
+		if _, err := decodeCursor(b); err == nil {
+			t.Fatalf("malformed cursor %x accepted", b)
+		}
+	}
+}
+
+// testpagedtx records the transactions and trailer of a paginated
+// GetTaddressTransactions response.
+type testpagedtx struct {
+	walletrpc.CompactTxStreamer_GetTaddressTransactionsServer
+	heights []string // of the transactions sent
+	trailer metadata.MD
+}
+
+func (tp *testpagedtx) Context() context.Context {
+	return context.Background()
+}
+
+func (tp *testpagedtx) Send(tx *walletrpc.RawTransaction) error {
+	tp.heights = append(tp.heights, fmt.Sprint(tx.Height))
+	return nil
+}
+
+func (tp *testpagedtx) SetTrailer(md metadata.MD) {
+	tp.trailer = md
+}
+
+// cursor returns the continuation cursor in the response's trailer, if any.
+func (tp *testpagedtx) cursor() []byte {
+	if v := tp.trailer.Get(cursorTrailer); len(v) > 0 {
+		return []byte(v[0])
+	}
+	return nil
+}
+
+func TestGetTaddressTransactionsCursor(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, _ := testsetup()
+
+	// Three transactions, at heights 100, 101 and 105; testpagedtx.Send
+	// records each by its height.
+	txids := []string{
+		"1100000000000000000000000000000000000000000000000000000000000000",
+		"2200000000000000000000000000000000000000000000000000000000000000",
+		"3300000000000000000000000000000000000000000000000000000000000000",
+	}
+	heights := map[string]int64{txids[0]: 100, txids[1]: 101, txids[2]: 105}
+	var request common.ZcashdRpcRequestGetaddresstxids
+	failTxid := ""
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "getaddresstxids":
+			if err := json.Unmarshal(params[0], &request); err != nil {
+				testT.Fatal("could not unmarshal getaddresstxids request")
+			}
+			return json.Marshal(txids)
+		case "getrawtransaction":
+			var txid string
+			json.Unmarshal(params[0], &txid)
+			if txid == failTxid {
+				return nil, errors.New("getrawtransaction test error")
+			}
+			return json.Marshal(&common.ZcashdRpcReplyGetrawtransaction{
+				Hex:    hex.EncodeToString(rawTxData[0]),
+				Height: heights[txid],
+			})
+		}
+		testT.Fatal("unexpected method", method)
+		return nil, nil
+	}
+	filter := &walletrpc.TransparentAddressBlockFilter{
+		Address: validTaddr,
+		Range: &walletrpc.BlockRange{
+			Start: &walletrpc.BlockID{Height: 100},
+			End:   &walletrpc.BlockID{Height: 200},
+		},
+		MaxEntries: 2,
+	}
+	resp := &testpagedtx{}
+	if err := lwd.GetTaddressTransactions(filter, resp); err != nil {
+		t.Fatal("GetTaddressTransactions failed:", err)
+	}
+	if !reflect.DeepEqual(resp.heights, []string{"100", "101"}) || resp.cursor() == nil {
+		t.Fatalf("unexpected first page %v, cursor %x", resp.heights, resp.cursor())
+	}
+
+	// The second page resumes after the second transaction.
+	filter.Cursor = resp.cursor()
+	resp = &testpagedtx{}
+	if err := lwd.GetTaddressTransactions(filter, resp); err != nil {
+		t.Fatal("GetTaddressTransactions failed:", err)
+	}
+	if request.Start != 101 || !reflect.DeepEqual(resp.heights, []string{"105"}) || resp.cursor() != nil {
+		t.Fatalf("unexpected second page %v from %d, cursor %x", resp.heights, request.Start, resp.cursor())
+	}
+
+	// An error after some transactions were sent leaves a cursor after them.
+	filter.Cursor = nil
+	filter.MaxEntries = 3
+	failTxid = txids[2]
+	resp = &testpagedtx{}
+	if err := lwd.GetTaddressTransactions(filter, resp); err == nil {
+		t.Fatal("GetTaddressTransactions should have failed")
+	}
+	c, err := decodeCursor(resp.cursor())
+	if err != nil || c == nil || c.height != 101 || hash32.Encode(hash32.Reverse(c.txid)) != txids[1] {
+		t.Fatalf("unexpected cursor after an error %+v, %v", c, err)
+	}
+
+	// A paginated request scans only as much of a too-wide range as it may.
+	failTxid = ""
+	filter.Range.End.Height = 100 + maxTaddrTxBlockSpan + 10
+	resp = &testpagedtx{}
+	if err := lwd.GetTaddressTransactions(filter, resp); err != nil {
+		t.Fatal("GetTaddressTransactions failed:", err)
+	}
+	c, _ = decodeCursor(resp.cursor())
+	if request.End != 100+maxTaddrTxBlockSpan || c == nil ||
+		c.height != 101+maxTaddrTxBlockSpan || c.txid != hash32.Nil {
+		t.Fatalf("unexpected cursor for a too-wide range %+v (end %d)", c, request.End)
+	}
+
+	// Malformed cursors and cursors outside the range are rejected.
+	for _, b := range [][]byte{{0x01}, (&cursor{height: 99}).encode()} {
+		filter.Cursor = b
+		if err := lwd.GetTaddressTransactions(filter, &testpagedtx{}); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument for cursor %x, got: %v", b, err)
+		}
+	}
+}
+
+func TestTaddrTxGroups(t *testing.T) {
+	txs := []common.TaddrTx{
+		{Height: 10, TxIndex: 1}, {Height: 10, TxIndex: 3}, {Height: 11}, {Height: 12}, {Height: 12, TxIndex: 2},
+	}
+	var sizes []int
+	for _, group := range taddrTxGroups(txs) {
+		sizes = append(sizes, len(group))
+	}
+	if !slices.Equal(sizes, []int{2, 1, 2}) {
+		t.Fatal("unexpected group sizes", sizes)
+	}
+	// Transactions of unknown height (from the backend) aren't grouped.
+	if groups := taddrTxGroups(make([]common.TaddrTx, 3)); len(groups) != 3 {
+		t.Fatal("unexpected groups", groups)
+	}
+}
+
+func TestFetchInOrder(t *testing.T) {
+	// Each fetch waits until the one after it has finished (the last
+	// needn't wait), so the fetches complete in reverse order; at most
+	// concurrency of them may run at once.
+	const n, concurrency = 10, 3
+	done := make([]chan struct{}, n+1)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	close(done[n])
+	var running, maxRunning atomic.Int32
+	fetch := func(ctx context.Context, i int) (int, error) {
+		r := running.Add(1)
+		for m := maxRunning.Load(); r > m && !maxRunning.CompareAndSwap(m, r); m = maxRunning.Load() {
+		}
+		defer running.Add(-1)
+		if i%concurrency != concurrency-1 {
+			<-done[i+1]
+		}
+		close(done[i])
+		return i * i, nil
+	}
+	var sent []int
+	send := func(i int, value int) error {
+		if value != i*i {
+			t.Fatalf("value %d sent for index %d", value, i)
+		}
+		sent = append(sent, i)
+		return nil
+	}
+	if err := fetchInOrder(context.Background(), n, concurrency, fetch, send); err != nil {
+		t.Fatal("fetchInOrder failed:", err)
+	}
+	if !reflect.DeepEqual(sent, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatal("results sent out of order:", sent)
+	}
+	if maxRunning.Load() > concurrency {
+		t.Fatal("too many concurrent fetches:", maxRunning.Load())
+	}
+
+	// A failed fetch stops the sends at its index.
+	sent = nil
+	err := fetchInOrder(context.Background(), n, concurrency,
+		func(ctx context.Context, i int) (int, error) {
+			if i == 4 {
+				return 0, errors.New("fetch test error")
+			}
+			return i * i, nil
+		}, send)
+	if err == nil || err.Error() != "fetch test error" || !reflect.DeepEqual(sent, []int{0, 1, 2, 3}) {
+		t.Fatalf("unexpected result after a failed fetch: %v, sent %v", err, sent)
+	}
+
+	// A fetch that doesn't finish before the deadline fails the call.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = fetchInOrder(ctx, n, concurrency,
+		func(ctx context.Context, i int) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}, send)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatal("expected DeadlineExceeded, got:", err)
+	}
+}
+
+// testpagedutxos records the UTXOs and trailer of a paginated
+// GetAddressUtxosStream response.
+type testpagedutxos struct {
+	walletrpc.CompactTxStreamer_GetAddressUtxosStreamServer
+	utxos   []string
+	trailer metadata.MD
+}
+
+func (tp *testpagedutxos) Context() context.Context {
+	return context.Background()
+}
+
+func (tp *testpagedutxos) Send(utxo *walletrpc.GetAddressUtxosReply) error {
+	tp.utxos = append(tp.utxos, fmt.Sprintf("%d:%x:%d", utxo.Height, utxo.Txid[0], utxo.Index))
+	return nil
+}
+
+func (tp *testpagedutxos) SetTrailer(md metadata.MD) {
+	tp.trailer = md
+}
+
+func TestGetAddressUtxosStreamCursor(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, _ := testsetup()
+
+	taddr := testTaddr(1)
+	utxo := func(txid string, index int64, height int) common.ZcashdRpcReplyGetaddressutxos {
+		return common.ZcashdRpcReplyGetaddressutxos{
+			Address:     taddr,
+			Txid:        strings.Repeat("0", 62) + txid,
+			OutputIndex: index,
+			Script:      "76a914000000000000000000000000000000000000000088ac",
+			Satoshis:    1000,
+			Height:      height,
+		}
+	}
+	utxos := []common.ZcashdRpcReplyGetaddressutxos{utxo("aa", 0, 100), utxo("aa", 1, 100), utxo("bb", 0, 101)}
+	var request common.ZcashdRpcRequestGetaddressutxos
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		if method != "getaddressutxos" {
+			testT.Fatal("unexpected method", method)
+		}
+		request = common.ZcashdRpcRequestGetaddressutxos{}
+		if err := json.Unmarshal(params[0], &request); err != nil {
+			testT.Fatal("could not unmarshal getaddressutxos request")
+		}
+		return json.Marshal(utxos)
+	}
+
+	arg := &walletrpc.GetAddressUtxosArg{Addresses: []string{taddr}, MaxEntries: 2}
+	resp := &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	if !reflect.DeepEqual(resp.utxos, []string{"100:aa:0", "100:aa:1"}) || len(resp.trailer.Get(cursorTrailer)) != 1 {
+		t.Fatalf("unexpected first page %v, trailer %v", resp.utxos, resp.trailer)
+	}
+
+	// The second page resumes after the second UTXO; the cursor replaces the
+	// backend's entry limit.
+	arg.Cursor = []byte(resp.trailer.Get(cursorTrailer)[0])
+	resp = &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	if request.StartHeight != 100 || request.MaxEntries != 0 ||
+		!reflect.DeepEqual(resp.utxos, []string{"101:bb:0"}) || resp.trailer != nil {
+		t.Fatalf("unexpected second page %v, request %+v, trailer %v", resp.utxos, request, resp.trailer)
+	}
+
+	// If the cursor's UTXO has since been spent, its height is returned again.
+	utxos = []common.ZcashdRpcReplyGetaddressutxos{utxo("aa", 0, 100), utxo("bb", 0, 101)}
+	resp = &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	if !reflect.DeepEqual(resp.utxos, []string{"100:aa:0", "101:bb:0"}) {
+		t.Fatalf("unexpected page after a spend %v", resp.utxos)
+	}
+}
+
+func TestGetAddressUtxosIncludeMempool(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, _ := testsetup()
+
+	txid := func(b string) string { return strings.Repeat("0", 62) + b }
+	script := func(i int) []byte {
+		addr, _ := address.DecodeTransparent(testTaddr(i), "main")
+		return parser.AddressScript(addr)
+	}
+	utxos := []common.ZcashdRpcReplyGetaddressutxos{
+		{Address: testTaddr(1), Txid: txid("aa"), OutputIndex: 0, Script: hex.EncodeToString(script(1)), Satoshis: 1000, Height: 100},
+		{Address: testTaddr(2), Txid: txid("bb"), OutputIndex: 1, Script: hex.EncodeToString(script(2)), Satoshis: 2000, Height: 101},
+	}
+	// Mempool transaction 11 spends aa:0 and pays testTaddr(1), an
+	// address that wasn't asked for, and testTaddr(2); transaction 22
+	// spends that last output.
+	prevout := func(b string) hash32.T {
+		h, _ := hash32.Decode(txid(b))
+		return hash32.Reverse(h)
+	}
+	mempoolTx := func(spends string, index uint32, outputs ...int) string {
+		tx := parser.NewTransaction()
+		tx.Version = 1
+		tx.TransparentInputs = []parser.TxIn{{PrevTxHash: prevout(spends), PrevTxOutIndex: index}}
+		for _, i := range outputs {
+			tx.TransparentOutputs = append(tx.TransparentOutputs, parser.TxOut{Value: uint64(100 * i), Script: script(i)})
+		}
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			testT.Fatal("MarshalBinary failed:", err)
+		}
+		return hex.EncodeToString(data)
+	}
+	mempool := map[string]string{
+		txid("11"): mempoolTx("aa", 0, 1, 3, 2),
+		txid("22"): mempoolTx("11", 2, 3),
+	}
+	var request *common.ZcashdRpcRequestGetaddressutxos
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "getaddressutxos":
+			request = &common.ZcashdRpcRequestGetaddressutxos{}
+			if err := json.Unmarshal(params[0], request); err != nil {
+				testT.Fatal("could not unmarshal getaddressutxos request")
+			}
+			var reply []common.ZcashdRpcReplyGetaddressutxos
+			for _, utxo := range utxos {
+				if slices.Contains(request.Addresses, utxo.Address) {
+					reply = append(reply, utxo)
+				}
+			}
+			return json.Marshal(reply)
+		case "getrawmempool":
+			return json.Marshal([]string{txid("22"), txid("11")})
+		case "getrawtransaction":
+			var arg string
+			json.Unmarshal(params[0], &arg)
+			return json.Marshal(mempool[arg])
+		}
+		testT.Fatal("unexpected method", method)
+		return nil, nil
+	}
+
+	arg := &walletrpc.GetAddressUtxosArg{
+		Addresses:      []string{testTaddr(1), testTaddr(2)},
+		MaxEntries:     2,
+		IncludeMempool: true,
+	}
+	resp := &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	// Mempool spends may remove UTXOs, so the backend's entry limit isn't used.
+	if request.MaxEntries != 0 || !reflect.DeepEqual(resp.utxos, []string{"101:bb:1", "0:11:0"}) ||
+		len(resp.trailer.Get(cursorTrailer)) != 1 {
+		t.Fatalf("unexpected utxos %v, request %+v, trailer %v", resp.utxos, request, resp.trailer)
+	}
+	// The cached mempool transactions, which GetMempoolTx also returns, keep
+	// their inputs.
+	if tx := (*mempoolMap)[txid("11")]; len(tx.Vin) != 1 || tx.Index != 0 {
+		t.Fatalf("unexpected cached mempool transaction %+v", tx)
+	}
+	list, err := lwd.GetAddressUtxos(context.Background(), &walletrpc.GetAddressUtxosArg{
+		Addresses:      []string{testTaddr(1)},
+		IncludeMempool: true,
+	})
+	if err != nil {
+		t.Fatal("GetAddressUtxos failed:", err)
+	}
+	r := list.AddressUtxos
+	if len(r) != 1 || r[0].Address != testTaddr(1) || r[0].Height != 0 || r[0].ValueZat != 100 ||
+		!bytes.Equal(r[0].Script, script(1)) {
+		t.Fatalf("unexpected utxos %+v", r)
+	}
+
+	// A cursor within the mempool's outputs needs no mined ones.
+	arg.Cursor = []byte(resp.trailer.Get(cursorTrailer)[0])
+	request = nil
+	resp = &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	if request != nil || resp.utxos != nil || resp.trailer != nil {
+		t.Fatalf("unexpected page after a mempool cursor %v, request %+v, trailer %v", resp.utxos, request, resp.trailer)
+	}
+
+	// Without includeMempool, spent UTXOs are still returned.
+	arg = &walletrpc.GetAddressUtxosArg{Addresses: []string{testTaddr(1), testTaddr(2)}}
+	resp = &testpagedutxos{}
+	if err := lwd.GetAddressUtxosStream(arg, resp); err != nil {
+		t.Fatal("GetAddressUtxosStream failed:", err)
+	}
+	if !reflect.DeepEqual(resp.utxos, []string{"100:aa:0", "101:bb:1"}) {
+		t.Fatalf("unexpected utxos without the mempool %v", resp.utxos)
+	}
+}
+
 func getblockStub(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
 	if method != "getblock" {
 		testT.Fatal("unexpected method:", method)
@@ -978,6 +1560,66 @@ func TestGetBlockRangeNilArgs(t *testing.T) {
 	}
 }
 
+func TestGetBlockHeaderRangeNilArgs(t *testing.T) {
+	lwd, _ := testsetup()
+
+	for _, blockrange := range []*walletrpc.BlockRange{
+		{Start: &walletrpc.BlockID{Height: 380640}},
+		{End: &walletrpc.BlockID{Height: 380640}},
+	} {
+		err := lwd.GetBlockHeaderRange(blockrange, &testgetbrange{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("GetBlockHeaderRange nil argument should fail, got", err)
+		}
+	}
+}
+
+type testreorghistory struct {
+	walletrpc.CompactTxStreamer_GetReorgHistoryServer
+	events []*walletrpc.ReorgEvent
+}
+
+func (tr *testreorghistory) Send(e *walletrpc.ReorgEvent) error {
+	tr.events = append(tr.events, e)
+	return nil
+}
+
+func TestGetReorgHistory(t *testing.T) {
+	lwd, cache := testsetup()
+	defer cache.Close()
+
+	hash := "00000000000000000000000000000000000000000000000000000000000000aa"
+	for i := int64(1); i <= 3; i++ {
+		cache.ReorgJournal().Record(&common.ReorgEvent{
+			Time:     1000 * i,
+			Depth:    1,
+			Orphaned: []common.ReorgBlock{{Height: 380640, Hash: hash}},
+			New:      []common.ReorgBlock{{Height: 380640, Hash: hash}},
+		})
+	}
+
+	resp := &testreorghistory{}
+	if err := lwd.GetReorgHistory(&walletrpc.GetReorgHistoryArg{}, resp); err != nil {
+		t.Fatal("GetReorgHistory failed", err)
+	}
+	if len(resp.events) != 3 {
+		t.Fatal("unexpected number of events", len(resp.events))
+	}
+	orphan := resp.events[0].OrphanedBlocks[0]
+	if orphan.Height != 380640 || orphan.Hash[0] != 0xaa || resp.events[0].Depth != 1 {
+		t.Fatal("unexpected orphaned block", orphan)
+	}
+
+	resp = &testreorghistory{}
+	arg := &walletrpc.GetReorgHistoryArg{StartTime: 2000, MaxEntries: 1}
+	if err := lwd.GetReorgHistory(arg, resp); err != nil {
+		t.Fatal("GetReorgHistory failed", err)
+	}
+	if len(resp.events) != 1 || resp.events[0].Time != 2000 {
+		t.Fatal("unexpected events", resp.events)
+	}
+}
+
 func sendrawtransactionStub(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
 	step++
 	if method != "sendrawtransaction" {
@@ -1150,7 +1792,7 @@ func TestGetTaddressTransactionsZeroEndIsBounded(t *testing.T) {
 	}
 
 	filter := &walletrpc.TransparentAddressBlockFilter{
-		Address: "t1234567890123456789012345678901234",
+		Address: validTaddr,
 		Range: &walletrpc.BlockRange{
 			Start: &walletrpc.BlockID{Height: 100},
 			End:   &walletrpc.BlockID{Height: 0}, // non-nil, zero
@@ -1437,3 +2079,370 @@ func TestGetMempoolTxAbortsRefreshOnClientCancel(t *testing.T) {
 		t.Fatalf("a partial cache was published: %d entries", len(*mempoolMap))
 	}
 }
+
+func TestTaddrIndexRPCs(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, cache := testsetup()
+	defer cache.Close()
+	index := common.NewTaddrIndex()
+	cache.SetTaddrIndex(index)
+
+	// With --taddr-index, the backend's address index isn't used.
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		testT.Fatal("unexpected call to the backend:", method)
+		return nil, nil
+	}
+	addrA, _ := address.DecodeTransparent(testTaddr(1), "main")
+	addrB, _ := address.DecodeTransparent(testTaddr(2), "main")
+	txid := hash32.T{0x01}
+	// The compact block has the raw block's hash, so the archive (below)
+	// matches the cache.
+	var blockHex string
+	json.Unmarshal(blocks[0], &blockHex)
+	rawBlock, _ := hex.DecodeString(blockHex)
+	rawHeader := parser.NewBlockHeader()
+	rawHeader.ParseFromSlice(rawBlock)
+	block := &walletrpc.CompactBlock{
+		Height: 380640,
+		Hash:   hash32.ToSlice(rawHeader.GetEncodableHash()),
+		Time:   1700000000,
+		Vtx: []*walletrpc.CompactTx{{
+			Txid: hash32.ToSlice(txid),
+			Vout: []*walletrpc.TxOut{
+				{Value: 1000, ScriptPubKey: parser.AddressScript(addrA)},
+				{Value: 2000, ScriptPubKey: parser.AddressScript(addrB)},
+				{Value: 3000, ScriptPubKey: parser.AddressScript(addrA)},
+			},
+		}},
+	}
+	if err := cache.Add(380640, block); err != nil {
+		t.Fatal(err)
+	}
+	index.CatchUp(cache, 1)
+
+	// GetTaddressTransactions reads the transaction from the raw block
+	// archive, at the position the index recorded.
+	archive := common.NewRawBlockArchive(t.TempDir(), "main", 0)
+	defer archive.Close()
+	if err := archive.Add(380640, rawBlock); err != nil {
+		t.Fatal(err)
+	}
+	cache.SetRawArchive(archive)
+	txs := &testpagedtx{}
+	err := lwd.GetTaddressTransactions(&walletrpc.TransparentAddressBlockFilter{
+		Address: testTaddr(1),
+		Range: &walletrpc.BlockRange{
+			Start: &walletrpc.BlockID{Height: 380000},
+			End:   &walletrpc.BlockID{Height: 380640},
+		},
+	}, txs)
+	if err != nil {
+		t.Fatal("GetTaddressTransactions failed:", err)
+	}
+	if !reflect.DeepEqual(txs.heights, []string{"380640"}) {
+		t.Fatal("unexpected transactions", txs.heights)
+	}
+
+	balance, err := lwd.GetTaddressBalance(context.Background(),
+		&walletrpc.AddressList{Addresses: []string{testTaddr(1), testTaddr(1)}})
+	if err != nil {
+		t.Fatal("GetTaddressBalance failed:", err)
+	}
+	if balance.ValueZat != 4000 {
+		t.Fatal("unexpected balance", balance.ValueZat)
+	}
+
+	utxos, err := lwd.GetAddressUtxos(context.Background(), &walletrpc.GetAddressUtxosArg{
+		Addresses:  []string{testTaddr(1), testTaddr(2)},
+		MaxEntries: 2,
+	})
+	if err != nil {
+		t.Fatal("GetAddressUtxos failed:", err)
+	}
+	r := utxos.AddressUtxos
+	if len(r) != 2 || r[0].Address != testTaddr(1) || r[0].ValueZat != 1000 ||
+		r[1].Index != 2 || r[1].Height != 380640 || !bytes.Equal(r[1].Txid, hash32.ToSlice(txid)) ||
+		!bytes.Equal(r[1].Script, parser.AddressScript(addrA)) {
+		t.Fatalf("unexpected utxos %+v", r)
+	}
+
+	balances, err := lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses:        []string{testTaddr(1), testTaddr(2), testTaddr(1)},
+		MinConfirmations: 2,
+	})
+	if err != nil {
+		t.Fatal("GetTaddressBalanceAtHeight failed:", err)
+	}
+	b := balances.Balances
+	if balances.Height != 380640 || len(b) != 2 ||
+		b[0].Address != testTaddr(1) || b[0].ValueZat != 4000 || b[0].UtxoCount != 2 || b[0].ConfirmedValueZat != 0 ||
+		b[1].Address != testTaddr(2) || b[1].ReceivedZat != 2000 || b[1].SentZat != 0 {
+		t.Fatalf("unexpected balances %+v", balances)
+	}
+	_, err = lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses: []string{testTaddr(1)},
+		Height:    380641,
+	})
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatal("expected OutOfRange for a height above the index, got:", err)
+	}
+
+	history := &testhistory{}
+	err = lwd.GetTaddressHistory(&walletrpc.GetTaddressHistoryArg{
+		Address: testTaddr(1),
+		Range:   &walletrpc.BlockRange{Start: &walletrpc.BlockID{Height: 380000}},
+	}, history)
+	if err != nil {
+		t.Fatal("GetTaddressHistory failed:", err)
+	}
+	if h := history.txs; len(h) != 1 || !bytes.Equal(h[0].Txid, hash32.ToSlice(txid)) ||
+		h[0].Height != 380640 || h[0].BlockTime != 1700000000 || h[0].ReceivedZat != 4000 ||
+		!reflect.DeepEqual(h[0].VoutIndices, []uint32{0, 2}) || h[0].VinIndices != nil {
+		t.Fatalf("unexpected history %+v", h)
+	}
+}
+
+// testhistory records the transactions a GetTaddressHistory response sends.
+type testhistory struct {
+	walletrpc.CompactTxStreamer_GetTaddressHistoryServer
+	txs     []*walletrpc.TaddressTransaction
+	trailer metadata.MD
+}
+
+func (th *testhistory) Context() context.Context {
+	return context.Background()
+}
+
+func (th *testhistory) Send(tx *walletrpc.TaddressTransaction) error {
+	th.txs = append(th.txs, tx)
+	return nil
+}
+
+func (th *testhistory) SetTrailer(md metadata.MD) {
+	th.trailer = md
+}
+
+// cursor returns the continuation cursor in the response's trailer, if any.
+func (th *testhistory) cursor() []byte {
+	if v := th.trailer.Get(cursorTrailer); len(v) > 0 {
+		return []byte(v[0])
+	}
+	return nil
+}
+
+func TestGetTaddressHistory(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, cache := testsetup()
+	defer cache.Close()
+
+	block := &walletrpc.CompactBlock{Height: 380640, Hash: hash32.ToSlice(hash32.T{0x02}), Time: 1700000000}
+	if err := cache.Add(380640, block); err != nil {
+		t.Fatal(err)
+	}
+	txidX := "1100000000000000000000000000000000000000000000000000000000000000"
+	txidY := "2200000000000000000000000000000000000000000000000000000000000000"
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "getaddressdeltas":
+			var request common.ZcashdRpcRequestGetaddressdeltas
+			if err := json.Unmarshal(params[0], &request); err != nil {
+				testT.Fatal("could not unmarshal getaddressdeltas request")
+			}
+			if !reflect.DeepEqual(request.Addresses, []string{testTaddr(1)}) ||
+				(request.Start != 1 && request.Start != 380640) || request.End != 380640 {
+				testT.Fatalf("unexpected getaddressdeltas request %+v", request)
+			}
+			// Y spends X's first output to the address.
+			return json.Marshal([]common.ZcashdRpcReplyGetaddressdeltas{
+				{Address: testTaddr(1), Txid: txidY, Index: 1, Satoshis: -500, Height: 380640, BlockIndex: 2},
+				{Address: testTaddr(1), Txid: txidX, Index: 0, Satoshis: 500, Height: 380640, BlockIndex: 1},
+				{Address: testTaddr(1), Txid: txidX, Index: 3, Satoshis: 200, Height: 380640, BlockIndex: 1},
+			})
+		case "getrawtransaction":
+			return json.Marshal(&common.ZcashdRpcReplyGetrawtransaction{
+				Hex:    hex.EncodeToString(rawTxData[0]),
+				Height: 380640,
+			})
+		}
+		testT.Fatal("unexpected method", method)
+		return nil, nil
+	}
+
+	history := &testhistory{}
+	err := lwd.GetTaddressHistory(&walletrpc.GetTaddressHistoryArg{
+		Address: testTaddr(1),
+		Range: &walletrpc.BlockRange{
+			Start: &walletrpc.BlockID{Height: 0},
+			End:   &walletrpc.BlockID{Height: 380640},
+		},
+		IncludeRawTransactions: true,
+	}, history)
+	if err != nil {
+		t.Fatal("GetTaddressHistory failed:", err)
+	}
+	h := history.txs
+	if len(h) != 2 {
+		t.Fatalf("unexpected history %+v", h)
+	}
+	if hash32.Encode(hash32.Reverse(hash32.FromSlice(h[0].Txid))) != txidX || h[0].TxIndex != 1 ||
+		h[0].BlockTime != 1700000000 || h[0].ReceivedZat != 700 || h[0].SpentZat != 0 ||
+		!reflect.DeepEqual(h[0].VoutIndices, []uint32{0, 3}) || !bytes.Equal(h[0].RawTransaction, rawTxData[0]) {
+		t.Fatalf("unexpected first transaction %+v", h[0])
+	}
+	if h[1].TxIndex != 2 || h[1].SpentZat != 500 || h[1].ReceivedZat != 0 ||
+		!reflect.DeepEqual(h[1].VinIndices, []uint32{1}) || h[1].VoutIndices != nil {
+		t.Fatalf("unexpected second transaction %+v", h[1])
+	}
+	if history.cursor() != nil {
+		t.Fatal("unexpected cursor for a complete response")
+	}
+
+	// One transaction at a time, resuming with the cursor.
+	var pages [][]*walletrpc.TaddressTransaction
+	var cursor []byte
+	for range 3 {
+		history = &testhistory{}
+		err = lwd.GetTaddressHistory(&walletrpc.GetTaddressHistoryArg{
+			Address: testTaddr(1),
+			Range: &walletrpc.BlockRange{
+				Start: &walletrpc.BlockID{Height: 0},
+				End:   &walletrpc.BlockID{Height: 380640},
+			},
+			Cursor:     cursor,
+			MaxEntries: 1,
+		}, history)
+		if err != nil {
+			t.Fatal("GetTaddressHistory failed:", err)
+		}
+		pages = append(pages, history.txs)
+		if cursor = history.cursor(); cursor == nil {
+			break
+		}
+	}
+	if len(pages) != 2 || len(pages[0]) != 1 || len(pages[1]) != 1 ||
+		pages[0][0].TxIndex != 1 || pages[1][0].TxIndex != 2 || pages[1][0].RawTransaction != nil {
+		t.Fatalf("unexpected pages %+v", pages)
+	}
+
+	err = lwd.GetTaddressHistory(&walletrpc.GetTaddressHistoryArg{Address: testTaddr(1)}, &testhistory{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument without a block range, got:", err)
+	}
+}
+
+func TestGetTaddressBalanceAtHeight(t *testing.T) {
+	testT = t
+	defer resetGlobals()
+	lwd, cache := testsetup()
+	defer cache.Close()
+
+	// A spending transaction with a transparent input, and the output it spends.
+	var spendingTx *parser.Transaction
+	for _, data := range rawTxData {
+		tx := parser.NewTransaction()
+		if _, err := tx.ParseFromSlice(data); err == nil && len(tx.TransparentInputs) > 0 {
+			spendingTx = tx
+			break
+		}
+	}
+	if spendingTx == nil {
+		t.Fatal("no test transaction with a transparent input")
+	}
+	in := spendingTx.TransparentInputs[0]
+	prevTxid := hash32.Encode(hash32.Reverse(in.PrevTxHash))
+
+	var requested []string
+	common.RawRequest = func(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+		requested = append(requested, method)
+		switch method {
+		case "getblockchaininfo":
+			return json.Marshal(&common.ZcashdRpcReplyGetblockchaininfo{Blocks: 1000})
+		case "getaddressdeltas":
+			var request common.ZcashdRpcRequestGetaddressdeltas
+			if err := json.Unmarshal(params[0], &request); err != nil {
+				testT.Fatal("could not unmarshal getaddressdeltas request")
+			}
+			if !reflect.DeepEqual(request.Addresses, []string{testTaddr(1), testTaddr(2)}) ||
+				request.Start != 1 || request.End != 990 {
+				testT.Fatalf("unexpected getaddressdeltas request %+v", request)
+			}
+			return json.Marshal([]common.ZcashdRpcReplyGetaddressdeltas{
+				{Address: testTaddr(1), Txid: prevTxid, Index: in.PrevTxOutIndex, Satoshis: 5000, Height: 900},
+				{Address: testTaddr(2), Txid: testTxid, Index: 1, Satoshis: 70, Height: 950},
+				{Address: testTaddr(1), Txid: testTxid, Index: 0, Satoshis: 100, Height: 985},
+				{Address: testTaddr(2), Txid: testTxid, Index: 2, Satoshis: 30, Height: 988},
+				// Spends the first output, but only the transaction says so.
+				{Address: testTaddr(1), Txid: spendingTx.GetDisplayHashString(), Index: 0, Satoshis: -5000, Height: 989},
+			})
+		case "getrawtransaction":
+			return json.Marshal(&common.ZcashdRpcReplyGetrawtransaction{
+				Hex:    hex.EncodeToString(spendingTx.Bytes()),
+				Height: 989,
+			})
+		}
+		testT.Fatal("unexpected method:", method)
+		return nil, nil
+	}
+
+	balances, err := lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses:        []string{testTaddr(1), testTaddr(2), testTaddr(1)},
+		Height:           990,
+		MinConfirmations: 5,
+	})
+	if err != nil {
+		t.Fatal("GetTaddressBalanceAtHeight failed:", err)
+	}
+	b := balances.Balances
+	if balances.Height != 990 || len(b) != 2 ||
+		b[0].ValueZat != 100 || b[0].ReceivedZat != 5100 || b[0].SentZat != 5000 ||
+		b[0].UtxoCount != 1 || b[0].ConfirmedValueZat != 100 ||
+		b[1].ValueZat != 100 || b[1].UtxoCount != 2 || b[1].ConfirmedValueZat != 70 {
+		t.Fatalf("unexpected balances %+v", b)
+	}
+	if !reflect.DeepEqual(requested, []string{"getblockchaininfo", "getaddressdeltas", "getrawtransaction"}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+
+	// With one confirmation, the spending transaction isn't needed.
+	requested = nil
+	balances, err = lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses: []string{testTaddr(1), testTaddr(2)},
+		Height:    990,
+	})
+	if err != nil {
+		t.Fatal("GetTaddressBalanceAtHeight failed:", err)
+	}
+	if b := balances.Balances; b[0].ConfirmedValueZat != 100 || b[1].ConfirmedValueZat != 100 {
+		t.Fatalf("unexpected balances %+v", b)
+	}
+	if !reflect.DeepEqual(requested, []string{"getblockchaininfo", "getaddressdeltas"}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+
+	// Nor with more confirmations than any output can have.
+	requested = nil
+	balances, err = lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses:        []string{testTaddr(1), testTaddr(2)},
+		Height:           990,
+		MinConfirmations: math.MaxUint32,
+	})
+	if err != nil {
+		t.Fatal("GetTaddressBalanceAtHeight failed:", err)
+	}
+	if b := balances.Balances; b[0].ValueZat != 100 || b[0].ConfirmedValueZat != 0 || b[1].ConfirmedValueZat != 0 {
+		t.Fatalf("unexpected balances %+v", b)
+	}
+	if !reflect.DeepEqual(requested, []string{"getblockchaininfo", "getaddressdeltas"}) {
+		t.Fatal("unexpected backend requests:", requested)
+	}
+
+	_, err = lwd.GetTaddressBalanceAtHeight(context.Background(), &walletrpc.GetTaddressBalanceAtHeightArg{
+		Addresses: []string{testTaddr(1)},
+		Height:    1001,
+	})
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatal("expected OutOfRange for a height above the tip, got:", err)
+	}
+}
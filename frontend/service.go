@@ -11,6 +11,8 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"maps"
+	"math"
 	"os"
 	"regexp"
 	"slices"
@@ -20,10 +22,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/zcash/lightwalletd/address"
 	"github.com/zcash/lightwalletd/common"
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser"
 	"github.com/zcash/lightwalletd/walletrpc"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -52,20 +56,48 @@ func NewDarksideStreamer(cache *common.BlockCache) (walletrpc.DarksideStreamerSe
 	return &DarksideStreamer{cache: cache}, nil
 }
 
-// Test to make sure Address is a single t address, return a gRPC error
-func checkTaddress(taddr string) error {
-	match, err := regexp.Match("\\At[a-zA-Z0-9]{34}\\z", []byte(taddr))
+// transparentAddress returns the transparent address (Base58Check, with a
+// P2PKH or P2SH prefix) for the given chain that addr pays to: addr itself,
+// the transparent receiver of a unified address, or the address of a TEX
+// address. Otherwise it returns a gRPC InvalidArgument error.
+func transparentAddress(chainName string, addr string) (string, error) {
+	taddr, err := address.TransparentReceiver(addr, chainName)
 	if err != nil {
-		return status.Errorf(codes.InvalidArgument,
-			"checkTaddress: invalid transparent address: %s error: %s", taddr, err.Error())
+		return "", status.Errorf(codes.InvalidArgument,
+			"invalid transparent address %q: %s", addr, err.Error())
 	}
-	if !match {
-		return status.Errorf(codes.InvalidArgument,
-			"checkTaddress: transparent address %s contains invalid characters", taddr)
+	return taddr, nil
+}
+
+// taddrIndex returns the local transparent address index if lightwalletd
+// keeps one (--taddr-index) and it has caught up with the cache; otherwise
+// it returns nil and the transparent address RPCs use the backend's index.
+func (s *lwdStreamer) taddrIndex() *common.TaddrIndex {
+	if s.cache == nil {
+		return nil
+	}
+	if index := s.cache.TaddrIndex(); index != nil && index.Ready() {
+		return index
 	}
 	return nil
 }
 
+// indexAddresses decodes the given transparent addresses, which
+// transparentAddress has returned, for lookup in the transparent address
+// index.
+func indexAddresses(chainName string, taddrs []string) ([]address.Transparent, error) {
+	addrs := make([]address.Transparent, len(taddrs))
+	for i, taddr := range taddrs {
+		t, err := address.DecodeTransparent(taddr, chainName)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"invalid transparent address %q: %s", taddr, err.Error())
+		}
+		addrs[i] = *t
+	}
+	return addrs, nil
+}
+
 // GetLatestBlock returns the height and hash of the best chain, according to zcashd.
 func (s *lwdStreamer) GetLatestBlock(ctx context.Context, placeholder *walletrpc.ChainSpec) (*walletrpc.BlockID, error) {
 	common.Log.Debugf("gRPC GetLatestBlock(%+v)\n", placeholder)
@@ -98,12 +130,30 @@ func (s *lwdStreamer) GetLatestBlock(ctx context.Context, placeholder *walletrpc
 // address-index scan (GHSA-x4m7-3gpp-xc36, finding 2).
 const maxTaddrTxBlockSpan = 10_000_000
 
+// Defaults for TaddrTxFetchConcurrency and TaddrTxTimeout.
+const (
+	DefaultTaddrTxFetchConcurrency = 8
+	DefaultTaddrTxTimeout          = 30 * time.Second
+)
+
+// TaddrTxFetchConcurrency is the number of transactions (or, from the raw
+// block archive, blocks) GetTaddressTransactions fetches ahead of the one
+// it's sending; it's set by
+// --taddr-tx-fetch-concurrency.
+var TaddrTxFetchConcurrency = DefaultTaddrTxFetchConcurrency
+
+// TaddrTxTimeout bounds the time a GetTaddressTransactions (or
+// GetTaddressHistory or GetTaddressBalanceAtHeight) call may take, including
+// the backend's address index scan; it's set by --taddr-tx-timeout.
+var TaddrTxTimeout = DefaultTaddrTxTimeout
+
 // GetTaddressTxids is a streaming RPC that returns transactions that have
 // the given transparent address (taddr) as either an input or output.
 // NB, this method is misnamed, it does not return txids.
 func (s *lwdStreamer) GetTaddressTransactions(addressBlockFilter *walletrpc.TransparentAddressBlockFilter, resp walletrpc.CompactTxStreamer_GetTaddressTransactionsServer) error {
 	common.Log.Debugf("gRPC GetTaddressTransactions(%+v)\n", addressBlockFilter)
-	if err := checkTaddress(addressBlockFilter.Address); err != nil {
+	taddr, err := transparentAddress(s.chainName, addressBlockFilter.Address)
+	if err != nil {
 		// This returns a gRPC-compatible error.
 		return err
 	}
@@ -114,6 +164,11 @@ func (s *lwdStreamer) GetTaddressTransactions(addressBlockFilter *walletrpc.Tran
 	if addressBlockFilter.Range.Start == nil {
 		return status.Error(codes.InvalidArgument, "GetTaddressTransactions: must specify a start block height")
 	}
+	after, err := decodeCursor(addressBlockFilter.Cursor)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "GetTaddressTransactions: %s", err.Error())
+	}
+	paginated := after != nil || addressBlockFilter.MaxEntries > 0
 
 	// End is optional in the protocol. An unset End would make zcashd's
 	// getaddresstxids scan the address index all the way to the current chain
@@ -137,63 +192,439 @@ func (s *lwdStreamer) GetTaddressTransactions(addressBlockFilter *walletrpc.Tran
 		}
 		end = uint64(info.Blocks)
 	}
+	if after != nil {
+		if after.height < start || after.height > end {
+			return status.Error(codes.InvalidArgument,
+				"GetTaddressTransactions: continuation cursor is outside the block range")
+		}
+		start = after.height
+	}
+	// A paginated request scans only as much of a too-wide range as it may,
+	// and the response's cursor resumes at the next block.
+	var next *cursor
 	if end > start && end-start > maxTaddrTxBlockSpan {
-		return status.Errorf(codes.InvalidArgument,
-			"GetTaddressTransactions: block range too wide (%d blocks, limit %d)",
-			end-start, maxTaddrTxBlockSpan)
+		if !paginated {
+			return status.Errorf(codes.InvalidArgument,
+				"GetTaddressTransactions: block range too wide (%d blocks, limit %d)",
+				end-start, maxTaddrTxBlockSpan)
+		}
+		end = start + maxTaddrTxBlockSpan
+		next = &cursor{height: end + 1}
+	}
+
+	// Bound the total time -- and make the backend calls cancelable -- so a slow
+	// or abandoned scan doesn't hold a lightwalletd goroutine and a zcashd RPC
+	// connection open indefinitely. This deadline covers both the getaddresstxids
+	// index scan and the per-txid getrawtransaction fan-out below.
+	timeout, cancel := context.WithTimeout(resp.Context(), TaddrTxTimeout)
+	defer cancel()
+
+	// The index knows where each transaction is, so it may be read from the
+	// raw block archive; getaddresstxids gives only the txids.
+	var txs []common.TaddrTx
+	if index := s.taddrIndex(); index != nil {
+		addrs, err := indexAddresses(s.chainName, []string{taddr})
+		if err != nil {
+			return err
+		}
+		if start <= end && start <= math.MaxInt32 {
+			txs = index.Transactions(addrs[0], int(start), int(min(end, math.MaxInt32)))
+		}
+	} else {
+		txids, err := getAddressTxids(timeout, taddr, start, end)
+		if err != nil {
+			return err
+		}
+		for _, txid := range txids {
+			txs = append(txs, common.TaddrTx{Txid: txid})
+		}
+	}
+
+	if after != nil && after.txid != hash32.Nil {
+		// The txids are in block order, so those up to the cursor's are at
+		// its height, and were returned before.
+		i := slices.IndexFunc(txs, func(tx common.TaddrTx) bool { return tx.Txid == after.txid })
+		if i >= 0 {
+			txs = txs[i+1:]
+		}
+	}
+	more := false
+	if addressBlockFilter.MaxEntries > 0 && len(txs) > int(addressBlockFilter.MaxEntries) {
+		txs = txs[:addressBlockFilter.MaxEntries]
+		more = true
+	}
+	var last *cursor
+	groups := taddrTxGroups(txs)
+	err = fetchInOrder(timeout, len(groups), TaddrTxFetchConcurrency,
+		func(ctx context.Context, i int) ([]*walletrpc.RawTransaction, error) {
+			return s.getTaddrTransactions(ctx, groups[i])
+		},
+		func(i int, rawTxs []*walletrpc.RawTransaction) error {
+			for j, tx := range rawTxs {
+				if err := resp.Send(tx); err != nil {
+					return err
+				}
+				last = &cursor{height: tx.Height, txid: groups[i][j].Txid}
+			}
+			return nil
+		})
+	if err != nil {
+		if paginated && last != nil {
+			resp.SetTrailer(last.trailer())
+		}
+		return err
+	}
+	if more {
+		next = last
+	}
+	if next != nil {
+		resp.SetTrailer(next.trailer())
+	}
+	return nil
+}
+
+// taddrTxGroups splits txs, which are in block order, into runs of the
+// transactions at the same (known) height, so that getTaddrTransactions
+// reads each block from the raw block archive only once.
+func taddrTxGroups(txs []common.TaddrTx) [][]common.TaddrTx {
+	var groups [][]common.TaddrTx
+	for i := 0; i < len(txs); {
+		j := i + 1
+		for j < len(txs) && txs[i].Height > 0 && txs[j].Height == txs[i].Height {
+			j++
+		}
+		groups = append(groups, txs[i:j])
+		i = j
+	}
+	return groups
+}
+
+// getTaddrTransactions returns the full transactions, which are in the same
+// block if their height is known, from the raw block archive if it has the
+// block, else from the backend.
+func (s *lwdStreamer) getTaddrTransactions(ctx context.Context, txs []common.TaddrTx) ([]*walletrpc.RawTransaction, error) {
+	var data [][]byte
+	if txs[0].Height > 0 && s.cache != nil {
+		if archive := s.cache.RawArchive(); archive != nil {
+			var indices []int
+			for _, tx := range txs {
+				indices = append(indices, tx.TxIndex)
+			}
+			data = archive.GetTransactions(txs[0].Height, indices)
+		}
+	}
+	rawTxs := make([]*walletrpc.RawTransaction, len(txs))
+	for i, tx := range txs {
+		if data != nil && data[i] != nil {
+			rawTxs[i] = &walletrpc.RawTransaction{Data: data[i], Height: uint64(tx.Height)}
+			continue
+		}
+		rawTx, err := s.GetTransaction(ctx, &walletrpc.TxFilter{Hash: hash32.ToSlice(tx.Txid)})
+		if err != nil {
+			return nil, err
+		}
+		rawTxs[i] = rawTx
+	}
+	return rawTxs, nil
+}
+
+// fetchInOrder calls fetch for each of the indices 0 through n-1, up to
+// concurrency of them at once, and send with each result, in order; fetch
+// runs at most concurrency results ahead of send, which bounds the memory
+// the results take. It stops at the first error from either, or when ctx is
+// done, cancelling the context it passes to fetch.
+func fetchInOrder[T any](ctx context.Context, n int, concurrency int, fetch func(context.Context, int) (T, error), send func(int, T) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		value T
+		err   error
+	}
+	results := make([]chan result, n)
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	// A slot is taken for each fetch, and released when its result is sent.
+	slots := make(chan struct{}, max(concurrency, 1))
+	go func() {
+		for i := range n {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				value, err := fetch(ctx, i)
+				results[i] <- result{value, err}
+			}()
+		}
+	}()
+	for i := range n {
+		var r result
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		<-slots
+		if r.err != nil {
+			if ctx.Err() != nil {
+				// The fetch most likely failed because the deadline passed.
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return r.err
+		}
+		if err := send(i, r.value); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// getAddressTxids returns the txids (little-endian) of the transactions that
+// pay or spend from taddr at heights start through end, using the backend's
+// getaddresstxids.
+func getAddressTxids(ctx context.Context, taddr string, start uint64, end uint64) ([]hash32.T, error) {
 	request := &common.ZcashdRpcRequestGetaddresstxids{
-		Addresses: []string{addressBlockFilter.Address},
+		Addresses: []string{taddr},
 		Start:     start,
 		End:       end,
 	}
 
 	param, err := json.Marshal(request)
 	if err != nil {
-		return status.Errorf(codes.InvalidArgument,
+		return nil, status.Errorf(codes.InvalidArgument,
 			"GetTaddressTransactions: error marshalling request: %s", err.Error())
 	}
 	params := []json.RawMessage{param}
 
-	// Bound the total time -- and make the backend calls cancelable -- so a slow
-	// or abandoned scan doesn't hold a lightwalletd goroutine and a zcashd RPC
-	// connection open indefinitely. This deadline covers both the getaddresstxids
-	// index scan and the per-txid getrawtransaction fan-out below.
-	timeout, cancel := context.WithTimeout(resp.Context(), 30*time.Second)
-	defer cancel()
-
-	result, rpcErr := common.RawRequest(timeout, "getaddresstxids", params)
+	result, rpcErr := common.RawRequest(ctx, "getaddresstxids", params)
 
 	// For some reason, the error responses are not JSON
 	if rpcErr != nil {
-		return status.Errorf(codes.InvalidArgument,
+		return nil, status.Errorf(codes.InvalidArgument,
 			"GetTaddressTransactions: getaddresstxids failed, error: %s", rpcErr.Error())
 	}
 
-	var txids []string
-	err = json.Unmarshal(result, &txids)
+	var txidstrs []string
+	err = json.Unmarshal(result, &txidstrs)
 	if err != nil {
-		return status.Errorf(codes.Unknown,
+		return nil, status.Errorf(codes.Unknown,
 			"GetTaddressTransactions: error unmarshalling getaddresstxids reply: %s", err.Error())
 	}
 
-	for _, txidstr := range txids {
-		txidBigEndian, _ := hex.DecodeString(txidstr)
+	txids := make([]hash32.T, len(txidstrs))
+	for i, txidstr := range txidstrs {
+		txidBigEndian, err := hash32.Decode(txidstr)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"GetTaddressTransactions: bad txid %q in getaddresstxids reply: %s", txidstr, err.Error())
+		}
 		// Txid is read as a string, which is in big-endian order. But when converting
 		// to bytes, it should be little-endian
-		txHash := hash32.ReverseSlice(txidBigEndian)
-		tx, err := s.GetTransaction(timeout, &walletrpc.TxFilter{Hash: txHash})
+		txids[i] = hash32.Reverse(txidBigEndian)
+	}
+	return txids, nil
+}
+
+// GetTaddressHistory is a streaming RPC that returns the transactions that
+// pay or spend from a taddr in a block range, with their block context and
+// the value they move, from the transparent address index if it's ready,
+// else from the backend's getaddressdeltas. The block times come from the
+// cache (or the backend, for blocks it doesn't have). Results are paginated
+// as for GetTaddressTransactions.
+func (s *lwdStreamer) GetTaddressHistory(arg *walletrpc.GetTaddressHistoryArg, resp walletrpc.CompactTxStreamer_GetTaddressHistoryServer) error {
+	common.Log.Debugf("gRPC GetTaddressHistory(%+v)\n", arg)
+	taddr, err := transparentAddress(s.chainName, arg.Address)
+	if err != nil {
+		return err
+	}
+	if arg.Range == nil || arg.Range.Start == nil {
+		return status.Error(codes.InvalidArgument,
+			"GetTaddressHistory: must specify a block range with a start height")
+	}
+	after, err := decodeCursor(arg.Cursor)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "GetTaddressHistory: %s", err.Error())
+	}
+	paginated := after != nil || arg.MaxEntries > 0
+
+	// As GetTaddressTransactions, default End to the tip and bound the span.
+	index := s.taddrIndex()
+	start := arg.Range.Start.Height
+	var end uint64
+	switch {
+	case arg.Range.End != nil && arg.Range.End.Height > 0:
+		end = arg.Range.End.Height
+	case index != nil:
+		end = uint64(max(index.GetNextHeight()-1, 0))
+	default:
+		info, err := common.GetBlockChainInfo()
+		if err != nil {
+			return status.Errorf(codes.Unavailable,
+				"GetTaddressHistory: could not determine chain tip: %s", err.Error())
+		}
+		end = uint64(info.Blocks)
+	}
+	if after != nil {
+		if after.height < start || after.height > end {
+			return status.Error(codes.InvalidArgument,
+				"GetTaddressHistory: continuation cursor is outside the block range")
+		}
+		start = after.height
+	}
+	var next *cursor
+	if end > start && end-start > maxTaddrTxBlockSpan {
+		if !paginated {
+			return status.Errorf(codes.InvalidArgument,
+				"GetTaddressHistory: block range too wide (%d blocks, limit %d)",
+				end-start, maxTaddrTxBlockSpan)
+		}
+		end = start + maxTaddrTxBlockSpan
+		next = &cursor{height: end + 1}
+	}
+	timeout, cancel := context.WithTimeout(resp.Context(), TaddrTxTimeout)
+	defer cancel()
+
+	var entries []common.TaddrEntry
+	if index != nil {
+		addrs, err := indexAddresses(s.chainName, []string{taddr})
+		if err != nil {
+			return err
+		}
+		if start <= end && start <= math.MaxInt32 {
+			entries = index.Entries(addrs[0], int(start), int(min(end, math.MaxInt32)))
+		}
+	} else if start <= end {
+		// getaddressdeltas rejects a zero start.
+		deltas, err := getAddressDeltas(timeout, "GetTaddressHistory", []string{taddr}, max(start, 1), end)
 		if err != nil {
 			return err
 		}
-		if err = resp.Send(tx); err != nil {
+		if entries, err = deltaEntries(deltas); err != nil {
 			return err
 		}
 	}
+
+	var txs []*walletrpc.TaddressTransaction
+	var positions []common.TaddrTx
+	for i := 0; i < len(entries); {
+		e := entries[i]
+		tx := &walletrpc.TaddressTransaction{
+			Txid:    hash32.ToSlice(e.Txid),
+			Height:  uint64(e.Height),
+			TxIndex: uint64(e.TxIndex),
+		}
+		// A transaction's entries are adjacent.
+		for ; i < len(entries) && entries[i].Txid == e.Txid; i++ {
+			if entries[i].Spending {
+				tx.SpentZat += entries[i].Value
+				tx.VinIndices = append(tx.VinIndices, entries[i].Index)
+			} else {
+				tx.ReceivedZat += entries[i].Value
+				tx.VoutIndices = append(tx.VoutIndices, entries[i].Index)
+			}
+		}
+		txs = append(txs, tx)
+		positions = append(positions, common.TaddrTx{Txid: e.Txid, Height: e.Height, TxIndex: e.TxIndex})
+	}
+	if after != nil && after.txid != hash32.Nil {
+		i := slices.IndexFunc(positions, func(tx common.TaddrTx) bool { return tx.Txid == after.txid })
+		if i >= 0 {
+			txs, positions = txs[i+1:], positions[i+1:]
+		}
+	}
+	more := false
+	if arg.MaxEntries > 0 && len(txs) > int(arg.MaxEntries) {
+		txs, positions = txs[:arg.MaxEntries], positions[:arg.MaxEntries]
+		more = true
+	}
+
+	// Each block's time is looked up, and its transactions read, once.
+	groups := taddrTxGroups(positions)
+	offsets := make([]int, len(groups))
+	for i := 1; i < len(groups); i++ {
+		offsets[i] = offsets[i-1] + len(groups[i-1])
+	}
+	var last *cursor
+	err = fetchInOrder(timeout, len(groups), TaddrTxFetchConcurrency,
+		func(ctx context.Context, i int) ([]*walletrpc.TaddressTransaction, error) {
+			group := txs[offsets[i] : offsets[i]+len(groups[i])]
+			block, err := common.GetBlock(ctx, s.cache, groups[i][0].Height)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, status.Errorf(codes.NotFound,
+					"GetTaddressHistory: block %d not found", groups[i][0].Height)
+			}
+			for _, tx := range group {
+				tx.BlockTime = block.Time
+			}
+			if arg.IncludeRawTransactions {
+				rawTxs, err := s.getTaddrTransactions(ctx, groups[i])
+				if err != nil {
+					return nil, err
+				}
+				for j, rawTx := range rawTxs {
+					group[j].RawTransaction = rawTx.Data
+				}
+			}
+			return group, nil
+		},
+		func(i int, group []*walletrpc.TaddressTransaction) error {
+			for _, tx := range group {
+				if err := resp.Send(tx); err != nil {
+					return err
+				}
+				last = &cursor{height: tx.Height, txid: hash32.FromSlice(tx.Txid)}
+			}
+			return nil
+		})
+	if err != nil {
+		if paginated && last != nil {
+			resp.SetTrailer(last.trailer())
+		}
+		return err
+	}
+	if more {
+		next = last
+	}
+	if next != nil {
+		resp.SetTrailer(next.trailer())
+	}
 	return nil
 }
 
+// deltaEntries converts the backend's getaddressdeltas for an address to
+// transparent address index entries, in block order. The deltas don't say
+// which output a spend spends, so the entries' Prev fields are zero.
+func deltaEntries(deltas []common.ZcashdRpcReplyGetaddressdeltas) ([]common.TaddrEntry, error) {
+	entries := make([]common.TaddrEntry, len(deltas))
+	for i, d := range deltas {
+		txidBigEndian, err := hash32.Decode(d.Txid)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"bad txid %q in getaddressdeltas reply: %s", d.Txid, err.Error())
+		}
+		entries[i] = common.TaddrEntry{
+			Height:   d.Height,
+			TxIndex:  d.BlockIndex,
+			Txid:     hash32.Reverse(txidBigEndian),
+			Index:    d.Index,
+			Spending: d.Satoshis < 0,
+			Value:    uint64(max(d.Satoshis, -d.Satoshis)),
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b common.TaddrEntry) int {
+		if a.Height != b.Height {
+			return a.Height - b.Height
+		}
+		return a.TxIndex - b.TxIndex
+	})
+	return entries, nil
+}
+
 // This method is deprecated; use GetTaddressTransactions instead. The two functions have the
 // same functionality, but the name GetTaddressTxids is misleading, because the method returns
 // transactions, not transaction IDs (txids). See https://github.com/zcash/lightwalletd/issues/426
@@ -307,6 +738,35 @@ func (s *lwdStreamer) GetBlockRange(span *walletrpc.BlockRange, resp walletrpc.C
 	}
 }
 
+// GetBlockHeaderRange returns a stream of header-only compact blocks, whose
+// header field is the full serialized block header, for clients that verify
+// the chain's proof of work and hash linkage.
+func (s *lwdStreamer) GetBlockHeaderRange(span *walletrpc.BlockRange, resp walletrpc.CompactTxStreamer_GetBlockHeaderRangeServer) error {
+	common.Log.Debugf("gRPC GetBlockHeaderRange(%+v)\n", span)
+	if span.Start == nil || span.End == nil {
+		return status.Error(codes.InvalidArgument,
+			"GetBlockHeaderRange: must specify start and end heights")
+	}
+	ctx := resp.Context()
+	blockChan := make(chan *walletrpc.CompactBlock)
+	errChan := make(chan error)
+	go common.GetBlockHeaderRange(ctx, s.cache, blockChan, errChan, span)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errChan:
+			return err
+		case cBlock := <-blockChan:
+			err := resp.Send(cBlock)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // GetBlockRangeNullifiers is the same as GetBlockRange except that only
 // the actions contain only nullifiers (a subset of the full compact block).
 func (s *lwdStreamer) GetBlockRangeNullifiers(span *walletrpc.BlockRange, resp walletrpc.CompactTxStreamer_GetBlockRangeNullifiersServer) error {
@@ -457,7 +917,8 @@ func (s *lwdStreamer) GetLatestTreeState(ctx context.Context, in *walletrpc.Empt
 }
 
 // GetTransaction returns the raw transaction bytes that are returned
-// by the zcashd 'getrawtransaction' RPC.
+// by the zcashd 'getrawtransaction' RPC. The transaction may be specified
+// either by txid or by a block (height or hash) and an index within it.
 func (s *lwdStreamer) GetTransaction(ctx context.Context, txf *walletrpc.TxFilter) (*walletrpc.RawTransaction, error) {
 	common.Log.Debugf("gRPC GetTransaction(%+v)\n", txf)
 	if txf.Hash != nil {
@@ -489,12 +950,70 @@ func (s *lwdStreamer) GetTransaction(ctx context.Context, txf *walletrpc.TxFilte
 		return tx, err
 	}
 
-	if txf.Block != nil && txf.Block.Hash != nil {
-		return nil, status.Error(codes.InvalidArgument,
-			"GetTransaction: specify a txid, not a blockhash+num")
+	if txf.Block != nil {
+		txid, err := getBlockTxid(ctx, s.cache, txf.Block, txf.Index)
+		if err != nil {
+			// getBlockTxid() returns gRPC-compatible errors.
+			return nil, err
+		}
+		return s.GetTransaction(ctx, &walletrpc.TxFilter{Hash: txid})
 	}
 	return nil, status.Error(codes.InvalidArgument,
-		"GetTransaction: specify a txid")
+		"GetTransaction: specify a txid or a block and index")
+}
+
+// getBlockTxid returns the txid (little-endian) of the transaction at the
+// given index within the given block. A block specified by height is looked
+// up in the cache (falling back to the backend, as GetBlock does); a block
+// specified by hash (little-endian, as in CompactBlock.Hash) is looked up
+// using the backend's verbose getblock, which lists the block's txids.
+// This returns gRPC-compatible errors.
+func getBlockTxid(ctx context.Context, cache *common.BlockCache, id *walletrpc.BlockID, index uint64) ([]byte, error) {
+	// Precedence: a hash is more specific than a height. If we have it, use it first.
+	if id.Hash != nil {
+		if len(id.Hash) != blockHashLen {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"GetTransaction: block hash has invalid length: %d", len(id.Hash))
+		}
+		hashJSON, err := json.Marshal(hash32.Encode(hash32.Reverse(hash32.FromSlice(id.Hash))))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"GetTransaction: cannot marshal block hash: %s", err.Error())
+		}
+		params := []json.RawMessage{hashJSON, json.RawMessage("1")}
+		result, rpcErr := common.RawRequest(ctx, "getblock", params)
+		if rpcErr != nil {
+			return nil, status.Errorf(codes.NotFound,
+				"GetTransaction: getblock failed: %s", rpcErr.Error())
+		}
+		var block1 common.ZcashRpcReplyGetblock1
+		if err := json.Unmarshal(result, &block1); err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"GetTransaction: cannot unmarshal getblock reply: %s", err.Error())
+		}
+		if index >= uint64(len(block1.Tx)) {
+			return nil, status.Errorf(codes.OutOfRange,
+				"GetTransaction: index %d out of range, block has %d transactions",
+				index, len(block1.Tx))
+		}
+		txidBigEndian, err := hash32.Decode(block1.Tx[index])
+		if err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"GetTransaction: cannot decode getblock txid: %s", err.Error())
+		}
+		return hash32.ToSlice(hash32.Reverse(txidBigEndian)), nil
+	}
+	cBlock, err := common.GetBlock(ctx, cache, int(id.Height))
+	if err != nil {
+		// GetBlock() returns gRPC-compatible errors.
+		return nil, err
+	}
+	if index >= uint64(len(cBlock.Vtx)) {
+		return nil, status.Errorf(codes.OutOfRange,
+			"GetTransaction: index %d out of range, block has %d transactions",
+			index, len(cBlock.Vtx))
+	}
+	return cBlock.Vtx[index].Txid, nil
 }
 
 // GetLightdInfo gets the LightWalletD (this server) info, and includes information
@@ -580,25 +1099,46 @@ func (s *lwdStreamer) SendTransaction(ctx context.Context, rawtx *walletrpc.RawT
 	return r, nil
 }
 
-func getTaddressBalanceZcashdRpc(ctx context.Context, addressList []string) (*walletrpc.Balance, error) {
-	// GHSA-x4m7-3gpp-xc36
-	if len(addressList) > maxTaddrsPerRequest {
-		return nil, status.Errorf(codes.ResourceExhausted,
-			"getTaddressBalanceZcashdRpc: too many addresses (limit %d)", maxTaddrsPerRequest)
-	}
-	// Eliminate duplicate addresses; zcashd sums per list entry, so a repeated
-	// address would otherwise inflate the balance it reports.
+// uniqueTransparentAddresses returns the transparent addresses that the given
+// addresses pay to (see transparentAddress), in order, without duplicates.
+func uniqueTransparentAddresses(chainName string, addressList []string) ([]string, error) {
 	addresses := make([]string, 0)
 	seen := make(map[string]struct{})
 	for _, addr := range addressList {
-		if _, dup := seen[addr]; dup {
+		taddr, err := transparentAddress(chainName, addr)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := seen[taddr]; dup {
 			continue
 		}
-		if err := checkTaddress(addr); err != nil {
+		seen[taddr] = struct{}{}
+		addresses = append(addresses, taddr)
+	}
+	return addresses, nil
+}
+
+// getTaddressBalance returns the total balance of the given addresses, from
+// the transparent address index if it isn't nil, else from the backend.
+func getTaddressBalance(ctx context.Context, index *common.TaddrIndex, chainName string, addressList []string) (*walletrpc.Balance, error) {
+	// GHSA-x4m7-3gpp-xc36
+	if len(addressList) > maxTaddrsPerRequest {
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"getTaddressBalance: too many addresses (limit %d)", maxTaddrsPerRequest)
+	}
+	// Eliminate duplicate addresses; zcashd sums per list entry, so a
+	// repeated address would otherwise inflate the balance it reports.
+	addresses, err := uniqueTransparentAddresses(chainName, addressList)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		addrs, err := indexAddresses(chainName, addresses)
+		if err != nil {
 			return nil, err
 		}
-		seen[addr] = struct{}{}
-		addresses = append(addresses, addr)
+		balance, _ := index.Balance(addrs)
+		return &walletrpc.Balance{ValueZat: int64(balance)}, nil
 	}
 	params := make([]json.RawMessage, 1)
 	addrList := &common.ZcashdRpcRequestGetaddressbalance{
@@ -620,13 +1160,13 @@ func getTaddressBalanceZcashdRpc(ctx context.Context, addressList []string) (*wa
 			code = codes.NotFound
 		}
 		return nil, status.Errorf(code,
-			"getTaddressBalanceZcashdRpc: getaddressbalance error: %s", rpcErr.Error())
+			"getTaddressBalance: getaddressbalance error: %s", rpcErr.Error())
 	}
 	var balanceReply common.ZcashdRpcReplyGetaddressbalance
 	err = json.Unmarshal(result, &balanceReply)
 	if err != nil {
 		return nil, status.Errorf(codes.Unknown,
-			"getTaddressBalanceZcashdRpc: failed to unmarshal getaddressbalance reply, error: %s", err.Error())
+			"getTaddressBalance: failed to unmarshal getaddressbalance reply, error: %s", err.Error())
 	}
 	return &walletrpc.Balance{ValueZat: balanceReply.Balance}, nil
 }
@@ -634,13 +1174,224 @@ func getTaddressBalanceZcashdRpc(ctx context.Context, addressList []string) (*wa
 // GetTaddressBalance returns the total balance for a list of taddrs
 func (s *lwdStreamer) GetTaddressBalance(ctx context.Context, addresses *walletrpc.AddressList) (*walletrpc.Balance, error) {
 	common.Log.Debugf("gRPC GetTaddressBalance(%+v)\n", addresses)
-	r, err := getTaddressBalanceZcashdRpc(ctx, addresses.Addresses)
+	r, err := getTaddressBalance(ctx, s.taddrIndex(), s.chainName, addresses.Addresses)
 	if err == nil {
 		common.Log.Tracef("  return: %+v\n", r)
 	}
 	return r, err
 }
 
+// GetTaddressBalanceAtHeight returns the balance of each of the given taddrs
+// as of a block height, from the transparent address index if it's ready,
+// else from the backend's getaddressdeltas.
+func (s *lwdStreamer) GetTaddressBalanceAtHeight(ctx context.Context, arg *walletrpc.GetTaddressBalanceAtHeightArg) (*walletrpc.TaddressBalanceList, error) {
+	common.Log.Debugf("gRPC GetTaddressBalanceAtHeight(%+v)\n", arg)
+	// GHSA-x4m7-3gpp-xc36
+	if len(arg.Addresses) > maxTaddrsPerRequest {
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"GetTaddressBalanceAtHeight: too many addresses (limit %d)", maxTaddrsPerRequest)
+	}
+	addresses, err := uniqueTransparentAddresses(s.chainName, arg.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	index := s.taddrIndex()
+	var tip int
+	if index != nil {
+		tip = index.GetNextHeight() - 1
+	} else {
+		info, err := common.GetBlockChainInfo()
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable,
+				"GetTaddressBalanceAtHeight: could not determine chain tip: %s", err.Error())
+		}
+		tip = info.Blocks
+	}
+	height := tip
+	if arg.Height > 0 {
+		if arg.Height > uint64(tip) {
+			return nil, status.Errorf(codes.OutOfRange,
+				"GetTaddressBalanceAtHeight: height %d is above the chain tip %d", arg.Height, tip)
+		}
+		height = int(arg.Height)
+	}
+	// No output has more than height+1 confirmations, so a larger minimum
+	// is no different.
+	minConf := int(min(max(arg.MinConfirmations, 1), uint32(height)+1))
+
+	var balances []common.TaddrBalance
+	if index != nil {
+		addrs, err := indexAddresses(s.chainName, addresses)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			balances = append(balances, index.BalanceAt(addr, height, minConf))
+		}
+	} else {
+		// As GetTaddressTransactions, bound the backend scan and fetches.
+		timeout, cancel := context.WithTimeout(ctx, TaddrTxTimeout)
+		defer cancel()
+		if balances, err = s.getTaddressBalancesFromDeltas(timeout, addresses, height, minConf); err != nil {
+			return nil, err
+		}
+	}
+	r := &walletrpc.TaddressBalanceList{Height: uint64(height)}
+	for i, b := range balances {
+		r.Balances = append(r.Balances, &walletrpc.TaddressBalance{
+			Address:           addresses[i],
+			ValueZat:          int64(b.Balance),
+			ReceivedZat:       int64(b.Received),
+			SentZat:           int64(b.Sent),
+			UtxoCount:         uint32(b.Utxos),
+			ConfirmedValueZat: int64(b.Confirmed),
+		})
+	}
+	common.Log.Tracef("  return: %+v\n", r)
+	return r, nil
+}
+
+// getTaddressBalancesFromDeltas returns the balances of the given addresses
+// as of the given height (see TaddrIndex.BalanceAt), computed from the
+// backend's getaddressdeltas. A spend's delta doesn't say which output it
+// spends, which matters only for spends of outputs that may have fewer than
+// minConf confirmations at the height; only those spending transactions are
+// fetched, to find the outputs they spend.
+func (s *lwdStreamer) getTaddressBalancesFromDeltas(ctx context.Context, addresses []string, height int, minConf int) ([]common.TaddrBalance, error) {
+	balances := make([]common.TaddrBalance, len(addresses))
+	if height < 1 || len(addresses) == 0 {
+		return balances, nil
+	}
+	deltas, err := getAddressDeltas(ctx, "GetTaddressBalanceAtHeight", addresses, 1, uint64(height))
+	if err != nil {
+		return nil, err
+	}
+
+	type outpoint struct {
+		txid  hash32.T // little-endian
+		index uint32
+	}
+	type spend struct {
+		outpoint
+		addr  int
+		value int64
+	}
+	positions := make(map[string]int)
+	for i, addr := range addresses {
+		positions[addr] = i
+	}
+	// The signed sums, as a spend may be listed before the output it spends.
+	sums := make([]struct{ received, sent, confirmed, utxos int64 }, len(addresses))
+	outputHeights := make(map[outpoint]int)
+	hasConfirmed := make([]bool, len(addresses))
+	var recentSpends []spend
+	confirmedHeight := height - minConf + 1
+	for _, d := range deltas {
+		i, ok := positions[d.Address]
+		if !ok || d.Height > height {
+			continue
+		}
+		txidBigEndian, err := hash32.Decode(d.Txid)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal,
+				"GetTaddressBalanceAtHeight: bad txid %q in getaddressdeltas reply: %s", d.Txid, err.Error())
+		}
+		op := outpoint{txid: hash32.Reverse(txidBigEndian), index: d.Index}
+		if d.Satoshis >= 0 {
+			sums[i].received += d.Satoshis
+			sums[i].utxos++
+			if d.Height <= confirmedHeight {
+				sums[i].confirmed += d.Satoshis
+				hasConfirmed[i] = true
+			}
+			outputHeights[op] = d.Height
+			continue
+		}
+		sums[i].sent -= d.Satoshis
+		sums[i].utxos--
+		if d.Height <= confirmedHeight {
+			// The output it spends can't be any higher.
+			sums[i].confirmed += d.Satoshis
+		} else {
+			recentSpends = append(recentSpends, spend{outpoint: op, addr: i, value: -d.Satoshis})
+		}
+	}
+	// A recent spend can only spend a confirmed output of an address that
+	// has one.
+	recentSpends = slices.DeleteFunc(recentSpends, func(sp spend) bool { return !hasConfirmed[sp.addr] })
+	err = fetchInOrder(ctx, len(recentSpends), TaddrTxFetchConcurrency,
+		func(ctx context.Context, i int) (bool, error) {
+			sp := recentSpends[i]
+			rawTx, err := s.GetTransaction(ctx, &walletrpc.TxFilter{Hash: hash32.ToSlice(sp.txid)})
+			if err != nil {
+				return false, err
+			}
+			tx := parser.NewTransaction()
+			if _, err := tx.ParseFromSlice(rawTx.Data); err != nil {
+				return false, status.Errorf(codes.Internal,
+					"GetTaddressBalanceAtHeight: cannot parse transaction: %s", err.Error())
+			}
+			if int(sp.index) >= len(tx.TransparentInputs) {
+				return false, status.Errorf(codes.Internal,
+					"GetTaddressBalanceAtHeight: getaddressdeltas input index %d is out of range", sp.index)
+			}
+			in := tx.TransparentInputs[sp.index]
+			prevHeight, ok := outputHeights[outpoint{txid: in.PrevTxHash, index: in.PrevTxOutIndex}]
+			return ok && prevHeight <= confirmedHeight, nil
+		},
+		func(i int, spendsConfirmed bool) error {
+			if spendsConfirmed {
+				sums[recentSpends[i].addr].confirmed -= recentSpends[i].value
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	for i, sum := range sums {
+		balances[i] = common.TaddrBalance{
+			Balance:   uint64(sum.received - sum.sent),
+			Received:  uint64(sum.received),
+			Sent:      uint64(sum.sent),
+			Utxos:     int(sum.utxos),
+			Confirmed: uint64(sum.confirmed),
+		}
+	}
+	return balances, nil
+}
+
+// getAddressDeltas returns the backend's getaddressdeltas for the given
+// addresses at heights start through end; method names the gRPC method in
+// errors.
+func getAddressDeltas(ctx context.Context, method string, addresses []string, start uint64, end uint64) ([]common.ZcashdRpcReplyGetaddressdeltas, error) {
+	param, err := json.Marshal(&common.ZcashdRpcRequestGetaddressdeltas{
+		Addresses: addresses,
+		Start:     start,
+		End:       end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, rpcErr := common.RawRequest(ctx, "getaddressdeltas", []json.RawMessage{param})
+	if rpcErr != nil {
+		var code codes.Code
+		switch {
+		case strings.Contains(rpcErr.Error(), "Invalid address"):
+			code = codes.InvalidArgument
+		case strings.Contains(rpcErr.Error(), "No information available"):
+			code = codes.NotFound
+		}
+		return nil, status.Errorf(code,
+			"%s: getaddressdeltas error: %s", method, rpcErr.Error())
+	}
+	var deltas []common.ZcashdRpcReplyGetaddressdeltas
+	if err := json.Unmarshal(result, &deltas); err != nil {
+		return nil, status.Errorf(codes.Unknown,
+			"%s: failed to unmarshal getaddressdeltas reply, error: %s", method, err.Error())
+	}
+	return deltas, nil
+}
+
 // maxTaddrsPerRequest bounds the number of transparent addresses a single
 // request may cause lightwalletd to process, across the transparent-address
 // gRPC methods. Without a cap, an unauthenticated client can drive unbounded
@@ -665,7 +1416,7 @@ func (s *lwdStreamer) GetTaddressBalanceStream(addresses walletrpc.CompactTxStre
 		}
 		// Validate and bound each address as it arrives, rather than
 		// accumulating unbounded, unvalidated input (GHSA-x4m7-3gpp-xc36).
-		if err := checkTaddress(addr.Address); err != nil {
+		if _, err := transparentAddress(s.chainName, addr.Address); err != nil {
 			return err
 		}
 		if len(addressList) >= maxTaddrsPerRequest {
@@ -674,7 +1425,7 @@ func (s *lwdStreamer) GetTaddressBalanceStream(addresses walletrpc.CompactTxStre
 		}
 		addressList = append(addressList, addr.Address)
 	}
-	balance, err := getTaddressBalanceZcashdRpc(addresses.Context(), addressList)
+	balance, err := getTaddressBalance(addresses.Context(), s.taddrIndex(), s.chainName, addressList)
 	if err != nil {
 		return err
 	}
@@ -750,10 +1501,36 @@ func (s *lwdStreamer) GetMempoolTx(exclude *walletrpc.GetMempoolTxRequest, resp
 		excludeHex[i] = hex.EncodeToString(rev)
 	}
 
+	cachedList, cachedMap, err := s.getMempool(resp.Context())
+	if err != nil {
+		return err
+	}
+	for _, txid := range MempoolFilter(cachedList, excludeHex) {
+		ctx, ok := cachedMap[txid]
+		if !ok {
+			// The transaction was in getrawmempool's reply but its
+			// getrawtransaction failed (it may have been mined or evicted
+			// in between); it will be picked up on the next refresh.
+			continue
+		}
+		if ftx := common.FilterTxPool(ctx, exclude.PoolTypes); ftx != nil {
+			err := resp.Send(ftx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getMempool returns the txids (big-endian hex) of the transactions in the
+// mempool and their compact forms, keyed by txid; the map lacks any that
+// could not be fetched. It refreshes its copy from the backend if it is more
+// than 2 seconds old.
+func (s *lwdStreamer) getMempool(ctx context.Context) ([]string, map[string]*walletrpc.CompactTx, error) {
 	// Hold the mutex only long enough to decide whether this call refreshes
 	// the cache, and to snapshot it and update lastMempool to prevent another
 	// thread from refreshing while our thread is doing that.
-	streamCtx := resp.Context()
 	s.mutex.Lock()
 	refresh := time.Since(lastMempool).Seconds() >= 2
 	if refresh {
@@ -769,35 +1546,35 @@ func (s *lwdStreamer) GetMempoolTx(exclude *walletrpc.GetMempoolTxRequest, resp
 	if refresh {
 		// Refresh our copy of the mempool.
 		params := make([]json.RawMessage, 0)
-		result, rpcErr := common.RawRequest(streamCtx, "getrawmempool", params)
+		result, rpcErr := common.RawRequest(ctx, "getrawmempool", params)
 		if rpcErr != nil {
-			return status.Errorf(codes.Internal, "GetMempoolTx: getrawmempool error: %s", rpcErr.Error())
+			return nil, nil, status.Errorf(codes.Internal, "getMempool: getrawmempool error: %s", rpcErr.Error())
 		}
 		var newmempoolList []string
 		err := json.Unmarshal(result, &newmempoolList)
 		if err != nil {
-			return status.Errorf(codes.Unknown,
-				"GetMempoolTx: failed to unmarshal getrawmempool reply, error: %s", err.Error())
+			return nil, nil, status.Errorf(codes.Unknown,
+				"getMempool: failed to unmarshal getrawmempool reply, error: %s", err.Error())
 		}
 		newmempoolMap := make(map[string]*walletrpc.CompactTx)
 		for _, txidstr := range newmempoolList {
-			if err := streamCtx.Err(); err != nil {
-				return status.FromContextError(err).Err()
+			if err := ctx.Err(); err != nil {
+				return nil, nil, status.FromContextError(err).Err()
 			}
-			if ctx, ok := cachedMap[txidstr]; ok {
-				// This ctx has already been fetched, copy pointer to it.
-				newmempoolMap[txidstr] = ctx
+			if compact, ok := cachedMap[txidstr]; ok {
+				// This transaction has already been fetched, copy pointer to it.
+				newmempoolMap[txidstr] = compact
 				continue
 			}
 			txidJSON, err := json.Marshal(txidstr)
 			if err != nil {
-				return status.Errorf(codes.Unknown,
-					"GetMempoolTx: failed to marshal txid, error: %s", err.Error())
+				return nil, nil, status.Errorf(codes.Unknown,
+					"getMempool: failed to marshal txid, error: %s", err.Error())
 			}
 			// The "0" is because we only need the raw hex, which is returned as
 			// just a hex string, and not even a json string (with quotes).
 			params := []json.RawMessage{txidJSON, json.RawMessage("0")}
-			result, rpcErr := common.RawRequest(streamCtx, "getrawtransaction", params)
+			result, rpcErr := common.RawRequest(ctx, "getrawtransaction", params)
 			if rpcErr != nil {
 				// Not an error; mempool transactions can disappear
 				continue
@@ -806,34 +1583,38 @@ func (s *lwdStreamer) GetMempoolTx(exclude *walletrpc.GetMempoolTxRequest, resp
 			var txStr string
 			err = json.Unmarshal(result, &txStr)
 			if err != nil {
-				return status.Errorf(codes.Internal,
-					"GetMempoolTx: failed to unmarshal getrawtransaction reply, error: %s", err.Error())
+				return nil, nil, status.Errorf(codes.Internal,
+					"getMempool: failed to unmarshal getrawtransaction reply, error: %s", err.Error())
 			}
 
 			// convert to binary
 			txBytes, err := hex.DecodeString(txStr)
 			if err != nil {
-				return status.Errorf(codes.Internal,
-					"GetMempoolTx: failed decode getrawtransaction reply, error: %s", err.Error())
+				return nil, nil, status.Errorf(codes.Internal,
+					"getMempool: failed decode getrawtransaction reply, error: %s", err.Error())
 			}
 			tx := parser.NewTransaction()
 			txdata, err := tx.ParseFromSlice(txBytes)
 			if err != nil {
-				return status.Errorf(codes.Internal,
-					"GetMempoolTx: failed to parse getrawtransaction reply, error: %s", err.Error())
+				return nil, nil, status.Errorf(codes.Internal,
+					"getMempool: failed to parse getrawtransaction reply, error: %s", err.Error())
 			}
 			if len(txdata) > 0 {
-				return status.Error(codes.Internal,
-					"GetMempoolTx: extra data deserializing transaction")
+				return nil, nil, status.Error(codes.Internal,
+					"getMempool: extra data deserializing transaction")
 			}
 			txidBigEndian, err := hex.DecodeString(txidstr)
 			if err != nil {
-				return status.Errorf(codes.Internal,
-					"GetMempoolTx: failed decode txid, error: %s", err.Error())
+				return nil, nil, status.Errorf(codes.Internal,
+					"getMempool: failed decode txid, error: %s", err.Error())
 			}
 			// convert from big endian bytes to little endian and set as the txid
 			tx.SetTxID(hash32.Reverse(hash32.FromSlice(txidBigEndian)))
-			newmempoolMap[txidstr] = tx.ToCompact( /* height */ 0)
+			// ToCompact takes index 0 to be the coinbase, and drops its
+			// inputs; a mempool transaction is never one, and has no index.
+			compact := tx.ToCompact(1)
+			compact.Index = 0
+			newmempoolMap[txidstr] = compact
 		}
 		s.mutex.Lock()
 		mempoolList = newmempoolList
@@ -841,22 +1622,7 @@ func (s *lwdStreamer) GetMempoolTx(exclude *walletrpc.GetMempoolTxRequest, resp
 		s.mutex.Unlock()
 		cachedList, cachedMap = newmempoolList, newmempoolMap
 	}
-	for _, txid := range MempoolFilter(cachedList, excludeHex) {
-		ctx, ok := cachedMap[txid]
-		if !ok {
-			// The transaction was in getrawmempool's reply but its
-			// getrawtransaction failed (it may have been mined or evicted
-			// in between); it will be picked up on the next refresh.
-			continue
-		}
-		if ftx := common.FilterTxPool(ctx, exclude.PoolTypes); ftx != nil {
-			err := resp.Send(ftx)
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return cachedList, cachedMap, nil
 }
 
 // Return the subset of items that aren't excluded, but
@@ -898,33 +1664,80 @@ func MempoolFilter(items, exclude []string) []string {
 	return tosend
 }
 
-func getAddressUtxos(ctx context.Context, arg *walletrpc.GetAddressUtxosArg, f func(*walletrpc.GetAddressUtxosReply) error) error {
+// getAddressUtxos calls f with each of the unspent outputs of the addresses
+// that arg names, from the transparent address index if there is one, else
+// from the backend, and then, if arg.IncludeMempool is set, with those of
+// the mempool's transactions. If arg.MaxEntries limited the outputs, it
+// returns the cursor to resume after the last one.
+func (s *lwdStreamer) getAddressUtxos(ctx context.Context, arg *walletrpc.GetAddressUtxosArg, f func(*walletrpc.GetAddressUtxosReply) error) (*cursor, error) {
 	// GHSA-x4m7-3gpp-xc36
 	if len(arg.Addresses) > maxTaddrsPerRequest {
-		return status.Errorf(codes.ResourceExhausted,
+		return nil, status.Errorf(codes.ResourceExhausted,
 			"getAddressUtxos: too many addresses (limit %d)", maxTaddrsPerRequest)
 	}
 	// Eliminate duplicate addresses; returned UTXO set doesn't change.
-	addresses := make([]string, 0)
-	seen := make(map[string]struct{})
-	for _, a := range arg.Addresses {
-		if _, dup := seen[a]; dup {
-			continue
+	addresses, err := uniqueTransparentAddresses(s.chainName, arg.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeCursor(arg.Cursor)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "getAddressUtxos: %s", err.Error())
+	}
+	startHeight := arg.StartHeight
+	if after != nil {
+		startHeight = max(startHeight, after.height)
+	}
+	var utxos []*walletrpc.GetAddressUtxosReply
+	switch {
+	case startHeight == mempoolCursorHeight:
+		// The cursor is among the mempool's outputs, so no mined ones remain.
+	case s.taddrIndex() != nil:
+		utxos, err = getAddressUtxosFromIndex(s.taddrIndex(), s.chainName, addresses, startHeight)
+	default:
+		// With a cursor, some of the outputs at its height are skipped, and
+		// mempool spends may remove some, so more than MaxEntries may be needed.
+		var maxEntries uint32
+		if after == nil && !arg.IncludeMempool {
+			maxEntries = arg.MaxEntries
 		}
-		if err := checkTaddress(a); err != nil {
-			return err
+		utxos, err = getAddressUtxosFromBackend(ctx, addresses, startHeight, maxEntries)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if arg.IncludeMempool {
+		_, mempool, err := s.getMempool(ctx)
+		if err != nil {
+			return nil, err
+		}
+		utxos, err = withMempoolUtxos(s.chainName, addresses, utxos, mempool)
+		if err != nil {
+			return nil, err
+		}
+	}
+	page, next := pageUtxos(utxos, utxoCursor, after, arg.MaxEntries)
+	for _, utxo := range page {
+		if err := f(utxo); err != nil {
+			return nil, err
 		}
-		seen[a] = struct{}{}
-		addresses = append(addresses, a)
 	}
+	return next, nil
+}
+
+// getAddressUtxosFromBackend returns the unspent outputs of the (validated
+// and deduplicated) transparent addresses at or above startHeight, in
+// height order, using the backend's getaddressutxos; maxEntries (zero means
+// unlimited) is passed on as a hint.
+func getAddressUtxosFromBackend(ctx context.Context, addresses []string, startHeight uint64, maxEntries uint32) ([]*walletrpc.GetAddressUtxosReply, error) {
 	addrList := &common.ZcashdRpcRequestGetaddressutxos{
 		Addresses:   addresses,
-		StartHeight: arg.StartHeight,
-		MaxEntries:  arg.MaxEntries,
+		StartHeight: startHeight,
+		MaxEntries:  maxEntries,
 	}
 	param, err := json.Marshal(addrList)
 	if err != nil {
-		return status.Errorf(codes.Unknown,
+		return nil, status.Errorf(codes.Unknown,
 			"getAddressUtxos: failed to marshal addrList, error: %s", err.Error())
 	}
 	params := []json.RawMessage{param}
@@ -937,62 +1750,171 @@ func getAddressUtxos(ctx context.Context, arg *walletrpc.GetAddressUtxosArg, f f
 		case strings.Contains(rpcErr.Error(), "No information available"):
 			code = codes.NotFound
 		}
-		return status.Errorf(code,
+		return nil, status.Errorf(code,
 			"getAddressUtxos: getaddressutxos error: %s", rpcErr.Error())
 	}
 	var utxosReply []common.ZcashdRpcReplyGetaddressutxos
 	err = json.Unmarshal(result, &utxosReply)
 	if err != nil {
-		return status.Errorf(codes.InvalidArgument,
+		return nil, status.Errorf(codes.InvalidArgument,
 			"getAddressUtxos: failed to unmarshal getaddressutxos reply, error: %s", err.Error())
 	}
-	n := 0
+	utxos := make([]*walletrpc.GetAddressUtxosReply, 0, len(utxosReply))
 	for _, utxo := range utxosReply {
-		// Re-apply the limits; a backend that ignored them sent everything.
-		if uint64(utxo.Height) < arg.StartHeight {
+		// Re-apply the start height; a backend that ignored it sent everything.
+		if uint64(utxo.Height) < startHeight {
 			continue
 		}
-		n++
-		if arg.MaxEntries > 0 && uint32(n) > arg.MaxEntries {
-			break
-		}
-
-		txidBigEndian, err := hex.DecodeString(utxo.Txid)
+		txidBigEndian, err := hash32.Decode(utxo.Txid)
 		if err != nil {
-			return status.Errorf(codes.Internal,
+			return nil, status.Errorf(codes.Internal,
 				"getAddressUtxos: failed decode txid, error: %s", err.Error())
 		}
 		scriptBytes, err := hex.DecodeString(utxo.Script)
 		if err != nil {
-			return status.Errorf(codes.Internal,
+			return nil, status.Errorf(codes.Internal,
 				"getAddressUtxos: failed decode utxo script, error: %s", err.Error())
 		}
 		// When expressed as bytes, a txid must be little-endian.
-		err = f(&walletrpc.GetAddressUtxosReply{
+		utxos = append(utxos, &walletrpc.GetAddressUtxosReply{
 			Address:  utxo.Address,
-			Txid:     hash32.ReverseSlice(txidBigEndian),
+			Txid:     hash32.ToSlice(hash32.Reverse(txidBigEndian)),
 			Index:    int32(utxo.OutputIndex),
 			Script:   scriptBytes,
 			ValueZat: int64(utxo.Satoshis),
 			Height:   uint64(utxo.Height),
 		})
-		if err != nil {
-			return err
+	}
+	return utxos, nil
+}
+
+// getAddressUtxosFromIndex is getAddressUtxosFromBackend using the
+// transparent address index.
+func getAddressUtxosFromIndex(index *common.TaddrIndex, chainName string, addresses []string, startHeight uint64) ([]*walletrpc.GetAddressUtxosReply, error) {
+	addrs, err := indexAddresses(chainName, addresses)
+	if err != nil {
+		return nil, err
+	}
+	var utxos []*walletrpc.GetAddressUtxosReply
+	for _, utxo := range index.Utxos(addrs, int(min(startHeight, math.MaxInt32))) {
+		utxos = append(utxos, &walletrpc.GetAddressUtxosReply{
+			Address:  utxo.Address.Encode(chainName),
+			Txid:     hash32.ToSlice(utxo.Txid),
+			Index:    int32(utxo.Index),
+			Script:   utxo.Script,
+			ValueZat: int64(utxo.Value),
+			Height:   uint64(utxo.Height),
+		})
+	}
+	return utxos, nil
+}
+
+// withMempoolUtxos returns the mined UTXOs of the (validated and
+// deduplicated) transparent addresses less those that a transaction in the
+// mempool (keyed by big-endian txid) spends, followed by the unspent outputs
+// of mempool transactions that pay to the addresses, with height 0, in txid
+// and then output order.
+func withMempoolUtxos(chainName string, addresses []string, utxos []*walletrpc.GetAddressUtxosReply, mempool map[string]*walletrpc.CompactTx) ([]*walletrpc.GetAddressUtxosReply, error) {
+	addrs, err := indexAddresses(chainName, addresses)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[address.Transparent]bool, len(addrs))
+	for _, addr := range addrs {
+		wanted[addr] = true
+	}
+	type outpoint struct {
+		txid  hash32.T // little-endian
+		index uint32
+	}
+	spent := make(map[outpoint]bool)
+	for _, tx := range mempool {
+		for _, in := range tx.Vin {
+			if len(in.PrevoutTxid) == 32 {
+				spent[outpoint{hash32.FromSlice(in.PrevoutTxid), in.PrevoutIndex}] = true
+			}
 		}
 	}
-	return nil
+	// The mempool copy may be a little out of date, so it may still have a
+	// transaction that has since been mined.
+	mined := make(map[outpoint]bool, len(utxos))
+	for _, utxo := range utxos {
+		mined[outpoint{hash32.FromSlice(utxo.Txid), uint32(utxo.Index)}] = true
+	}
+	result := slices.DeleteFunc(utxos, func(utxo *walletrpc.GetAddressUtxosReply) bool {
+		return spent[outpoint{hash32.FromSlice(utxo.Txid), uint32(utxo.Index)}]
+	})
+	for _, txid := range slices.Sorted(maps.Keys(mempool)) {
+		tx := mempool[txid]
+		for i, out := range tx.Vout {
+			taddr, err := parser.ScriptAddress(out.ScriptPubKey)
+			if err != nil || !wanted[*taddr] {
+				continue
+			}
+			if o := (outpoint{hash32.FromSlice(tx.Txid), uint32(i)}); spent[o] || mined[o] {
+				continue
+			}
+			result = append(result, &walletrpc.GetAddressUtxosReply{
+				Address:  taddr.Encode(chainName),
+				Txid:     tx.Txid,
+				Index:    int32(i),
+				Script:   out.ScriptPubKey,
+				ValueZat: int64(out.Value),
+				Height:   0,
+			})
+		}
+	}
+	return result, nil
+}
+
+// utxoCursor returns the cursor that resumes after the given UTXO.
+func utxoCursor(utxo *walletrpc.GetAddressUtxosReply) cursor {
+	height := utxo.Height
+	if height == 0 {
+		height = mempoolCursorHeight
+	}
+	return cursor{height: height, txid: hash32.FromSlice(utxo.Txid), index: uint32(utxo.Index)}
+}
+
+// pageUtxos returns the part of a list of UTXOs (in result order, none below
+// the cursor's height) that a request resuming after the given cursor (nil if
+// none) returns, limited to maxEntries (zero means unlimited); key returns a
+// UTXO's cursor. If the part has maxEntries UTXOs, it also returns the cursor
+// to resume after the last one.
+func pageUtxos[T any](utxos []T, key func(T) cursor, after *cursor, maxEntries uint32) ([]T, *cursor) {
+	if after != nil {
+		for i, utxo := range utxos {
+			k := key(utxo)
+			if k.height > after.height {
+				break
+			}
+			if k == *after {
+				utxos = utxos[i+1:]
+				break
+			}
+		}
+	}
+	if maxEntries == 0 || uint32(len(utxos)) < maxEntries {
+		return utxos, nil
+	}
+	utxos = utxos[:maxEntries]
+	next := key(utxos[maxEntries-1])
+	return utxos, &next
 }
 
 func (s *lwdStreamer) GetAddressUtxos(ctx context.Context, arg *walletrpc.GetAddressUtxosArg) (*walletrpc.GetAddressUtxosReplyList, error) {
 	common.Log.Debugf("gRPC GetAddressUtxos(%+v)\n", arg)
 	addressUtxos := make([]*walletrpc.GetAddressUtxosReply, 0)
-	err := getAddressUtxos(ctx, arg, func(utxo *walletrpc.GetAddressUtxosReply) error {
+	next, err := s.getAddressUtxos(ctx, arg, func(utxo *walletrpc.GetAddressUtxosReply) error {
 		addressUtxos = append(addressUtxos, utxo)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	if next != nil {
+		grpc.SetTrailer(ctx, next.trailer())
+	}
 	r := &walletrpc.GetAddressUtxosReplyList{AddressUtxos: addressUtxos}
 	common.Log.Tracef("  return: %+v\n", r)
 	return r, nil
@@ -1074,15 +1996,64 @@ func (s *lwdStreamer) GetSubtreeRoots(arg *walletrpc.GetSubtreeRootsArg, resp wa
 
 func (s *lwdStreamer) GetAddressUtxosStream(arg *walletrpc.GetAddressUtxosArg, resp walletrpc.CompactTxStreamer_GetAddressUtxosStreamServer) error {
 	common.Log.Debugf("gRPC GetAddressUtxosStream(%+v)\n", arg)
-	err := getAddressUtxos(resp.Context(), arg, func(utxo *walletrpc.GetAddressUtxosReply) error {
+	next, err := s.getAddressUtxos(resp.Context(), arg, func(utxo *walletrpc.GetAddressUtxosReply) error {
 		return resp.Send(utxo)
 	})
 	if err != nil {
 		return err
 	}
+	if next != nil {
+		resp.SetTrailer(next.trailer())
+	}
 	return nil
 }
 
+// GetReorgHistory is a streaming RPC that returns the chain reorganizations
+// recorded in the reorg journal, oldest first.
+func (s *lwdStreamer) GetReorgHistory(arg *walletrpc.GetReorgHistoryArg, resp walletrpc.CompactTxStreamer_GetReorgHistoryServer) error {
+	common.Log.Debugf("gRPC GetReorgHistory(%+v)\n", arg)
+	if s.cache == nil {
+		return status.Error(codes.FailedPrecondition,
+			"GetReorgHistory: the reorg journal requires the block cache (lightwalletd is running with --nocache)")
+	}
+	var n uint32
+	for _, event := range s.cache.ReorgJournal().Events() {
+		if uint64(event.Time) < arg.StartTime {
+			continue
+		}
+		if arg.MaxEntries > 0 && n >= arg.MaxEntries {
+			break
+		}
+		n++
+		r := &walletrpc.ReorgEvent{
+			Time:           uint64(event.Time),
+			Depth:          uint32(event.Depth),
+			OrphanedBlocks: make([]*walletrpc.BlockID, len(event.Orphaned)),
+			NewBlocks:      make([]*walletrpc.BlockID, len(event.New)),
+		}
+		for i, b := range event.Orphaned {
+			r.OrphanedBlocks[i] = reorgBlockID(b)
+		}
+		for i, b := range event.New {
+			r.NewBlocks[i] = reorgBlockID(b)
+		}
+		if err := resp.Send(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reorgBlockID converts a reorg journal block to a BlockID, with the hash
+// in little-endian order (as in CompactBlock.Hash).
+func reorgBlockID(b common.ReorgBlock) *walletrpc.BlockID {
+	id := &walletrpc.BlockID{Height: uint64(b.Height)}
+	if hash, err := hash32.Decode(b.Hash); err == nil {
+		id.Hash = hash32.ToSlice(hash32.Reverse(hash))
+	}
+	return id
+}
+
 // This rpc is used only for testing.
 var concurrent int64
 
@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser/internal/bytestring"
@@ -27,6 +28,49 @@ func NewBlock() *Block {
 	return &Block{height: -1}
 }
 
+// NewBlockFromTransactions constructs a block from a header and
+// transactions, for tools that build blocks rather than parse them.
+func NewBlockFromTransactions(hdr *RawBlockHeader, vtx []*Transaction) *Block {
+	return &Block{
+		hdr:    &BlockHeader{RawBlockHeader: hdr},
+		vtx:    vtx,
+		height: -1,
+	}
+}
+
+// RawHeader returns a copy of the block's header fields, which can be
+// changed and passed to NewBlockFromTransactions to make a modified block.
+func (b *Block) RawHeader() RawBlockHeader {
+	return *b.hdr.RawBlockHeader
+}
+
+// VerifyProofOfWork returns an error if the block's Equihash solution isn't
+// valid, or its hash doesn't meet its target, or its target is easier than
+// the given proof-of-work limit.
+func (b *Block) VerifyProofOfWork(powLimit *big.Int) error {
+	if err := b.hdr.VerifyEquihash(); err != nil {
+		return err
+	}
+	return b.hdr.VerifyTarget(powLimit)
+}
+
+// VerifyMerkleRoot returns an error unless the header's hashMerkleRoot is
+// the root of the Merkle tree of the block's txids (see MerkleRoot). Txids
+// that were set with SetTxID, as from getblock, must match the computed ones.
+// (The hashFinalSaplingRoot / block commitments field isn't checked; that
+// would need the note commitment trees.)
+func (b *Block) VerifyMerkleRoot() error {
+	root, err := MerkleRoot(b.vtx)
+	if err != nil {
+		return fmt.Errorf("computing merkle root: %w", err)
+	}
+	if root != b.hdr.HashMerkleRoot {
+		return fmt.Errorf("merkle root %s doesn't match the header's hashMerkleRoot %s",
+			hash32.Encode(hash32.Reverse(root)), hash32.Encode(hash32.Reverse(b.hdr.HashMerkleRoot)))
+	}
+	return nil
+}
+
 // GetVersion returns a block's version number (current 4)
 func (b *Block) GetVersion() int {
 	return int(b.hdr.Version)
@@ -79,7 +123,10 @@ func (b *Block) GetHeight() int {
 	if b.height != -1 {
 		return b.height
 	}
-	coinbaseScript := bytestring.String(b.vtx[0].transparentInputs[0].ScriptSig)
+	if len(b.vtx) == 0 || len(b.vtx[0].TransparentInputs) == 0 {
+		return -1
+	}
+	coinbaseScript := bytestring.String(b.vtx[0].TransparentInputs[0].ScriptSig)
 	var heightNum int64
 	if !coinbaseScript.ReadScriptInt64(&heightNum) {
 		return -1
@@ -126,38 +173,61 @@ func (b *Block) ToCompact() *walletrpc.CompactBlock {
 
 // ParseFromSlice deserializes a block from the given data stream
 // and returns a slice to the remaining data. The caller should verify
-// there is no remaining data if none is expected.
+// there is no remaining data if none is expected. If the block can't be
+// parsed, the error is a *ParseError.
 func (b *Block) ParseFromSlice(data []byte) (rest []byte, err error) {
 	hdr := NewBlockHeader()
-	data, err = hdr.ParseFromSlice(data)
+	s := bytestring.String(data)
+	rest, err = hdr.ParseFromSlice(data)
 	if err != nil {
-		return nil, fmt.Errorf("parsing block header: %w", err)
+		return nil, nestedError(err, data, s, "header")
 	}
+	s = rest
 
-	s := bytestring.String(data)
 	var txCount int
 	if !s.ReadCompactSize(&txCount) {
-		return nil, errors.New("could not read tx_count")
+		return nil, fieldError(data, s, "vtx", errors.New("could not read tx_count"))
 	}
 	if err := rejectCountExceedingRemaining("tx_count", txCount, len(s), minTransactionWireBytes); err != nil {
-		return nil, err
+		return nil, fieldError(data, s, "vtx", err)
 	}
-	data = []byte(s)
 
 	vtx := make([]*Transaction, 0, txCount)
 	var i int
-	for i = 0; i < txCount && len(data) > 0; i++ {
+	for i = 0; i < txCount && len(s) > 0; i++ {
 		tx := NewTransaction()
-		data, err = tx.ParseFromSlice(data)
+		rest, err = tx.ParseFromSlice([]byte(s))
 		if err != nil {
-			return nil, fmt.Errorf("error parsing transaction %d: %w", i, err)
+			return nil, nestedError(err, data, s, fmt.Sprintf("vtx[%d]", i))
 		}
+		s = rest
 		vtx = append(vtx, tx)
 	}
 	if i < txCount {
-		return nil, errors.New("parsing block transactions: not enough data")
+		return nil, fieldError(data, s, fmt.Sprintf("vtx[%d]", i),
+			errors.New("parsing block transactions: not enough data"))
 	}
 	b.hdr = hdr
 	b.vtx = vtx
-	return data, nil
+	return []byte(s), nil
+}
+
+// MarshalBinary returns the block in serialized form; it's the inverse of
+// ParseFromSlice.
+func (b *Block) MarshalBinary() ([]byte, error) {
+	hdr, err := b.hdr.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var s bytestring.Builder
+	s.AddBytes(hdr)
+	s.AddCompactSize(len(b.vtx))
+	for i, tx := range b.vtx {
+		txBytes, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("error serializing transaction %d: %w", i, err)
+		}
+		s.AddBytes(txBytes)
+	}
+	return s.Bytes(), nil
 }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser/internal/bytestring"
+	"github.com/zcash/lightwalletd/parser/internal/equihash"
 )
 
 const (
@@ -136,62 +137,63 @@ func NewBlockHeader() *BlockHeader {
 
 // ParseFromSlice parses the block header struct from the provided byte slice,
 // advancing over the bytes read. If successful it returns the rest of the
-// slice, otherwise it returns the input slice unaltered along with an error.
+// slice, otherwise it returns the input slice unaltered along with a
+// *ParseError.
 func (hdr *BlockHeader) ParseFromSlice(in []byte) (rest []byte, err error) {
 	s := bytestring.String(in)
 
 	// Primary parsing layer: sort the bytes into things
 
 	if !s.ReadInt32(&hdr.Version) {
-		return in, errors.New("could not read header version")
+		return in, fieldError(in, s, "version", errors.New("could not read header version"))
 	}
 
 	b32 := make([]byte, 32)
 	if !s.ReadBytes(&b32, 32) {
-		return in, errors.New("could not read HashPrevBlock")
+		return in, fieldError(in, s, "hashPrevBlock", errors.New("could not read HashPrevBlock"))
 	}
 	hdr.HashPrevBlock = hash32.FromSlice(b32)
 
 	if !s.ReadBytes(&b32, 32) {
-		return in, errors.New("could not read HashMerkleRoot")
+		return in, fieldError(in, s, "hashMerkleRoot", errors.New("could not read HashMerkleRoot"))
 	}
 	hdr.HashMerkleRoot = hash32.FromSlice(b32)
 
 	if !s.ReadBytes(&b32, 32) {
-		return in, errors.New("could not read HashFinalSaplingRoot")
+		return in, fieldError(in, s, "hashFinalSaplingRoot", errors.New("could not read HashFinalSaplingRoot"))
 	}
 	hdr.HashFinalSaplingRoot = hash32.FromSlice(b32)
 
 	if !s.ReadUint32(&hdr.Time) {
-		return in, errors.New("could not read timestamp")
+		return in, fieldError(in, s, "time", errors.New("could not read timestamp"))
 	}
 
 	b4 := make([]byte, 4)
 	if !s.ReadBytes(&b4, 4) {
-		return in, errors.New("could not read NBits bytes")
+		return in, fieldError(in, s, "nBits", errors.New("could not read NBits bytes"))
 	}
 	hdr.NBitsBytes = [4]byte(b4)
 
 	if !s.ReadBytes(&b32, 32) {
-		return in, errors.New("could not read Nonce bytes")
+		return in, fieldError(in, s, "nonce", errors.New("could not read Nonce bytes"))
 	}
 	hdr.Nonce = hash32.FromSlice(b32)
 
 	{
 		var length int
 		if !s.ReadCompactSize(&length) {
-			return in, errors.New("could not read compact size of solution")
+			return in, fieldError(in, s, "solution", errors.New("could not read compact size of solution"))
 		}
 		// Check before allocating: length is a byte count bounded only by
 		// maxCompactSize, so a truncated header could otherwise size a 32MB
 		// slice that the ReadBytes below immediately fails to fill.
 		if length > len(s) {
-			return in, fmt.Errorf("solution_length %d exceeds remaining input length %d",
-				length, len(s))
+			return in, fieldError(in, s, "solution", fmt.Errorf("solution_length %d exceeds remaining input length %d",
+				length, len(s)))
 		}
 		hdr.Solution = make([]byte, length)
 		if !s.ReadBytes(&hdr.Solution, length) {
-			return in, errors.New("could not read CompactSize-prefixed Equihash solution")
+			return in, fieldError(in, s, "solution", errors.New("could not read CompactSize-prefixed Equihash solution"))
 		}
 	}
 
@@ -220,6 +222,42 @@ func parseNBits(b []byte) *big.Int {
 	return new(big.Int).SetBytes(targetBytes)
 }
 
+// VerifyEquihash returns an error if the header's Equihash solution isn't
+// valid. The Equihash parameters are those whose solutions are the size of
+// this one: (200, 9) on mainnet and testnet, (48, 5) on regtest.
+func (hdr *BlockHeader) VerifyEquihash() error {
+	params, ok := equihash.ParamsForSolution(len(hdr.Solution))
+	if !ok {
+		return fmt.Errorf("no Equihash parameters have %d-byte solutions", len(hdr.Solution))
+	}
+	serializedHeader, err := hdr.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return equihash.Verify(params, serializedHeader[:serBlockHeaderMinusEquihashSize], hdr.Solution)
+}
+
+// VerifyTarget returns an error unless the header's hash is no greater than
+// the target its nBits field encodes, and that target is no easier than
+// powLimit, the network's proof-of-work limit. (It doesn't check that the
+// target is the one the difficulty adjustment requires.)
+func (hdr *BlockHeader) VerifyTarget(powLimit *big.Int) error {
+	// nBits is a little-endian uint32; parseNBits wants it big-endian.
+	nbits := hdr.NBitsBytes
+	target := parseNBits([]byte{nbits[3], nbits[2], nbits[1], nbits[0]})
+	if target.Sign() <= 0 {
+		return fmt.Errorf("nBits %08x encodes a target that isn't positive", binary.LittleEndian.Uint32(nbits[:]))
+	}
+	if target.Cmp(powLimit) > 0 {
+		return fmt.Errorf("nBits %08x encodes a target above the proof-of-work limit", binary.LittleEndian.Uint32(nbits[:]))
+	}
+	hash := hdr.GetDisplayHash()
+	if new(big.Int).SetBytes(hash[:]).Cmp(target) > 0 {
+		return fmt.Errorf("block hash %x is above the target %064x", hash, target)
+	}
+	return nil
+}
+
 // GetDisplayHash returns the bytes of a block hash in big-endian order.
 func (hdr *BlockHeader) GetDisplayHash() hash32.T {
 	if hdr.cachedHash != hash32.Nil {
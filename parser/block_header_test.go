@@ -278,3 +278,46 @@ func TestWriteCompactLengthPrefixed(t *testing.T) {
 		t.Fatal("TestWriteCompactLengthPrefixed incorrect result")
 	}
 }
+
+func TestVerifyProofOfWork(t *testing.T) {
+	testnetPowLimit, _ := new(big.Int).SetString("07ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 16)
+	testBlocks, err := os.ReadFile("../testdata/blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blockHex := range strings.Fields(string(testBlocks)) {
+		blockData, err := hex.DecodeString(blockHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := NewBlock()
+		if _, err := block.ParseFromSlice(blockData); err != nil {
+			t.Fatal(err)
+		}
+		if err := block.VerifyProofOfWork(testnetPowLimit); err != nil {
+			t.Fatalf("block %d: %v", block.GetHeight(), err)
+		}
+
+		// A lower proof-of-work limit rejects the block's target.
+		if err := block.VerifyProofOfWork(big.NewInt(1)); err == nil {
+			t.Fatalf("block %d: target above the limit accepted", block.GetHeight())
+		}
+
+		// Changing the nonce invalidates the solution.
+		hdr := block.RawHeader()
+		hdr.Nonce[0]++
+		modified := &BlockHeader{RawBlockHeader: &hdr}
+		if err := modified.VerifyEquihash(); err == nil {
+			t.Fatalf("block %d: modified header's solution accepted", block.GetHeight())
+		}
+
+		// So does changing the solution.
+		hdr = block.RawHeader()
+		hdr.Solution = bytes.Clone(hdr.Solution)
+		hdr.Solution[100] ^= 0x10
+		modified = &BlockHeader{RawBlockHeader: &hdr}
+		if err := modified.VerifyEquihash(); err == nil {
+			t.Fatalf("block %d: modified solution accepted", block.GetHeight())
+		}
+	}
+}
@@ -4,8 +4,10 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -93,3 +95,169 @@ func TestParseBlockRejectsTransactionCountThatCannotFit(t *testing.T) {
 		t.Fatalf("error mismatch:\nhave: %v\nwant substring: %s", err, wantErr)
 	}
 }
+
+func TestBlockMarshalBinary(t *testing.T) {
+	var blocks [][]byte
+	testBlocks, err := os.ReadFile("../testdata/blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blockHex := range strings.Fields(string(testBlocks)) {
+		blockData, err := hex.DecodeString(blockHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, blockData)
+	}
+	var compactTests []struct {
+		Full string `json:"full"`
+	}
+	blockJSON, err := os.ReadFile("../testdata/compact_blocks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(blockJSON, &compactTests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range compactTests {
+		blockData, err := hex.DecodeString(test.Full)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, blockData)
+	}
+
+	for i, blockData := range blocks {
+		block := NewBlock()
+		if _, err := block.ParseFromSlice(blockData); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+		marshaled, err := block.MarshalBinary()
+		if err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+		if !bytes.Equal(marshaled, blockData) {
+			t.Fatalf("block %d (height %d) doesn't round-trip", i, block.GetHeight())
+		}
+	}
+}
+
+func TestNewBlockFromTransactions(t *testing.T) {
+	var compactTests []struct {
+		Full string `json:"full"`
+	}
+	blockJSON, err := os.ReadFile("../testdata/compact_blocks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(blockJSON, &compactTests); err != nil {
+		t.Fatal(err)
+	}
+	blockData, _ := hex.DecodeString(compactTests[0].Full)
+	block := NewBlock()
+	if _, err := block.ParseFromSlice(blockData); err != nil {
+		t.Fatal(err)
+	}
+
+	// Build a modified copy: a different previous block, and the coinbase
+	// transaction twice.
+	hdr := block.RawHeader()
+	hdr.HashPrevBlock[0]++
+	vtx := append([]*Transaction{}, block.Transactions()...)
+	vtx = append(vtx, vtx[0])
+	modified := NewBlockFromTransactions(&hdr, vtx)
+	if modified.GetHeight() != block.GetHeight() {
+		t.Fatalf("height %d, expected %d", modified.GetHeight(), block.GetHeight())
+	}
+	if block.hdr.HashPrevBlock == hdr.HashPrevBlock {
+		t.Fatal("RawHeader didn't return a copy")
+	}
+	modifiedData, err := modified.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsed := NewBlock()
+	rest, err := reparsed.ParseFromSlice(modifiedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Fatal("extra data remaining")
+	}
+	if reparsed.GetTxCount() != block.GetTxCount()+1 {
+		t.Fatalf("tx count %d, expected %d", reparsed.GetTxCount(), block.GetTxCount()+1)
+	}
+	if reparsed.GetPrevHash() != hdr.HashPrevBlock {
+		t.Fatal("prevhash not modified")
+	}
+	if reparsed.GetDisplayHash() == block.GetDisplayHash() {
+		t.Fatal("block hash not changed")
+	}
+}
+
+func TestBlockParseError(t *testing.T) {
+	testBlocks, err := os.ReadFile("../testdata/blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Block 380643; its second transaction has two transparent outputs.
+	blockData, err := hex.DecodeString(strings.Fields(string(testBlocks))[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := NewBlock()
+	if _, err := block.ParseFromSlice(blockData); err != nil {
+		t.Fatal(err)
+	}
+	tx := block.Transactions()[1]
+	txOffset := len(blockData) - len(tx.Bytes())
+	script := tx.TransparentOutputs[1].Script
+	scriptOffset := txOffset + bytes.LastIndex(tx.Bytes(), script) - 1 // the length byte
+	countOffset := txOffset - len(block.Transactions()[0].Bytes()) - 1
+
+	tests := []struct {
+		name    string
+		length  int
+		wantErr ParseError
+	}{
+		{
+			name:    "header",
+			length:  102,
+			wantErr: ParseError{Offset: 100, Field: "header.time"},
+		},
+		{
+			name:    "transaction count",
+			length:  countOffset,
+			wantErr: ParseError{Offset: countOffset, Field: "vtx"},
+		},
+		{
+			name:   "output script",
+			length: scriptOffset + len(script),
+			wantErr: ParseError{
+				Offset:  scriptOffset,
+				Field:   "vtx[1].vout[1].script",
+				Version: 4,
+				Pool:    "transparent",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBlock().ParseFromSlice(blockData[:tt.length])
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Offset != tt.wantErr.Offset || parseErr.Field != tt.wantErr.Field ||
+				parseErr.Version != tt.wantErr.Version || parseErr.Pool != tt.wantErr.Pool {
+				t.Fatalf("have: %+v\nwant: %+v", *parseErr, tt.wantErr)
+			}
+		})
+	}
+	_, err = NewBlock().ParseFromSlice(blockData[:scriptOffset+len(script)])
+	wantErr := fmt.Sprintf("parsing vtx[1].vout[1].script at offset %d (v4 transaction, transparent): could not read txOut script",
+		scriptOffset)
+	if err.Error() != wantErr {
+		t.Fatalf("error mismatch:\nhave: %v\nwant: %s", err, wantErr)
+	}
+}
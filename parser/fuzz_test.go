@@ -0,0 +1,149 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package parser
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// These fuzz targets run on their seed corpora as part of go test; to fuzz,
+// run for example
+//
+//	go test ./parser -run '^$' -fuzz FuzzBlockParseFromSlice
+
+// testBlocks returns the blocks in testdata/blocks and testdata/corpus.
+func testBlocks(t testing.TB) [][]byte {
+	blocks := readHexVectors(t, "../testdata/blocks")
+	names, err := filepath.Glob("../testdata/corpus/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		contents, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blockData, err := hex.DecodeString(strings.TrimSpace(string(contents)))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		blocks = append(blocks, blockData)
+	}
+	return blocks
+}
+
+// requireParseError fails the test unless err is a *ParseError whose offset
+// is within the data.
+func requireParseError(t *testing.T, err error, data []byte) {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("error %q (%T) isn't a *ParseError", err, err)
+	}
+	if parseErr.Offset < 0 || parseErr.Offset > len(data) {
+		t.Fatalf("error %q offset is outside the %d bytes of data", err, len(data))
+	}
+}
+
+// requireRoundTrip fails the test unless marshaled is the parsed prefix of
+// data (the part before rest).
+func requireRoundTrip(t *testing.T, data, rest, marshaled []byte, err error) {
+	if err != nil {
+		t.Fatal("parsed data doesn't marshal:", err)
+	}
+	if parsed := data[:len(data)-len(rest)]; !bytes.Equal(marshaled, parsed) {
+		t.Fatalf("doesn't round-trip:\nhave: %x\nwant: %x", marshaled, parsed)
+	}
+}
+
+func FuzzBlockParseFromSlice(f *testing.F) {
+	for _, blockData := range testBlocks(f) {
+		f.Add(blockData)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		block := NewBlock()
+		rest, err := block.ParseFromSlice(data)
+		if err != nil {
+			requireParseError(t, err, data)
+			return
+		}
+		marshaled, err := block.MarshalBinary()
+		requireRoundTrip(t, data, rest, marshaled, err)
+
+		compact := block.ToCompact()
+		if len(compact.Vtx) != block.GetTxCount() {
+			t.Fatalf("compact block has %d transactions, want %d", len(compact.Vtx), block.GetTxCount())
+		}
+		if height := block.GetHeight(); height >= 0 && compact.Height != uint64(height) {
+			t.Fatalf("compact block height %d, want %d", compact.Height, height)
+		}
+		for i, ctx := range compact.Vtx {
+			tx := block.Transactions()[i]
+			if len(ctx.Outputs) != len(tx.SaplingOutputs) || len(ctx.Actions) != tx.OrchardActionsCount() ||
+				len(ctx.IronwoodActions) != tx.IronwoodActionsCount() || len(ctx.Vout) != len(tx.TransparentOutputs) {
+				t.Fatalf("compact transaction %d doesn't match the transaction", i)
+			}
+		}
+		block.VerifyMerkleRoot()
+	})
+}
+
+func FuzzBlockHeaderParseFromSlice(f *testing.F) {
+	for _, blockData := range testBlocks(f) {
+		f.Add(blockData)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		hdr := NewBlockHeader()
+		rest, err := hdr.ParseFromSlice(data)
+		if err != nil {
+			requireParseError(t, err, data)
+			return
+		}
+		marshaled, err := hdr.MarshalBinary()
+		requireRoundTrip(t, data, rest, marshaled, err)
+		hdr.GetDisplayHash()
+		hdr.VerifyEquihash()
+	})
+}
+
+func FuzzTransactionParseFromSlice(f *testing.F) {
+	for _, txData := range testTransactions(f) {
+		f.Add(txData)
+	}
+	// Versions 1 and 2 (the test vectors' oldest are version 3).
+	for _, version := range []uint32{1, 2} {
+		tx := NewTransaction()
+		tx.Version = version
+		tx.TransparentInputs = []TxIn{{PrevTxOutIndex: 1, ScriptSig: HexBytes{0x51}}}
+		tx.TransparentOutputs = []TxOut{{Value: 1, Script: HexBytes{0x6a}}}
+		f.Add(tx.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := NewTransaction()
+		rest, err := tx.ParseFromSlice(data)
+		if err != nil {
+			requireParseError(t, err, data)
+			return
+		}
+		marshaled, err := tx.MarshalBinary()
+		requireRoundTrip(t, data, rest, marshaled, err)
+
+		compact := tx.ToCompact(0)
+		if len(compact.Spends) != len(tx.SaplingSpends) || len(compact.Outputs) != len(tx.SaplingOutputs) ||
+			len(compact.Actions) != tx.OrchardActionsCount() || len(compact.IronwoodActions) != tx.IronwoodActionsCount() ||
+			len(compact.Vout) != len(tx.TransparentOutputs) {
+			t.Fatal("compact transaction doesn't match the transaction")
+		}
+		if _, err := json.Marshal(tx); err != nil {
+			t.Fatal(err)
+		}
+		tx.ComputeTxID()
+	})
+}
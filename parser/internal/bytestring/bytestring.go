@@ -90,10 +90,12 @@ func (s *String) ReadBytes(out *[]byte, n int) bool {
 
 // ReadCompactSize reads and interprets a Bitcoin-custom compact integer
 // encoding used for length-prefixing and count values. If the values fall
-// outside the expected canonical ranges, it returns false.
+// outside the expected canonical ranges, it returns false without advancing
+// the string.
 func (s *String) ReadCompactSize(size *int) bool {
 	*size = 0
-	lenBytes := s.read(1)
+	t := *s
+	lenBytes := t.read(1)
 	if lenBytes == nil {
 		return false
 	}
@@ -120,7 +122,7 @@ func (s *String) ReadCompactSize(size *int) bool {
 
 	if lenLen > 0 {
 		// expect little endian uint of varying size
-		lenBytes := s.read(lenLen)
+		lenBytes := t.read(lenLen)
 		if len(lenBytes) < lenLen {
 			return false
 		}
@@ -134,23 +136,27 @@ func (s *String) ReadCompactSize(size *int) bool {
 		return false
 	}
 	*size = int(length)
+	*s = t
 	return true
 }
 
 // ReadCompactLengthPrefixed reads data prefixed by a CompactSize-encoded
-// length field into out. It reports whether the read was successful.
+// length field into out. It reports whether the read was successful; if not,
+// the string isn't advanced.
 func (s *String) ReadCompactLengthPrefixed(out *String) bool {
+	t := *s
 	var length int
-	if !s.ReadCompactSize(&length) {
+	if !t.ReadCompactSize(&length) {
 		return false
 	}
 
-	v := s.read(length)
+	v := t.read(length)
 	if v == nil {
 		return false
 	}
 
 	*out = v
+	*s = t
 	return true
 }
 
@@ -276,3 +282,75 @@ func (s *String) ReadScriptInt64(num *int64) bool {
 	*num = int64(number)
 	return true
 }
+
+// Builder is the writer counterpart of String: it appends values to a byte
+// string in the encodings that String's methods read.
+type Builder struct {
+	buf []byte
+}
+
+// Bytes returns the bytes written so far.
+func (b *Builder) Bytes() []byte {
+	return b.buf
+}
+
+// AddUint8 appends a single byte.
+func (b *Builder) AddUint8(v uint8) {
+	b.buf = append(b.buf, v)
+}
+
+// AddBytes appends the given bytes as they are.
+func (b *Builder) AddBytes(v []byte) {
+	b.buf = append(b.buf, v...)
+}
+
+// AddUint16 appends a little-endian 16-bit value.
+func (b *Builder) AddUint16(v uint16) {
+	b.buf = append(b.buf, byte(v), byte(v>>8))
+}
+
+// AddUint32 appends a little-endian 32-bit value.
+func (b *Builder) AddUint32(v uint32) {
+	b.buf = append(b.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// AddUint64 appends a little-endian 64-bit value.
+func (b *Builder) AddUint64(v uint64) {
+	b.AddUint32(uint32(v))
+	b.AddUint32(uint32(v >> 32))
+}
+
+// AddInt32 appends a little-endian 32-bit value in two's complement.
+func (b *Builder) AddInt32(v int32) {
+	b.AddUint32(uint32(v))
+}
+
+// AddInt64 appends a little-endian 64-bit value in two's complement.
+func (b *Builder) AddInt64(v int64) {
+	b.AddUint64(uint64(v))
+}
+
+// AddCompactSize appends size in the canonical (shortest) CompactSize
+// encoding, the one ReadCompactSize requires.
+func (b *Builder) AddCompactSize(size int) {
+	switch {
+	case size < 253:
+		b.AddUint8(uint8(size))
+	case size <= 0xffff:
+		b.AddUint8(253)
+		b.AddUint16(uint16(size))
+	case uint64(size) <= 0xffffffff:
+		b.AddUint8(254)
+		b.AddUint32(uint32(size))
+	default:
+		b.AddUint8(255)
+		b.AddUint64(uint64(size))
+	}
+}
+
+// AddCompactLengthPrefixed appends v prefixed by its CompactSize-encoded
+// length.
+func (b *Builder) AddCompactLengthPrefixed(v []byte) {
+	b.AddCompactSize(len(v))
+	b.AddBytes(v)
+}
@@ -219,6 +219,9 @@ func TestString_ReadCompactSize(t *testing.T) {
 		if expected != tt.expected {
 			t.Errorf("ReadCompactSize case %d: want: %v, have: %v", i, tt.expected, expected)
 		}
+		if !ok && len(tt.s) != len(readCompactSizeTests[i].s) {
+			t.Errorf("ReadCompactSize case %d: advanced on failure", i)
+		}
 	}
 }
 
@@ -259,6 +262,9 @@ func TestString_ReadCompactLengthPrefixed(t *testing.T) {
 	if s.ReadCompactLengthPrefixed(&v) {
 		t.Fatalf("ReadCompactLengthPrefix unexpected success")
 	}
+	if len(s) != 3 {
+		t.Fatalf("ReadCompactLengthPrefix advanced on failure")
+	}
 }
 
 func TestString_SkipCompactLengthPrefixed(t *testing.T) {
@@ -587,3 +593,50 @@ func TestString_ReadScriptInt64(t *testing.T) {
 		}
 	}
 }
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	b.AddUint8(0x01)
+	b.AddUint16(0x0302)
+	b.AddUint32(0x07060504)
+	b.AddUint64(0x0f0e0d0c0b0a0908)
+	b.AddInt32(-2)
+	b.AddInt64(-3)
+	b.AddBytes([]byte{0xaa, 0xbb})
+	b.AddCompactLengthPrefixed([]byte{0xcc})
+	expected := []byte{
+		0x01,
+		0x02, 0x03,
+		0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0xfe, 0xff, 0xff, 0xff,
+		0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xaa, 0xbb,
+		0x01, 0xcc,
+	}
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Fatalf("Builder: want: %x, have: %x", expected, b.Bytes())
+	}
+}
+
+func TestBuilder_AddCompactSize(t *testing.T) {
+	for i, tt := range readCompactSizeTests {
+		if !tt.ok {
+			continue
+		}
+		var b Builder
+		b.AddCompactSize(tt.expected)
+		if !bytes.Equal(b.Bytes(), tt.s) {
+			t.Errorf("AddCompactSize case %d: want: %x, have: %x", i, []byte(tt.s), b.Bytes())
+		}
+	}
+	for _, size := range []int{0, 252, 253, 0xffff, 0x10000, int(maxCompactSize)} {
+		var b Builder
+		b.AddCompactSize(size)
+		s := String(b.Bytes())
+		var v int
+		if !s.ReadCompactSize(&v) || v != size {
+			t.Errorf("AddCompactSize(%d) encoded as %x, not read back", size, b.Bytes())
+		}
+	}
+}
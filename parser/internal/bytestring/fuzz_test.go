@@ -0,0 +1,62 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package bytestring
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReadCompactSize(f *testing.F) {
+	for _, seed := range [][]byte{
+		{}, {0x00}, {0xfc}, {0xfd, 0xfd, 0x00}, {0xfd, 0xfc, 0x00},
+		{0xfe, 0x00, 0x00, 0x01, 0x00}, {0xfe, 0xff, 0xff, 0x00, 0x00},
+		{0xff, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00},
+		{0x03, 0x01, 0x02, 0x03, 0x04},
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// A size that can be read is canonically encoded, so it
+		// round-trips; one that can't leaves the string unchanged.
+		s := String(data)
+		var size int
+		if s.ReadCompactSize(&size) {
+			var b Builder
+			b.AddCompactSize(size)
+			if !bytes.Equal(b.Bytes(), data[:len(data)-len(s)]) {
+				t.Fatalf("compact size %d doesn't round-trip", size)
+			}
+		} else if len(s) != len(data) {
+			t.Fatal("failed ReadCompactSize advanced the string")
+		}
+
+		s = String(data)
+		var v String
+		if s.ReadCompactLengthPrefixed(&v) {
+			var b Builder
+			b.AddCompactLengthPrefixed(v)
+			if !bytes.Equal(b.Bytes(), data[:len(data)-len(s)]) {
+				t.Fatal("length-prefixed string doesn't round-trip")
+			}
+		} else if len(s) != len(data) {
+			t.Fatal("failed ReadCompactLengthPrefixed advanced the string")
+		}
+	})
+}
+
+func FuzzReadScriptInt64(f *testing.F) {
+	for _, seed := range [][]byte{
+		{}, {op0}, {op1Negate}, {op16}, {0x03, 0xd1, 0x2c, 0x0c}, {0x09, 0x01},
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s := String(data)
+		var num int64
+		if s.ReadScriptInt64(&num) && len(s) >= len(data) {
+			t.Fatal("ReadScriptInt64 succeeded without reading")
+		}
+	})
+}
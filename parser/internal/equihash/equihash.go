@@ -0,0 +1,151 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+// Package equihash verifies Equihash proof-of-work solutions, as specified
+// in section 7.7.1 of the Zcash protocol spec and implemented by zcashd's
+// Equihash<N,K>::IsValidSolution.
+package equihash
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/zcash/lightwalletd/internal/blake2b"
+)
+
+// Params are the Equihash parameters n and k.
+type Params struct {
+	N, K int
+}
+
+// Known parameter sets: mainnet and testnet use (200, 9), regtest uses
+// (48, 5), and (144, 5) is defined by the spec for other chains.
+var (
+	Params200_9 = Params{200, 9}
+	Params144_5 = Params{144, 5}
+	Params48_5  = Params{48, 5}
+)
+
+// ParamsForSolution returns the known parameters whose solutions are the
+// given number of bytes.
+func ParamsForSolution(size int) (Params, bool) {
+	for _, p := range []Params{Params200_9, Params144_5, Params48_5} {
+		if p.SolutionSize() == size {
+			return p, true
+		}
+	}
+	return Params{}, false
+}
+
+func (p Params) collisionBits() int {
+	return p.N / (p.K + 1)
+}
+
+func (p Params) indicesPerHash() int {
+	return 512 / p.N
+}
+
+// SolutionSize returns the size in bytes of a solution: 2^k indices of
+// n/(k+1)+1 bits each.
+func (p Params) SolutionSize() int {
+	return (1 << p.K) * (p.collisionBits() + 1) / 8
+}
+
+// hashes computes the n-bit hash for each index.
+func (p Params) hashes(input []byte, indices []uint32) [][]byte {
+	var personal [16]byte
+	copy(personal[:], "ZcashPoW")
+	binary.LittleEndian.PutUint32(personal[8:], uint32(p.N))
+	binary.LittleEndian.PutUint32(personal[12:], uint32(p.K))
+	hashBytes := p.N / 8
+	data := make([]byte, len(input)+4)
+	copy(data, input)
+	out := make([][]byte, len(indices))
+	for i, index := range indices {
+		binary.LittleEndian.PutUint32(data[len(input):], index/uint32(p.indicesPerHash()))
+		digest := blake2b.Sum(p.indicesPerHash()*hashBytes, personal, data)
+		start := int(index%uint32(p.indicesPerHash())) * hashBytes
+		out[i] = digest[start : start+hashBytes]
+	}
+	return out
+}
+
+// indices unpacks the solution's big-endian (collisionBits+1)-bit indices.
+func (p Params) indices(solution []byte) []uint32 {
+	width := p.collisionBits() + 1
+	indices := make([]uint32, 1<<p.K)
+	bit := 0
+	for i := range indices {
+		for j := 0; j < width; j++ {
+			b := (solution[bit/8] >> (7 - bit%8)) & 1
+			indices[i] = indices[i]<<1 | uint32(b)
+			bit++
+		}
+	}
+	return indices
+}
+
+// leadingZeroBits reports whether the first n bits of x are zero.
+func leadingZeroBits(x []byte, n int) bool {
+	for i := 0; i < n/8; i++ {
+		if x[i] != 0 {
+			return false
+		}
+	}
+	return n%8 == 0 || x[n/8]>>(8-n%8) == 0
+}
+
+// Verify returns an error unless solution is a valid Equihash solution for
+// the given input (for a block header, its serialization up to and
+// including the nonce).
+func Verify(p Params, input []byte, solution []byte) error {
+	if len(solution) != p.SolutionSize() {
+		return fmt.Errorf("equihash (%d, %d) solution is %d bytes, must be %d",
+			p.N, p.K, len(solution), p.SolutionSize())
+	}
+	indices := p.indices(solution)
+	seen := make(map[uint32]bool, len(indices))
+	for _, index := range indices {
+		if seen[index] {
+			return errors.New("equihash solution has duplicate indices")
+		}
+		seen[index] = true
+	}
+
+	// Each row is the XOR of the hashes of a subtree's indices, and the
+	// subtree's first index. Combining two subtrees at level r requires their
+	// hashes to collide in the first r*n/(k+1) bits, and the left subtree's
+	// indices to come first.
+	type row struct {
+		hash  []byte
+		first uint32
+	}
+	rows := make([]row, len(indices))
+	for i, h := range p.hashes(input, indices) {
+		rows[i] = row{h, indices[i]}
+	}
+	for r := 1; len(rows) > 1; r++ {
+		next := make([]row, len(rows)/2)
+		for i := range next {
+			left, right := rows[2*i], rows[2*i+1]
+			x := make([]byte, len(left.hash))
+			for j := range x {
+				x[j] = left.hash[j] ^ right.hash[j]
+			}
+			if !leadingZeroBits(x, r*p.collisionBits()) {
+				return fmt.Errorf("equihash solution has no collision at level %d", r)
+			}
+			if right.first < left.first {
+				return fmt.Errorf("equihash solution indices out of order at level %d", r)
+			}
+			next[i] = row{x, left.first}
+		}
+		rows = next
+	}
+	if !leadingZeroBits(rows[0].hash, p.N) {
+		return errors.New("equihash solution hashes don't XOR to zero")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package equihash
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSolutionSize(t *testing.T) {
+	for _, tt := range []struct {
+		p    Params
+		size int
+	}{
+		{Params200_9, 1344},
+		{Params144_5, 100},
+		{Params48_5, 36},
+	} {
+		if got := tt.p.SolutionSize(); got != tt.size {
+			t.Errorf("%v: SolutionSize() = %d, want %d", tt.p, got, tt.size)
+		}
+		if p, ok := ParamsForSolution(tt.size); !ok || p != tt.p {
+			t.Errorf("ParamsForSolution(%d) = %v, %v", tt.size, p, ok)
+		}
+	}
+	if _, ok := ParamsForSolution(1000); ok {
+		t.Error("ParamsForSolution(1000) succeeded")
+	}
+}
+
+// solve finds solutions using Wagner's algorithm; it's only practical for
+// small parameters.
+func solve(p Params, input []byte) [][]uint32 {
+	type row struct {
+		hash    []byte
+		indices []uint32
+	}
+	initial := make([]uint32, 1<<(p.collisionBits()+1))
+	for i := range initial {
+		initial[i] = uint32(i)
+	}
+	rows := make([]row, len(initial))
+	for i, h := range p.hashes(input, initial) {
+		rows[i] = row{h, []uint32{uint32(i)}}
+	}
+	for r := 1; r <= p.K; r++ {
+		bits := r * p.collisionBits()
+		var next []row
+		for i := range rows {
+			for j := i + 1; j < len(rows); j++ {
+				x := make([]byte, len(rows[i].hash))
+				for b := range x {
+					x[b] = rows[i].hash[b] ^ rows[j].hash[b]
+				}
+				if !leadingZeroBits(x, bits) {
+					continue
+				}
+				left, right := rows[i].indices, rows[j].indices
+				if right[0] < left[0] {
+					left, right = right, left
+				}
+				indices := append(slices.Clone(left), right...)
+				sorted := slices.Clone(indices)
+				slices.Sort(sorted)
+				if len(slices.Compact(sorted)) != len(indices) {
+					continue
+				}
+				next = append(next, row{x, indices})
+			}
+		}
+		rows = next
+	}
+	var solutions [][]uint32
+	for _, r := range rows {
+		if leadingZeroBits(r.hash, p.N) {
+			solutions = append(solutions, r.indices)
+		}
+	}
+	return solutions
+}
+
+// pack is the inverse of Params.indices.
+func pack(p Params, indices []uint32) []byte {
+	width := p.collisionBits() + 1
+	solution := make([]byte, p.SolutionSize())
+	bit := 0
+	for _, index := range indices {
+		for j := width - 1; j >= 0; j-- {
+			solution[bit/8] |= byte(index>>j&1) << (7 - bit%8)
+			bit++
+		}
+	}
+	return solution
+}
+
+func TestVerify(t *testing.T) {
+	p := Params48_5
+	var input []byte
+	var solution []uint32
+	for nonce := 0; solution == nil; nonce++ {
+		if nonce == 100 {
+			t.Fatal("no solution found")
+		}
+		input = []byte(fmt.Sprintf("equihash test input %d", nonce))
+		if solutions := solve(p, input); len(solutions) > 0 {
+			solution = solutions[0]
+		}
+	}
+	packed := pack(p, solution)
+	if !slices.Equal(p.indices(packed), solution) {
+		t.Fatal("pack doesn't round-trip")
+	}
+	if err := Verify(p, input, packed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		input    []byte
+		solution []byte
+	}{
+		{"other input", append(slices.Clone(input), 0), packed},
+		{"short", input, packed[:len(packed)-1]},
+		{"swapped", input, pack(p, append([]uint32{solution[1], solution[0]}, solution[2:]...))},
+		{"duplicate", input, pack(p, append([]uint32{solution[0], solution[0]}, solution[2:]...))},
+		{"zero", input, make([]byte, len(packed))},
+	} {
+		if err := Verify(p, tt.input, tt.solution); err == nil {
+			t.Errorf("%s: invalid solution accepted", tt.name)
+		}
+	}
+}
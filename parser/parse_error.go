@@ -0,0 +1,96 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zcash/lightwalletd/parser/internal/bytestring"
+)
+
+// ParseError is the error returned by the ParseFromSlice methods of Block,
+// BlockHeader and Transaction. It locates the failure, so that when a new
+// network upgrade breaks parsing, the field responsible can be found.
+type ParseError struct {
+	// Offset is the byte offset, from the start of the input to
+	// ParseFromSlice, at which parsing failed.
+	Offset int
+
+	// Field is the path to the field that failed to parse, for example
+	// "vtx[3].vout[1].script" (for a block) or "vShieldedSpend[0].cv" (for a
+	// transaction). Vectors are named as in zcashd's serialization (vtx, vin,
+	// vout, vShieldedSpend, vShieldedOutput, vJoinSplit, and actions within
+	// the orchard and ironwood bundles).
+	Field string
+
+	// Version is the version of the transaction that failed to parse, or
+	// zero if the failure isn't within a transaction (or is in its header).
+	Version uint32
+
+	// Pool is the part of the transaction the field belongs to
+	// ("transparent", "sprout", "sapling", "orchard" or "ironwood"), or
+	// empty if none.
+	Pool string
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var details []string
+	if e.Version != 0 {
+		details = append(details, fmt.Sprintf("v%d transaction", e.Version))
+	}
+	if e.Pool != "" {
+		details = append(details, e.Pool)
+	}
+	s := fmt.Sprintf("parsing %s at offset %d", e.Field, e.Offset)
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError returns a ParseError for the given field of data, where s is
+// the part of data that remains unparsed.
+func fieldError(data []byte, s bytestring.String, field string, err error) *ParseError {
+	return &ParseError{Offset: len(data) - len(s), Field: field, Err: err}
+}
+
+// nestedError returns the error from parsing the element at the given field
+// of data (where s, the part of data that remains unparsed, begins with the
+// element) as a ParseError whose offset and field path are relative to data.
+// An empty field leaves the path as it is, for a helper that parses part of
+// the same structure.
+func nestedError(err error, data []byte, s bytestring.String, field string) *ParseError {
+	var e *ParseError
+	if !errors.As(err, &e) {
+		return fieldError(data, s, field, err)
+	}
+	e.Offset += len(data) - len(s)
+	switch {
+	case field == "":
+	case e.Field == "":
+		e.Field = field
+	case strings.HasPrefix(e.Field, "["):
+		e.Field = field + e.Field
+	default:
+		e.Field = field + "." + e.Field
+	}
+	return e
+}
+
+// inPool sets the pool of err, a ParseError, unless it's already set.
+func inPool(err *ParseError, pool string) *ParseError {
+	if err.Pool == "" {
+		err.Pool = pool
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/zcash/lightwalletd/address"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ErrNoAddress is returned when a transparent output's script doesn't pay to
+// a single transparent address.
+var ErrNoAddress = errors.New("script has no transparent address")
+
+// ScriptClass is the class of a transparent output's scriptPubKey, as
+// zcashd's Solver classifies it.
+type ScriptClass int
+
+const (
+	ScriptNonstandard ScriptClass = iota
+	ScriptP2PKH                   // OP_DUP OP_HASH160 <20 bytes> OP_EQUALVERIFY OP_CHECKSIG
+	ScriptP2SH                    // OP_HASH160 <20 bytes> OP_EQUAL
+	ScriptMultisig                // OP_m <pubkey>... OP_n OP_CHECKMULTISIG
+	ScriptNullData                // OP_RETURN followed only by pushes and OP_1NEGATE to OP_16
+)
+
+// String returns the class's name as zcashd's scriptPubKey "type" field
+// gives it.
+func (c ScriptClass) String() string {
+	switch c {
+	case ScriptNonstandard:
+		return "nonstandard"
+	case ScriptP2PKH:
+		return "pubkeyhash"
+	case ScriptP2SH:
+		return "scripthash"
+	case ScriptMultisig:
+		return "multisig"
+	case ScriptNullData:
+		return "nulldata"
+	}
+	return fmt.Sprintf("ScriptClass(%d)", int(c))
+}
+
+// Script opcodes that classification needs.
+const (
+	opPushData1     = 0x4c
+	opPushData2     = 0x4d
+	opPushData4     = 0x4e
+	op1             = 0x51
+	op16            = 0x60
+	opReturn        = 0x6a
+	opDup           = 0x76
+	opEqual         = 0x87
+	opEqualVerify   = 0x88
+	opHash160       = 0xa9
+	opCheckSig      = 0xac
+	opCheckMultisig = 0xae
+)
+
+// readPush reads a data push (OP_0, a direct push of 1 to 75 bytes, or
+// OP_PUSHDATA1, 2 or 4) from the start of script, returning the data and
+// the rest of the script; ok is false if script doesn't start with one.
+func readPush(script []byte) (data []byte, rest []byte, ok bool) {
+	if len(script) == 0 {
+		return nil, nil, false
+	}
+	op, script := script[0], script[1:]
+	var n int
+	switch {
+	case op < opPushData1:
+		n = int(op)
+	case op == opPushData1 && len(script) >= 1:
+		n, script = int(script[0]), script[1:]
+	case op == opPushData2 && len(script) >= 2:
+		n, script = int(binary.LittleEndian.Uint16(script)), script[2:]
+	case op == opPushData4 && len(script) >= 4:
+		n, script = int(binary.LittleEndian.Uint32(script)), script[4:]
+	default:
+		return nil, nil, false
+	}
+	if n < 0 || n > len(script) {
+		return nil, nil, false
+	}
+	return script[:n], script[n:], true
+}
+
+// isPubKey reports whether b has the length and leading byte of a
+// compressed or uncompressed secp256k1 public key.
+func isPubKey(b []byte) bool {
+	switch len(b) {
+	case 33:
+		return b[0] == 0x02 || b[0] == 0x03
+	case 65:
+		return b[0] == 0x04
+	}
+	return false
+}
+
+// ClassifyScript returns the class of a scriptPubKey.
+func ClassifyScript(script []byte) ScriptClass {
+	switch {
+	case len(script) == 25 && script[0] == opDup && script[1] == opHash160 && script[2] == 20 &&
+		script[23] == opEqualVerify && script[24] == opCheckSig:
+		return ScriptP2PKH
+	case len(script) == 23 && script[0] == opHash160 && script[1] == 20 && script[22] == opEqual:
+		return ScriptP2SH
+	case len(script) >= 1 && script[0] == opReturn:
+		// As zcashd's IsPushOnly, any opcode up to OP_16 is allowed.
+		for rest := script[1:]; len(rest) > 0; {
+			if rest[0] > opPushData4 && rest[0] <= op16 {
+				rest = rest[1:]
+				continue
+			}
+			var ok bool
+			if _, rest, ok = readPush(rest); !ok {
+				return ScriptNonstandard
+			}
+		}
+		return ScriptNullData
+	case isMultisig(script):
+		return ScriptMultisig
+	}
+	return ScriptNonstandard
+}
+
+// isMultisig reports whether script is a bare m-of-n multisig script.
+func isMultisig(script []byte) bool {
+	if len(script) < 3 || script[len(script)-1] != opCheckMultisig {
+		return false
+	}
+	m, n := script[0], script[len(script)-2]
+	if m < op1 || m > op16 || n < op1 || n > op16 || m > n {
+		return false
+	}
+	keys := 0
+	for rest := script[1 : len(script)-2]; len(rest) > 0; keys++ {
+		var key []byte
+		var ok bool
+		if key, rest, ok = readPush(rest); !ok || !isPubKey(key) {
+			return false
+		}
+	}
+	return keys == int(n-op1+1)
+}
+
+// ScriptAddress returns the transparent address that a P2PKH or P2SH
+// scriptPubKey pays to; it returns ErrNoAddress for other classes.
+func ScriptAddress(script []byte) (*address.Transparent, error) {
+	switch ClassifyScript(script) {
+	case ScriptP2PKH:
+		return &address.Transparent{Kind: address.P2PKH, Hash: [20]byte(script[3:23])}, nil
+	case ScriptP2SH:
+		return &address.Transparent{Kind: address.P2SH, Hash: [20]byte(script[2:22])}, nil
+	}
+	return nil, ErrNoAddress
+}
+
+// AddressScript returns the standard scriptPubKey that pays to the given
+// transparent address; ScriptAddress is its inverse.
+func AddressScript(t *address.Transparent) []byte {
+	if t.Kind == address.P2SH {
+		return append(append([]byte{opHash160, 20}, t.Hash[:]...), opEqual)
+	}
+	return append(append([]byte{opDup, opHash160, 20}, t.Hash[:]...), opEqualVerify, opCheckSig)
+}
+
+// Class returns the class of the output's scriptPubKey.
+func (toutput *TxOut) Class() ScriptClass {
+	return ClassifyScript(toutput.Script)
+}
+
+// Address returns the encoded transparent address the output pays to, for
+// the given chain (as named by getblockchaininfo); it returns ErrNoAddress
+// if the output isn't P2PKH or P2SH.
+func (toutput *TxOut) Address(chainName string) (string, error) {
+	taddr, err := ScriptAddress(toutput.Script)
+	if err != nil {
+		return "", err
+	}
+	return taddr.Encode(chainName), nil
+}
+
+// SpentPubKeyHash returns the public key hash (HASH160 of the public key)
+// of the P2PKH output that the input spends, if its scriptSig is the
+// standard <signature> <public key>; ok is false otherwise (including for
+// coinbase inputs and P2SH spends).
+func (tinput *TxIn) SpentPubKeyHash() (hash [20]byte, ok bool) {
+	sig, rest, ok := readPush(tinput.ScriptSig)
+	if !ok || len(sig) == 0 {
+		return hash, false
+	}
+	pubKey, rest, ok := readPush(rest)
+	if !ok || len(rest) != 0 || !isPubKey(pubKey) {
+		return hash, false
+	}
+	return hash160(pubKey), true
+}
+
+// hash160 returns RIPEMD160(SHA256(b)), the hash that P2PKH and P2SH
+// scripts commit to.
+func hash160(b []byte) [20]byte {
+	digest := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(digest[:])
+	return [20]byte(h.Sum(nil))
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package parser
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The secp256k1 generator, compressed, and its HASH160.
+const (
+	testPubKey     = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	testPubKeyHash = "751e76e8199196d454941c45d1b3a323f1433bd6"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestClassifyScript(t *testing.T) {
+	for _, tt := range []struct {
+		script string
+		class  ScriptClass
+		addr   string // on mainnet, or "" if none
+	}{
+		{"76a9148286bf790866805397e3a947640b77a43f0b43a588ac", ScriptP2PKH, "t1VmmGiyjVNeCjxDZzg7vZmd99WyzVby9yC"},
+		{"a9147d46a730d31f97b1930d3368a967c309bd4d136a87", ScriptP2SH, "t3Vz22vK5z2LcKEdg16Yv4FFneEL1zg9ojd"},
+		{"5121" + testPubKey + "21" + testPubKey + "52ae", ScriptMultisig, ""},
+		{"6a", ScriptNullData, ""},
+		{"6a0568656c6c6f", ScriptNullData, ""},
+		{"6a4c0568656c6c6f", ScriptNullData, ""},
+		{"6a00", ScriptNullData, ""},                 // OP_0
+		{"6a4f51600568656c6c6f", ScriptNullData, ""}, // OP_1NEGATE OP_1 OP_16 push
+		{"6a50", ScriptNullData, ""},                 // OP_RESERVED is push-only too
+		{"", ScriptNonstandard, ""},
+		{"21" + testPubKey + "ac", ScriptNonstandard, ""}, // P2PK
+		{"6a0668656c6c6f", ScriptNonstandard, ""},         // truncated push
+		{"6a76", ScriptNonstandard, ""},                   // non-push after OP_RETURN
+		{"6a6100", ScriptNonstandard, ""},                 // OP_NOP after OP_RETURN
+		{"76a9148286bf790866805397e3a947640b77a43f0b43a588", ScriptNonstandard, ""},
+		{"5221" + testPubKey + "51ae", ScriptNonstandard, ""}, // m > n
+		{"5121" + testPubKey + "52ae", ScriptNonstandard, ""}, // too few keys
+		{"510401020304" + "51ae", ScriptNonstandard, ""},      // not a pubkey
+		{"a9147d46a730d31f97b1930d3368a967c309bd4d136a88", ScriptNonstandard, ""},
+	} {
+		script := mustDecodeHex(t, tt.script)
+		if class := ClassifyScript(script); class != tt.class {
+			t.Errorf("%s: class %v, want %v", tt.script, class, tt.class)
+		}
+		out := TxOut{Script: script}
+		addr, err := out.Address("main")
+		if tt.addr == "" {
+			if !errors.Is(err, ErrNoAddress) {
+				t.Errorf("%s: address %q, err %v", tt.script, addr, err)
+			}
+		} else if err != nil || addr != tt.addr {
+			t.Errorf("%s: address %q, err %v, want %s", tt.script, addr, err, tt.addr)
+		}
+	}
+}
+
+func TestTxOutAddressTestnet(t *testing.T) {
+	out := TxOut{Script: mustDecodeHex(t, "76a91453c0307d6851aa0ce7825ba883c6bd9ad242b48688ac")}
+	addr, err := out.Address("test")
+	if err != nil || addr != "tmHMBeeYRuc2eVicLNfP15YLxbQsooCA6jb" {
+		t.Fatal("unexpected address", addr, err)
+	}
+}
+
+func TestSpentPubKeyHash(t *testing.T) {
+	sig := strings.Repeat("30", 71) + "01"
+	for _, tt := range []struct {
+		scriptSig string
+		ok        bool
+	}{
+		{"48" + sig + "21" + testPubKey, true},
+		{"4c48" + sig + "21" + testPubKey, true},
+		{"", false},
+		{"48" + sig, false},                            // no public key
+		{"0021" + testPubKey, false},                   // empty signature
+		{"48" + sig + "21" + testPubKey + "00", false}, // trailing data
+		{"48" + sig + "2105" + testPubKey[2:], false},  // bad key prefix
+		{"03a0860100", false},                          // coinbase height
+	} {
+		in := TxIn{ScriptSig: mustDecodeHex(t, tt.scriptSig)}
+		hash, ok := in.SpentPubKeyHash()
+		if ok != tt.ok {
+			t.Errorf("%s: ok %v", tt.scriptSig, ok)
+		} else if ok && hex.EncodeToString(hash[:]) != testPubKeyHash {
+			t.Errorf("%s: hash %x", tt.scriptSig, hash)
+		}
+	}
+}
@@ -6,102 +6,151 @@
 package parser
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser/internal/bytestring"
 	"github.com/zcash/lightwalletd/walletrpc"
 )
 
-type rawTransaction struct {
-	fOverwintered      bool
-	version            uint32
-	nVersionGroupID    uint32
-	consensusBranchID  uint32
-	transparentInputs  []txIn
-	transparentOutputs []txOut
-	//nLockTime           uint32
-	//nExpiryHeight       uint32
-	//valueBalanceSapling int64
-	shieldedSpends  []spend
-	shieldedOutputs []output
-	joinSplits      []joinSplit
-	//joinSplitPubKey     []byte
-	//joinSplitSig        []byte
-	//bindingSigSapling   []byte
-	orchardActions  []action
-	ironwoodActions []action
-}
-
-// Txin format as described in https://en.bitcoin.it/wiki/Transaction
-type txIn struct {
+// HexBytes is a byte string that's rendered in JSON as hex (in wire order),
+// rather than base64.
+type HexBytes []byte
+
+func (h HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *HexBytes) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	*h = b
+	return nil
+}
+
+// TransactionData is the fully decoded content of a transaction, of any
+// version from 1 through 6. Fields that a transaction's version doesn't
+// have are zero (or nil). The JSON field names follow those of zcashd's
+// getrawtransaction verbose output where there's a counterpart, but byte
+// strings other than txids are hex in wire order (zcashd reverses some).
+type TransactionData struct {
+	Overwintered      bool   `json:"overwintered"`
+	Version           uint32 `json:"version"`
+	VersionGroupID    uint32 `json:"versiongroupid,omitempty"`    // v3 and later
+	ConsensusBranchID uint32 `json:"consensusbranchid,omitempty"` // v5 and later
+	LockTime          uint32 `json:"locktime"`
+	ExpiryHeight      uint32 `json:"expiryheight,omitempty"` // v3 and later
+
+	TransparentInputs  []TxIn  `json:"vin"`
+	TransparentOutputs []TxOut `json:"vout"`
+
+	// Sapling (v4 and later).
+	ValueBalanceSapling int64           `json:"valueBalanceZat"`
+	SaplingAnchor       HexBytes        `json:"anchorSapling,omitempty"` // v5 and later; v4 spends each have one
+	SaplingSpends       []SaplingSpend  `json:"vShieldedSpend"`
+	SaplingOutputs      []SaplingOutput `json:"vShieldedOutput"`
+	BindingSigSapling   HexBytes        `json:"bindingSig,omitempty"`
+
+	// Sprout (v2 through v4).
+	JoinSplits      []JoinSplit `json:"vjoinsplit"`
+	JoinSplitPubKey HexBytes    `json:"joinSplitPubKey,omitempty"`
+	JoinSplitSig    HexBytes    `json:"joinSplitSig,omitempty"`
+
+	// Orchard (v5 and later) and Ironwood (v6 and later); nil if the
+	// transaction has no actions in the pool.
+	Orchard  *OrchardBundle `json:"orchard,omitempty"`
+	Ironwood *OrchardBundle `json:"ironwood,omitempty"`
+}
+
+// TxIn is a transparent input, as described in https://en.bitcoin.it/wiki/Transaction
+type TxIn struct {
 	// SHA256d of a previous (to-be-used) transaction
 	PrevTxHash hash32.T
 	// Index of the to-be-used output in the previous tx
 	PrevTxOutIndex uint32
 	// CompactSize-prefixed, could be a pubkey or a script
-	ScriptSig []byte
-
+	ScriptSig HexBytes
 	// Bitcoin: "normally 0xFFFFFFFF; irrelevant unless transaction's lock_time > 0"
-	//SequenceNumber uint32
+	SequenceNumber uint32
 }
 
-func (tx *txIn) ParseFromSlice(data []byte) ([]byte, error) {
+// MarshalJSON renders the input with the previous txid in big-endian
+// (display) order, as zcashd does.
+func (tin TxIn) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Txid      string   `json:"txid"`
+		Vout      uint32   `json:"vout"`
+		ScriptSig HexBytes `json:"scriptSig"`
+		Sequence  uint32   `json:"sequence"`
+	}{hash32.Encode(hash32.Reverse(tin.PrevTxHash)), tin.PrevTxOutIndex, tin.ScriptSig, tin.SequenceNumber})
+}
+
+func (tx *TxIn) ParseFromSlice(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
 
 	b32 := make([]byte, 32)
 	if !s.ReadBytes(&b32, 32) {
-		return nil, errors.New("could not read HashPrevBlock")
+		return nil, fieldError(data, s, "prevTxHash", errors.New("could not read HashPrevBlock"))
 	}
 	tx.PrevTxHash = hash32.FromSlice(b32)
 
 	if !s.ReadUint32(&tx.PrevTxOutIndex) {
-		return nil, errors.New("could not read PrevTxOutIndex")
+		return nil, fieldError(data, s, "prevTxOutIndex", errors.New("could not read PrevTxOutIndex"))
 	}
 
 	if !s.ReadCompactLengthPrefixed((*bytestring.String)(&tx.ScriptSig)) {
-		return nil, errors.New("could not read ScriptSig")
+		return nil, fieldError(data, s, "scriptSig", errors.New("could not read ScriptSig"))
 	}
 
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip SequenceNumber")
+	if !s.ReadUint32(&tx.SequenceNumber) {
+		return nil, fieldError(data, s, "sequenceNumber", errors.New("could not read SequenceNumber"))
 	}
 
 	return []byte(s), nil
 }
 
-func (tinput *txIn) ToCompact() *walletrpc.CompactTxIn {
+func (tinput *TxIn) ToCompact() *walletrpc.CompactTxIn {
 	return &walletrpc.CompactTxIn{
 		PrevoutTxid:  hash32.ToSlice(tinput.PrevTxHash),
 		PrevoutIndex: tinput.PrevTxOutIndex,
 	}
 }
 
-// Txout format as described in https://en.bitcoin.it/wiki/Transaction
-type txOut struct {
+// TxOut is a transparent output, as described in https://en.bitcoin.it/wiki/Transaction
+type TxOut struct {
 	// Non-negative int giving the number of zatoshis to be transferred
-	Value uint64
+	Value uint64 `json:"valueZat"`
 
 	// Script. CompactSize-prefixed.
-	Script []byte
+	Script HexBytes `json:"scriptPubKey"`
 }
 
-func (tx *txOut) ParseFromSlice(data []byte) ([]byte, error) {
+func (tx *TxOut) ParseFromSlice(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
 
 	if !s.ReadUint64(&tx.Value) {
-		return nil, errors.New("could not read txOut value")
+		return nil, fieldError(data, s, "value", errors.New("could not read txOut value"))
 	}
 
 	if !s.ReadCompactLengthPrefixed((*bytestring.String)(&tx.Script)) {
-		return nil, errors.New("could not read txOut script")
+		return nil, fieldError(data, s, "script", errors.New("could not read txOut script"))
 	}
 
 	return []byte(s), nil
 }
 
-func (toutput *txOut) ToCompact() *walletrpc.TxOut {
+func (toutput *TxOut) ToCompact() *walletrpc.TxOut {
 	return &walletrpc.TxOut{
 		Value:        toutput.Value,
 		ScriptPubKey: toutput.Script,
@@ -113,265 +162,296 @@ func (tx *Transaction) ParseTransparent(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
 	var txInCount int
 	if !s.ReadCompactSize(&txInCount) {
-		return nil, errors.New("could not read tx_in_count")
+		return nil, inPool(fieldError(data, s, "vin", errors.New("could not read tx_in_count")), "transparent")
 	}
 	if err := rejectCountExceedingRemaining("tx_in_count", txInCount, len(s), minTxInWireBytes); err != nil {
-		return nil, err
+		return nil, inPool(fieldError(data, s, "vin", err), "transparent")
 	}
-	var err error
-	tx.transparentInputs = make([]txIn, txInCount)
+	tx.TransparentInputs = make([]TxIn, txInCount)
 	for i := 0; i < txInCount; i++ {
-		ti := &tx.transparentInputs[i]
-		s, err = ti.ParseFromSlice([]byte(s))
+		ti := &tx.TransparentInputs[i]
+		rest, err := ti.ParseFromSlice([]byte(s))
 		if err != nil {
-			return nil, fmt.Errorf("error parsing transparent input: %w", err)
+			return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vin[%d]", i)), "transparent")
 		}
+		s = rest
 	}
 
 	var txOutCount int
 	if !s.ReadCompactSize(&txOutCount) {
-		return nil, errors.New("could not read tx_out_count")
+		return nil, inPool(fieldError(data, s, "vout", errors.New("could not read tx_out_count")), "transparent")
 	}
 	if err := rejectCountExceedingRemaining("tx_out_count", txOutCount, len(s), minTxOutWireBytes); err != nil {
-		return nil, err
+		return nil, inPool(fieldError(data, s, "vout", err), "transparent")
 	}
-	tx.transparentOutputs = make([]txOut, txOutCount)
+	tx.TransparentOutputs = make([]TxOut, txOutCount)
 	for i := 0; i < txOutCount; i++ {
-		to := &tx.transparentOutputs[i]
-		s, err = to.ParseFromSlice([]byte(s))
+		to := &tx.TransparentOutputs[i]
+		rest, err := to.ParseFromSlice([]byte(s))
 		if err != nil {
-			return nil, fmt.Errorf("error parsing transparent output: %w", err)
+			return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vout[%d]", i)), "transparent")
 		}
+		s = rest
 	}
 	return []byte(s), nil
 }
 
-// spend is a Sapling Spend Description as described in 7.3 of the Zcash
-// protocol specification.
-type spend struct {
-	//cv           []byte // 32
-	//anchor       []byte // 32
-	nullifier []byte // 32
-	//rk           []byte // 32
-	//zkproof      []byte // 192
-	//spendAuthSig []byte // 64
+// SaplingSpend is a Sapling Spend Description as described in 7.3 of the
+// Zcash protocol specification. In v5 and later transactions, the anchor is
+// shared by all spends (TransactionData.SaplingAnchor), and the proof and
+// signature are serialized after all the spends and outputs.
+type SaplingSpend struct {
+	Cv           HexBytes `json:"cv"`               // 32
+	Anchor       HexBytes `json:"anchor,omitempty"` // 32, v4 only
+	Nullifier    HexBytes `json:"nullifier"`        // 32
+	Rk           HexBytes `json:"rk"`               // 32
+	Zkproof      HexBytes `json:"proof"`            // 192
+	SpendAuthSig HexBytes `json:"spendAuthSig"`     // 64
 }
 
-func (p *spend) ParseFromSlice(data []byte, version uint32) ([]byte, error) {
+func (p *SaplingSpend) ParseFromSlice(data []byte, version uint32) ([]byte, error) {
 	s := bytestring.String(data)
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip cv")
+	if !s.ReadBytes((*[]byte)(&p.Cv), 32) {
+		return nil, fieldError(data, s, "cv", errors.New("could not read cv"))
 	}
 
-	if version <= 4 && !s.Skip(32) {
-		return nil, errors.New("could not skip anchor")
+	if version <= 4 && !s.ReadBytes((*[]byte)(&p.Anchor), 32) {
+		return nil, fieldError(data, s, "anchor", errors.New("could not read anchor"))
 	}
 
-	if !s.ReadBytes(&p.nullifier, 32) {
-		return nil, errors.New("could not read nullifier")
+	if !s.ReadBytes((*[]byte)(&p.Nullifier), 32) {
+		return nil, fieldError(data, s, "nullifier", errors.New("could not read nullifier"))
 	}
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip rk")
+	if !s.ReadBytes((*[]byte)(&p.Rk), 32) {
+		return nil, fieldError(data, s, "rk", errors.New("could not read rk"))
 	}
 
-	if version <= 4 && !s.Skip(192) {
-		return nil, errors.New("could not skip zkproof")
+	if version <= 4 && !s.ReadBytes((*[]byte)(&p.Zkproof), 192) {
+		return nil, fieldError(data, s, "zkproof", errors.New("could not read zkproof"))
 	}
 
-	if version <= 4 && !s.Skip(64) {
-		return nil, errors.New("could not skip spendAuthSig")
+	if version <= 4 && !s.ReadBytes((*[]byte)(&p.SpendAuthSig), 64) {
+		return nil, fieldError(data, s, "spendAuthSig", errors.New("could not read spendAuthSig"))
 	}
 
 	return []byte(s), nil
 }
 
-func (p *spend) ToCompact() *walletrpc.CompactSaplingSpend {
+func (p *SaplingSpend) ToCompact() *walletrpc.CompactSaplingSpend {
 	return &walletrpc.CompactSaplingSpend{
-		Nf: p.nullifier,
+		Nf: p.Nullifier,
 	}
 }
 
-// output is a Sapling Output Description as described in section 7.4 of the
-// Zcash protocol spec.
-type output struct {
-	//cv            []byte // 32
-	cmu           []byte // 32
-	ephemeralKey  []byte // 32
-	encCiphertext []byte // 580
-	//outCiphertext []byte // 80
-	//zkproof       []byte // 192
+// SaplingOutput is a Sapling Output Description as described in section 7.4
+// of the Zcash protocol spec. In v5 and later transactions, the proof is
+// serialized after all the spends and outputs.
+type SaplingOutput struct {
+	Cv            HexBytes `json:"cv"`            // 32
+	Cmu           HexBytes `json:"cmu"`           // 32
+	EphemeralKey  HexBytes `json:"ephemeralKey"`  // 32
+	EncCiphertext HexBytes `json:"encCiphertext"` // 580
+	OutCiphertext HexBytes `json:"outCiphertext"` // 80
+	Zkproof       HexBytes `json:"proof"`         // 192
 }
 
-func (p *output) ParseFromSlice(data []byte, version uint32) ([]byte, error) {
+func (p *SaplingOutput) ParseFromSlice(data []byte, version uint32) ([]byte, error) {
 	s := bytestring.String(data)
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip cv")
+	if !s.ReadBytes((*[]byte)(&p.Cv), 32) {
+		return nil, fieldError(data, s, "cv", errors.New("could not read cv"))
 	}
 
-	if !s.ReadBytes(&p.cmu, 32) {
-		return nil, errors.New("could not read cmu")
+	if !s.ReadBytes((*[]byte)(&p.Cmu), 32) {
+		return nil, fieldError(data, s, "cmu", errors.New("could not read cmu"))
 	}
 
-	if !s.ReadBytes(&p.ephemeralKey, 32) {
-		return nil, errors.New("could not read ephemeralKey")
+	if !s.ReadBytes((*[]byte)(&p.EphemeralKey), 32) {
+		return nil, fieldError(data, s, "ephemeralKey", errors.New("could not read ephemeralKey"))
 	}
 
-	if !s.ReadBytes(&p.encCiphertext, 580) {
-		return nil, errors.New("could not read encCiphertext")
+	if !s.ReadBytes((*[]byte)(&p.EncCiphertext), 580) {
+		return nil, fieldError(data, s, "encCiphertext", errors.New("could not read encCiphertext"))
 	}
 
-	if !s.Skip(80) {
-		return nil, errors.New("could not skip outCiphertext")
+	if !s.ReadBytes((*[]byte)(&p.OutCiphertext), 80) {
+		return nil, fieldError(data, s, "outCiphertext", errors.New("could not read outCiphertext"))
 	}
 
-	if version <= 4 && !s.Skip(192) {
-		return nil, errors.New("could not skip zkproof")
+	if version <= 4 && !s.ReadBytes((*[]byte)(&p.Zkproof), 192) {
+		return nil, fieldError(data, s, "zkproof", errors.New("could not read zkproof"))
 	}
 
 	return []byte(s), nil
 }
 
-func (p *output) ToCompact() *walletrpc.CompactSaplingOutput {
+func (p *SaplingOutput) ToCompact() *walletrpc.CompactSaplingOutput {
 	return &walletrpc.CompactSaplingOutput{
-		Cmu:          p.cmu,
-		EphemeralKey: p.ephemeralKey,
-		Ciphertext:   p.encCiphertext[:52],
+		Cmu:          p.Cmu,
+		EphemeralKey: p.EphemeralKey,
+		Ciphertext:   p.EncCiphertext[:52],
 	}
 }
 
-// joinSplit is a JoinSplit description as described in 7.2 of the Zcash
+// JoinSplit is a JoinSplit description as described in 7.2 of the Zcash
 // protocol spec. Its exact contents differ by transaction version and network
-// upgrade level. Only version 4 is supported, no need for proofPHGR13.
-type joinSplit struct {
-	//vpubOld        uint64
-	//vpubNew        uint64
-	//anchor         []byte    // 32
-	//nullifiers     [2][]byte // 64 [N_old][32]byte
-	//commitments    [2][]byte // 64 [N_new][32]byte
-	//ephemeralKey   []byte    // 32
-	//randomSeed     []byte    // 32
-	//vmacs          [2][]byte // 64 [N_old][32]byte
-	//proofGroth16   []byte    // 192 (version 4, sapling), or 296 (pre-sapling)
-	//encCiphertexts [2][]byte // 1202 [N_new][601]byte
-}
-
-func (p *joinSplit) ParseFromSlice(data []byte, isGroth16Proof bool) ([]byte, error) {
+// upgrade level: the proof is PHGR13 (296 bytes) before Sapling, and Groth16
+// (192 bytes) in v4 transactions.
+type JoinSplit struct {
+	VpubOld        uint64      `json:"vpub_oldZat"`
+	VpubNew        uint64      `json:"vpub_newZat"`
+	Anchor         HexBytes    `json:"anchor"`        // 32
+	Nullifiers     [2]HexBytes `json:"nullifiers"`    // [N_old][32]byte
+	Commitments    [2]HexBytes `json:"commitments"`   // [N_new][32]byte
+	EphemeralKey   HexBytes    `json:"onetimePubKey"` // 32
+	RandomSeed     HexBytes    `json:"randomSeed"`    // 32
+	Vmacs          [2]HexBytes `json:"macs"`          // [N_old][32]byte
+	Proof          HexBytes    `json:"proof"`         // 192 (version 4, sapling), or 296 (pre-sapling)
+	EncCiphertexts [2]HexBytes `json:"ciphertexts"`   // [N_new][601]byte
+}
+
+func (p *JoinSplit) ParseFromSlice(data []byte, isGroth16Proof bool) ([]byte, error) {
 	s := bytestring.String(data)
 
-	if !s.Skip(8) {
-		return nil, errors.New("could not skip vpubOld")
+	if !s.ReadUint64(&p.VpubOld) {
+		return nil, fieldError(data, s, "vpubOld", errors.New("could not read vpubOld"))
 	}
 
-	if !s.Skip(8) {
-		return nil, errors.New("could not skip vpubNew")
+	if !s.ReadUint64(&p.VpubNew) {
+		return nil, fieldError(data, s, "vpubNew", errors.New("could not read vpubNew"))
 	}
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip anchor")
+	if !s.ReadBytes((*[]byte)(&p.Anchor), 32) {
+		return nil, fieldError(data, s, "anchor", errors.New("could not read anchor"))
 	}
 
 	for i := 0; i < 2; i++ {
-		if !s.Skip(32) {
-			return nil, errors.New("could not skip a nullifier")
+		if !s.ReadBytes((*[]byte)(&p.Nullifiers[i]), 32) {
+			return nil, fieldError(data, s, fmt.Sprintf("nullifiers[%d]", i), errors.New("could not read a nullifier"))
 		}
 	}
 
 	for i := 0; i < 2; i++ {
-		if !s.Skip(32) {
-			return nil, errors.New("could not skip a commitment")
+		if !s.ReadBytes((*[]byte)(&p.Commitments[i]), 32) {
+			return nil, fieldError(data, s, fmt.Sprintf("commitments[%d]", i), errors.New("could not read a commitment"))
 		}
 	}
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip ephemeralKey")
+	if !s.ReadBytes((*[]byte)(&p.EphemeralKey), 32) {
+		return nil, fieldError(data, s, "ephemeralKey", errors.New("could not read ephemeralKey"))
 	}
 
-	if !s.Skip(32) {
-		return nil, errors.New("could not skip randomSeed")
+	if !s.ReadBytes((*[]byte)(&p.RandomSeed), 32) {
+		return nil, fieldError(data, s, "randomSeed", errors.New("could not read randomSeed"))
 	}
 
 	for i := 0; i < 2; i++ {
-		if !s.Skip(32) {
-			return nil, errors.New("could not skip a vmac")
+		if !s.ReadBytes((*[]byte)(&p.Vmacs[i]), 32) {
+			return nil, fieldError(data, s, fmt.Sprintf("vmacs[%d]", i), errors.New("could not read a vmac"))
 		}
 	}
 
 	// For these sizes, see 5.4.10.2 (page 110) of the Zcash protocol spec 2025.6.1-103
 	if isGroth16Proof {
-		if !s.Skip(192) {
-			return nil, errors.New("could not skip Groth16 proof")
+		if !s.ReadBytes((*[]byte)(&p.Proof), 192) {
+			return nil, fieldError(data, s, "proof", errors.New("could not read Groth16 proof"))
 		}
 	} else {
 		// older PHGR proof
-		if !s.Skip(296) {
-			return nil, errors.New("could not skip PHGR proof")
+		if !s.ReadBytes((*[]byte)(&p.Proof), 296) {
+			return nil, fieldError(data, s, "proof", errors.New("could not read PHGR proof"))
 		}
 	}
 
 	for i := 0; i < 2; i++ {
-		if !s.Skip(601) {
-			return nil, errors.New("could not skip an encCiphertext")
+		if !s.ReadBytes((*[]byte)(&p.EncCiphertexts[i]), 601) {
+			return nil, fieldError(data, s, fmt.Sprintf("encCiphertexts[%d]", i), errors.New("could not read an encCiphertext"))
 		}
 	}
 
 	return []byte(s), nil
 }
 
-type action struct {
-	//cv            []byte // 32
-	nullifier []byte // 32
-	//rk            []byte // 32
-	cmx           []byte // 32
-	ephemeralKey  []byte // 32
-	encCiphertext []byte // 580
-	//outCiphertext []byte // 80
+// OrchardBundle is the Orchard (or, with the same encoding, Ironwood) part of
+// a v5 or later transaction, as described in section 7.1 of the Zcash
+// protocol spec.
+type OrchardBundle struct {
+	Actions      []OrchardAction `json:"actions"`
+	Flags        byte            `json:"flags"` // bit 0: enableSpends, bit 1: enableOutputs
+	ValueBalance int64           `json:"valueBalanceZat"`
+	Anchor       HexBytes        `json:"anchor"`     // 32
+	Proofs       HexBytes        `json:"proof"`      // variable length
+	BindingSig   HexBytes        `json:"bindingSig"` // 64
 }
 
-func (a *action) ParseFromSlice(data []byte) ([]byte, error) {
+// OrchardAction is an Orchard Action Description as described in section
+// 7.5 of the Zcash protocol spec; the spend authorization signature is
+// serialized after all the actions.
+type OrchardAction struct {
+	Cv            HexBytes `json:"cv"`            // 32
+	Nullifier     HexBytes `json:"nullifier"`     // 32
+	Rk            HexBytes `json:"rk"`            // 32
+	Cmx           HexBytes `json:"cmx"`           // 32
+	EphemeralKey  HexBytes `json:"ephemeralKey"`  // 32
+	EncCiphertext HexBytes `json:"encCiphertext"` // 580
+	OutCiphertext HexBytes `json:"outCiphertext"` // 80
+	SpendAuthSig  HexBytes `json:"spendAuthSig"`  // 64
+}
+
+func (a *OrchardAction) ParseFromSlice(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
-	if !s.Skip(32) {
-		return nil, errors.New("could not read action cv")
+	if !s.ReadBytes((*[]byte)(&a.Cv), 32) {
+		return nil, fieldError(data, s, "cv", errors.New("could not read action cv"))
 	}
-	if !s.ReadBytes(&a.nullifier, 32) {
-		return nil, errors.New("could not read action nullifier")
+	if !s.ReadBytes((*[]byte)(&a.Nullifier), 32) {
+		return nil, fieldError(data, s, "nullifier", errors.New("could not read action nullifier"))
 	}
-	if !s.Skip(32) {
-		return nil, errors.New("could not read action rk")
+	if !s.ReadBytes((*[]byte)(&a.Rk), 32) {
+		return nil, fieldError(data, s, "rk", errors.New("could not read action rk"))
 	}
-	if !s.ReadBytes(&a.cmx, 32) {
-		return nil, errors.New("could not read action cmx")
+	if !s.ReadBytes((*[]byte)(&a.Cmx), 32) {
+		return nil, fieldError(data, s, "cmx", errors.New("could not read action cmx"))
 	}
-	if !s.ReadBytes(&a.ephemeralKey, 32) {
-		return nil, errors.New("could not read action ephemeralKey")
+	if !s.ReadBytes((*[]byte)(&a.EphemeralKey), 32) {
+		return nil, fieldError(data, s, "ephemeralKey", errors.New("could not read action ephemeralKey"))
 	}
-	if !s.ReadBytes(&a.encCiphertext, 580) {
-		return nil, errors.New("could not read action encCiphertext")
+	if !s.ReadBytes((*[]byte)(&a.EncCiphertext), 580) {
+		return nil, fieldError(data, s, "encCiphertext", errors.New("could not read action encCiphertext"))
 	}
-	if !s.Skip(80) {
-		return nil, errors.New("could not read action outCiphertext")
+	if !s.ReadBytes((*[]byte)(&a.OutCiphertext), 80) {
+		return nil, fieldError(data, s, "outCiphertext", errors.New("could not read action outCiphertext"))
 	}
 	return []byte(s), nil
 }
 
-func (p *action) ToCompact() *walletrpc.CompactOrchardAction {
+func (p *OrchardAction) ToCompact() *walletrpc.CompactOrchardAction {
 	return &walletrpc.CompactOrchardAction{
-		Nullifier:    p.nullifier,
-		Cmx:          p.cmx,
-		EphemeralKey: p.ephemeralKey,
-		Ciphertext:   p.encCiphertext[:52],
+		Nullifier:    p.Nullifier,
+		Cmx:          p.Cmx,
+		EphemeralKey: p.EphemeralKey,
+		Ciphertext:   p.EncCiphertext[:52],
 	}
 }
 
 // Transaction encodes a full (zcashd) transaction.
 type Transaction struct {
-	*rawTransaction
+	*TransactionData
 	rawBytes []byte
 	txID     hash32.T // from getblock verbose=1
 }
 
+// MarshalJSON renders the transaction's txid (in big-endian display order)
+// and its decoded content.
+func (tx *Transaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Txid string `json:"txid"`
+		Size int    `json:"size"`
+		*TransactionData
+	}{tx.GetDisplayHashString(), len(tx.Bytes()), tx.TransactionData})
+}
+
 func (tx *Transaction) SetTxID(txid hash32.T) {
 	tx.txID = txid
 }
@@ -386,24 +466,116 @@ func (tx *Transaction) GetEncodableHash() hash32.T {
 	return tx.txID
 }
 
-// Bytes returns a full transaction's raw bytes.
+// Bytes returns a full transaction's raw bytes: the bytes it was parsed
+// from or, for a transaction that was built rather than parsed, its
+// serialization (nil if it can't be serialized).
 func (tx *Transaction) Bytes() []byte {
+	if tx.rawBytes == nil {
+		rawBytes, err := tx.MarshalBinary()
+		if err != nil {
+			return nil
+		}
+		return rawBytes
+	}
 	return tx.rawBytes
 }
 
 // SaplingOutputsCount returns the number of Sapling outputs in the transaction.
 func (tx *Transaction) SaplingOutputsCount() int {
-	return len(tx.shieldedOutputs)
+	return len(tx.SaplingOutputs)
 }
 
 // OrchardActionsCount returns the number of Orchard actions in the transaction.
 func (tx *Transaction) OrchardActionsCount() int {
-	return len(tx.orchardActions)
+	return len(tx.orchardActions())
 }
 
 // IronwoodActionsCount returns the number of Ironwood actions in the transaction.
 func (tx *Transaction) IronwoodActionsCount() int {
-	return len(tx.ironwoodActions)
+	return len(tx.ironwoodActions())
+}
+
+func (tx *Transaction) orchardActions() []OrchardAction {
+	if tx.Orchard == nil {
+		return nil
+	}
+	return tx.Orchard.Actions
+}
+
+func (tx *Transaction) ironwoodActions() []OrchardAction {
+	if tx.Ironwood == nil {
+		return nil
+	}
+	return tx.Ironwood.Actions
+}
+
+// maxMoney is MAX_MONEY, 21 million ZEC in zatoshis; no valid amount exceeds it.
+const maxMoney = 21_000_000 * 100_000_000
+
+// Fee returns the transaction fee in zatoshis, if it can be computed from the
+// transaction alone, which requires that it have no transparent inputs (their
+// values are in the outputs they spend). It's then
+//
+//	valueBalanceSapling + valueBalanceOrchard + valueBalanceIronwood
+//	+ sum(vpubNew) - sum(vpubOld) - sum(tOut)
+//
+// The second result is false if there are transparent inputs (including the
+// coinbase input) or the values are out of range.
+func (tx *Transaction) Fee() (uint64, bool) {
+	if len(tx.TransparentInputs) > 0 {
+		return 0, false
+	}
+	return tx.fee(nil)
+}
+
+// FeeWithPrevouts returns the transaction fee in zatoshis given the values of
+// the outputs its transparent inputs spend, in input order. The second result
+// is false if there isn't one value per input, or the values are out of range.
+func (tx *Transaction) FeeWithPrevouts(prevoutValues []uint64) (uint64, bool) {
+	if len(prevoutValues) != len(tx.TransparentInputs) {
+		return 0, false
+	}
+	return tx.fee(prevoutValues)
+}
+
+func (tx *Transaction) fee(prevoutValues []uint64) (uint64, bool) {
+	var fee int64
+	// Bounding each term and the running total prevents overflow.
+	add := func(v int64) bool {
+		if v < -maxMoney || v > maxMoney {
+			return false
+		}
+		fee += v
+		return fee >= -8*maxMoney && fee <= 8*maxMoney
+	}
+	if !add(tx.ValueBalanceSapling) {
+		return 0, false
+	}
+	for _, bundle := range []*OrchardBundle{tx.Orchard, tx.Ironwood} {
+		if bundle != nil && !add(bundle.ValueBalance) {
+			return 0, false
+		}
+	}
+	for _, v := range prevoutValues {
+		if v > maxMoney || !add(int64(v)) {
+			return 0, false
+		}
+	}
+	for _, js := range tx.JoinSplits {
+		if js.VpubNew > maxMoney || js.VpubOld > maxMoney ||
+			!add(int64(js.VpubNew)) || !add(-int64(js.VpubOld)) {
+			return 0, false
+		}
+	}
+	for _, to := range tx.TransparentOutputs {
+		if to.Value > maxMoney || !add(-int64(to.Value)) {
+			return 0, false
+		}
+	}
+	if fee < 0 {
+		return 0, false
+	}
+	return uint64(fee), true
 }
 
 // ToCompact converts the given (full) transaction to compact format.
@@ -411,37 +583,40 @@ func (tx *Transaction) ToCompact(index int) *walletrpc.CompactTx {
 	// we don't need to store the vin (transparent inputs) of a coinbase tx
 	var vinLen int
 	if index > 0 {
-		vinLen = len(tx.transparentInputs)
+		vinLen = len(tx.TransparentInputs)
 	}
+	orchardActions, ironwoodActions := tx.orchardActions(), tx.ironwoodActions()
 	ctx := &walletrpc.CompactTx{
-		Index: uint64(index), // index is contextual
-		Txid:  hash32.ToSlice(tx.GetEncodableHash()),
-		//Fee:     0, // TODO: calculate fees
-		Spends:          make([]*walletrpc.CompactSaplingSpend, len(tx.shieldedSpends)),
-		Outputs:         make([]*walletrpc.CompactSaplingOutput, len(tx.shieldedOutputs)),
-		Actions:         make([]*walletrpc.CompactOrchardAction, len(tx.orchardActions)),
-		IronwoodActions: make([]*walletrpc.CompactOrchardAction, len(tx.ironwoodActions)),
+		Index:           uint64(index), // index is contextual
+		Txid:            hash32.ToSlice(tx.GetEncodableHash()),
+		Spends:          make([]*walletrpc.CompactSaplingSpend, len(tx.SaplingSpends)),
+		Outputs:         make([]*walletrpc.CompactSaplingOutput, len(tx.SaplingOutputs)),
+		Actions:         make([]*walletrpc.CompactOrchardAction, len(orchardActions)),
+		IronwoodActions: make([]*walletrpc.CompactOrchardAction, len(ironwoodActions)),
 		Vin:             make([]*walletrpc.CompactTxIn, vinLen),
-		Vout:            make([]*walletrpc.TxOut, len(tx.transparentOutputs)),
+		Vout:            make([]*walletrpc.TxOut, len(tx.TransparentOutputs)),
 	}
-	for i, spend := range tx.shieldedSpends {
+	if fee, ok := tx.Fee(); ok && fee <= math.MaxUint32 {
+		ctx.Fee = uint32(fee)
+	}
+	for i, spend := range tx.SaplingSpends {
 		ctx.Spends[i] = spend.ToCompact()
 	}
-	for i, output := range tx.shieldedOutputs {
+	for i, output := range tx.SaplingOutputs {
 		ctx.Outputs[i] = output.ToCompact()
 	}
-	for i, a := range tx.orchardActions {
+	for i, a := range orchardActions {
 		ctx.Actions[i] = a.ToCompact()
 	}
-	for i, a := range tx.ironwoodActions {
+	for i, a := range ironwoodActions {
 		ctx.IronwoodActions[i] = a.ToCompact()
 	}
 	if vinLen > 0 {
-		for i, tinput := range tx.transparentInputs {
+		for i, tinput := range tx.TransparentInputs {
 			ctx.Vin[i] = tinput.ToCompact()
 		}
 	}
-	for i, toutput := range tx.transparentOutputs {
+	for i, toutput := range tx.TransparentOutputs {
 		ctx.Vout[i] = toutput.ToCompact()
 	}
 	return ctx
@@ -450,82 +625,86 @@ func (tx *Transaction) ToCompact(index int) *walletrpc.CompactTx {
 // parse version 4 transaction data after the nVersionGroupId field.
 func (tx *Transaction) parsePreV5(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
-	var err error
-	s, err = tx.ParseTransparent([]byte(s))
+	rest, err := tx.ParseTransparent([]byte(s))
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip nLockTime")
+	s = rest
+	if !s.ReadUint32(&tx.LockTime) {
+		return nil, fieldError(data, s, "nLockTime", errors.New("could not read nLockTime"))
 	}
 
-	if tx.version > 1 {
-		if (tx.isOverwinterV3() || tx.isSaplingV4()) && !s.Skip(4) {
-			return nil, errors.New("could not skip nExpiryHeight")
+	if tx.Version > 1 {
+		if (tx.isOverwinterV3() || tx.isSaplingV4()) && !s.ReadUint32(&tx.ExpiryHeight) {
+			return nil, fieldError(data, s, "nExpiryHeight", errors.New("could not read nExpiryHeight"))
 		}
 
 		var spendCount, outputCount int
 
 		if tx.isSaplingV4() {
-			if !s.Skip(8) {
-				return nil, errors.New("could not skip valueBalance")
+			if !s.ReadInt64(&tx.ValueBalanceSapling) {
+				return nil, inPool(fieldError(data, s, "valueBalanceSapling", errors.New("could not read valueBalance")), "sapling")
 			}
 			if !s.ReadCompactSize(&spendCount) {
-				return nil, errors.New("could not read nShieldedSpend")
+				return nil, inPool(fieldError(data, s, "vShieldedSpend", errors.New("could not read nShieldedSpend")), "sapling")
 			}
 			if err := rejectCountExceedingRemaining("nShieldedSpend", spendCount, len(s), minSaplingV4SpendBytes); err != nil {
-				return nil, err
+				return nil, inPool(fieldError(data, s, "vShieldedSpend", err), "sapling")
 			}
-			tx.shieldedSpends = make([]spend, spendCount)
+			tx.SaplingSpends = make([]SaplingSpend, spendCount)
 			for i := 0; i < spendCount; i++ {
-				newSpend := &tx.shieldedSpends[i]
-				s, err = newSpend.ParseFromSlice([]byte(s), 4)
+				newSpend := &tx.SaplingSpends[i]
+				rest, err := newSpend.ParseFromSlice([]byte(s), 4)
 				if err != nil {
-					return nil, fmt.Errorf("error parsing shielded Spend: %w", err)
+					return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vShieldedSpend[%d]", i)), "sapling")
 				}
+				s = rest
 			}
 			if !s.ReadCompactSize(&outputCount) {
-				return nil, errors.New("could not read nShieldedOutput")
+				return nil, inPool(fieldError(data, s, "vShieldedOutput", errors.New("could not read nShieldedOutput")), "sapling")
 			}
 			if err := rejectCountExceedingRemaining("nShieldedOutput", outputCount, len(s), minSaplingV4OutputBytes); err != nil {
-				return nil, err
+				return nil, inPool(fieldError(data, s, "vShieldedOutput", err), "sapling")
 			}
-			tx.shieldedOutputs = make([]output, outputCount)
+			tx.SaplingOutputs = make([]SaplingOutput, outputCount)
 			for i := 0; i < outputCount; i++ {
-				newOutput := &tx.shieldedOutputs[i]
-				s, err = newOutput.ParseFromSlice([]byte(s), tx.version)
+				newOutput := &tx.SaplingOutputs[i]
+				rest, err := newOutput.ParseFromSlice([]byte(s), tx.Version)
 				if err != nil {
-					return nil, fmt.Errorf("error parsing shielded Output: %w", err)
+					return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vShieldedOutput[%d]", i)), "sapling")
 				}
+				s = rest
 			}
 		}
 
 		var joinSplitCount int
 		if !s.ReadCompactSize(&joinSplitCount) {
-			return nil, errors.New("could not read nJoinSplit")
+			return nil, inPool(fieldError(data, s, "vJoinSplit", errors.New("could not read nJoinSplit")), "sprout")
 		}
 		if err := rejectCountExceedingRemaining("nJoinSplit", joinSplitCount, len(s), minJoinSplitWireBytes(tx.isGroth16Proof())); err != nil {
-			return nil, err
+			return nil, inPool(fieldError(data, s, "vJoinSplit", err), "sprout")
 		}
 
-		tx.joinSplits = make([]joinSplit, joinSplitCount)
+		tx.JoinSplits = make([]JoinSplit, joinSplitCount)
 		if joinSplitCount > 0 {
 			for i := 0; i < joinSplitCount; i++ {
-				js := &tx.joinSplits[i]
-				s, err = js.ParseFromSlice([]byte(s), tx.isGroth16Proof())
+				js := &tx.JoinSplits[i]
+				rest, err := js.ParseFromSlice([]byte(s), tx.isGroth16Proof())
 				if err != nil {
-					return nil, fmt.Errorf("error parsing JoinSplit: %w", err)
+					return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vJoinSplit[%d]", i)), "sprout")
 				}
+				s = rest
 			}
-			if !s.Skip(32) {
-				return nil, errors.New("could not skip joinSplitPubKey")
+			if !s.ReadBytes((*[]byte)(&tx.JoinSplitPubKey), 32) {
+				return nil, inPool(fieldError(data, s, "joinSplitPubKey", errors.New("could not read joinSplitPubKey")), "sprout")
 			}
-			if !s.Skip(64) {
-				return nil, errors.New("could not skip joinSplitSig")
+			if !s.ReadBytes((*[]byte)(&tx.JoinSplitSig), 64) {
+				return nil, inPool(fieldError(data, s, "joinSplitSig", errors.New("could not read joinSplitSig")), "sprout")
 			}
 		}
-		if tx.isSaplingV4() && spendCount+outputCount > 0 && !s.Skip(64) {
-			return nil, errors.New("could not skip bindingSigSapling")
+		if tx.isSaplingV4() && spendCount+outputCount > 0 &&
+			!s.ReadBytes((*[]byte)(&tx.BindingSigSapling), 64) {
+			return nil, inPool(fieldError(data, s, "bindingSigSapling", errors.New("could not read bindingSigSapling")), "sapling")
 		}
 	}
 	return s, nil
@@ -534,39 +713,38 @@ func (tx *Transaction) parsePreV5(data []byte) ([]byte, error) {
 // parse version 5 transaction data after the nVersionGroupId field.
 func (tx *Transaction) parseV5(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
-	var err error
-	if !s.ReadUint32(&tx.consensusBranchID) {
-		return nil, errors.New("could not read nConsensusBranchId")
+	if !s.ReadUint32(&tx.ConsensusBranchID) {
+		return nil, fieldError(data, s, "nConsensusBranchId", errors.New("could not read nConsensusBranchId"))
 	}
-	if tx.nVersionGroupID != ZIP225_VERSION_GROUP_ID {
+	if tx.VersionGroupID != ZIP225_VERSION_GROUP_ID {
 		// This shouldn't be possible
-		return nil, fmt.Errorf("version group ID 0x%08X must be 0x%08X",
-			tx.nVersionGroupID, ZIP225_VERSION_GROUP_ID)
+		return nil, fieldError(data, s, "nVersionGroupId", fmt.Errorf("version group ID 0x%08X must be 0x%08X",
+			tx.VersionGroupID, ZIP225_VERSION_GROUP_ID))
 	}
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip nLockTime")
+	if !s.ReadUint32(&tx.LockTime) {
+		return nil, fieldError(data, s, "nLockTime", errors.New("could not read nLockTime"))
 	}
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip nExpiryHeight")
+	if !s.ReadUint32(&tx.ExpiryHeight) {
+		return nil, fieldError(data, s, "nExpiryHeight", errors.New("could not read nExpiryHeight"))
 	}
-	s, err = tx.ParseTransparent([]byte(s))
+	rest, err := tx.ParseTransparent([]byte(s))
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
+	s = rest
 
-	s, err = tx.parseSaplingBundle([]byte(s))
+	rest, err = tx.parseSaplingBundle([]byte(s))
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
+	s = rest
 
-	var orchardActions []action
-	s, orchardActions, err = parseOrchardActionShapeBundle([]byte(s), "Orchard")
+	rest, tx.Orchard, err = parseOrchardActionShapeBundle([]byte(s), "Orchard")
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
-	tx.orchardActions = orchardActions
 
-	return s, nil
+	return rest, nil
 }
 
 // parse version 6 transaction data after the nVersionGroupId field.
@@ -578,49 +756,47 @@ func (tx *Transaction) parseV5(data []byte) ([]byte, error) {
 // bundle. The Ironwood bundle reuses the Orchard Action encoding per ZIP 229.
 func (tx *Transaction) parseV6(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
-	var err error
-	if !s.ReadUint32(&tx.consensusBranchID) {
-		return nil, errors.New("could not read nConsensusBranchId")
+	if !s.ReadUint32(&tx.ConsensusBranchID) {
+		return nil, fieldError(data, s, "nConsensusBranchId", errors.New("could not read nConsensusBranchId"))
 	}
-	if tx.nVersionGroupID != NU6_3_VERSION_GROUP_ID {
+	if tx.VersionGroupID != NU6_3_VERSION_GROUP_ID {
 		// This shouldn't be possible
-		return nil, fmt.Errorf("version group ID 0x%08X must be 0x%08X",
-			tx.nVersionGroupID, NU6_3_VERSION_GROUP_ID)
+		return nil, fieldError(data, s, "nVersionGroupId", fmt.Errorf("version group ID 0x%08X must be 0x%08X",
+			tx.VersionGroupID, NU6_3_VERSION_GROUP_ID))
 	}
 	// Like parseV5, do not validate nConsensusBranchId: it identifies the
 	// consensus epoch the transaction is mined in, not the epoch that
 	// introduced the v6 format, so it changes at every network upgrade.
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip nLockTime")
+	if !s.ReadUint32(&tx.LockTime) {
+		return nil, fieldError(data, s, "nLockTime", errors.New("could not read nLockTime"))
 	}
-	if !s.Skip(4) {
-		return nil, errors.New("could not skip nExpiryHeight")
+	if !s.ReadUint32(&tx.ExpiryHeight) {
+		return nil, fieldError(data, s, "nExpiryHeight", errors.New("could not read nExpiryHeight"))
 	}
-	s, err = tx.ParseTransparent([]byte(s))
+	rest, err := tx.ParseTransparent([]byte(s))
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
+	s = rest
 
-	s, err = tx.parseSaplingBundle([]byte(s))
+	rest, err = tx.parseSaplingBundle([]byte(s))
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
+	s = rest
 
-	var orchardActions []action
-	s, orchardActions, err = parseOrchardActionShapeBundle([]byte(s), "Orchard")
+	rest, tx.Orchard, err = parseOrchardActionShapeBundle([]byte(s), "Orchard")
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
-	tx.orchardActions = orchardActions
+	s = rest
 
-	var ironwoodActions []action
-	s, ironwoodActions, err = parseOrchardActionShapeBundle([]byte(s), "Ironwood")
+	rest, tx.Ironwood, err = parseOrchardActionShapeBundle([]byte(s), "Ironwood")
 	if err != nil {
-		return nil, err
+		return nil, nestedError(err, data, s, "")
 	}
-	tx.ironwoodActions = ironwoodActions
 
-	return s, nil
+	return rest, nil
 }
 
 // parseSaplingBundle parses the Sapling spend/output/proof/signature bundle
@@ -628,112 +804,128 @@ func (tx *Transaction) parseV6(data []byte) ([]byte, error) {
 // parsing the transparent part first.
 func (tx *Transaction) parseSaplingBundle(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
-	var err error
 	var spendCount, outputCount int
 	if !s.ReadCompactSize(&spendCount) {
-		return nil, errors.New("could not read nShieldedSpend")
+		return nil, inPool(fieldError(data, s, "vShieldedSpend", errors.New("could not read nShieldedSpend")), "sapling")
 	}
 	if err := rejectCountExceedingRemaining("nShieldedSpend", spendCount, len(s), minSaplingV5SpendBytes); err != nil {
-		return nil, err
+		return nil, inPool(fieldError(data, s, "vShieldedSpend", err), "sapling")
 	}
 	if spendCount >= (1 << 16) {
-		return nil, fmt.Errorf("spendCount (%d) must be less than 2^16", spendCount)
+		return nil, inPool(fieldError(data, s, "vShieldedSpend",
+			fmt.Errorf("spendCount (%d) must be less than 2^16", spendCount)), "sapling")
 	}
-	tx.shieldedSpends = make([]spend, spendCount)
+	tx.SaplingSpends = make([]SaplingSpend, spendCount)
 	for i := 0; i < spendCount; i++ {
-		newSpend := &tx.shieldedSpends[i]
-		s, err = newSpend.ParseFromSlice([]byte(s), tx.version)
+		newSpend := &tx.SaplingSpends[i]
+		rest, err := newSpend.ParseFromSlice([]byte(s), tx.Version)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing shielded Spend: %w", err)
+			return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vShieldedSpend[%d]", i)), "sapling")
 		}
+		s = rest
 	}
 	if !s.ReadCompactSize(&outputCount) {
-		return nil, errors.New("could not read nShieldedOutput")
+		return nil, inPool(fieldError(data, s, "vShieldedOutput", errors.New("could not read nShieldedOutput")), "sapling")
 	}
 	if err := rejectCountExceedingRemaining("nShieldedOutput", outputCount, len(s), minSaplingV5OutputBytes); err != nil {
-		return nil, err
+		return nil, inPool(fieldError(data, s, "vShieldedOutput", err), "sapling")
 	}
 	if outputCount >= (1 << 16) {
-		return nil, fmt.Errorf("outputCount (%d) must be less than 2^16", outputCount)
+		return nil, inPool(fieldError(data, s, "vShieldedOutput",
+			fmt.Errorf("outputCount (%d) must be less than 2^16", outputCount)), "sapling")
 	}
-	tx.shieldedOutputs = make([]output, outputCount)
+	tx.SaplingOutputs = make([]SaplingOutput, outputCount)
 	for i := 0; i < outputCount; i++ {
-		newOutput := &tx.shieldedOutputs[i]
-		s, err = newOutput.ParseFromSlice([]byte(s), tx.version)
+		newOutput := &tx.SaplingOutputs[i]
+		rest, err := newOutput.ParseFromSlice([]byte(s), tx.Version)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing shielded Output: %w", err)
+			return nil, inPool(nestedError(err, data, s, fmt.Sprintf("vShieldedOutput[%d]", i)), "sapling")
 		}
+		s = rest
 	}
-	if spendCount+outputCount > 0 && !s.Skip(8) {
-		return nil, errors.New("could not read valueBalance")
+	if spendCount+outputCount > 0 && !s.ReadInt64(&tx.ValueBalanceSapling) {
+		return nil, inPool(fieldError(data, s, "valueBalanceSapling", errors.New("could not read valueBalance")), "sapling")
 	}
-	if spendCount > 0 && !s.Skip(32) {
-		return nil, errors.New("could not skip anchorSapling")
+	if spendCount > 0 && !s.ReadBytes((*[]byte)(&tx.SaplingAnchor), 32) {
+		return nil, inPool(fieldError(data, s, "anchorSapling", errors.New("could not read anchorSapling")), "sapling")
 	}
-	if !s.Skip(192 * spendCount) {
-		return nil, errors.New("could not skip vSpendProofsSapling")
+	for i := range tx.SaplingSpends {
+		if !s.ReadBytes((*[]byte)(&tx.SaplingSpends[i].Zkproof), 192) {
+			return nil, inPool(fieldError(data, s, fmt.Sprintf("vShieldedSpend[%d].zkproof", i),
+				errors.New("could not read vSpendProofsSapling")), "sapling")
+		}
 	}
-	if !s.Skip(64 * spendCount) {
-		return nil, errors.New("could not skip vSpendAuthSigsSapling")
+	for i := range tx.SaplingSpends {
+		if !s.ReadBytes((*[]byte)(&tx.SaplingSpends[i].SpendAuthSig), 64) {
+			return nil, inPool(fieldError(data, s, fmt.Sprintf("vShieldedSpend[%d].spendAuthSig", i),
+				errors.New("could not read vSpendAuthSigsSapling")), "sapling")
+		}
 	}
-	if !s.Skip(192 * outputCount) {
-		return nil, errors.New("could not skip vOutputProofsSapling")
+	for i := range tx.SaplingOutputs {
+		if !s.ReadBytes((*[]byte)(&tx.SaplingOutputs[i].Zkproof), 192) {
+			return nil, inPool(fieldError(data, s, fmt.Sprintf("vShieldedOutput[%d].zkproof", i),
+				errors.New("could not read vOutputProofsSapling")), "sapling")
+		}
 	}
-	if spendCount+outputCount > 0 && !s.Skip(64) {
-		return nil, errors.New("could not skip bindingSigSapling")
+	if spendCount+outputCount > 0 && !s.ReadBytes((*[]byte)(&tx.BindingSigSapling), 64) {
+		return nil, inPool(fieldError(data, s, "bindingSigSapling", errors.New("could not read bindingSigSapling")), "sapling")
 	}
 	return s, nil
 }
 
 // parseOrchardActionShapeBundle parses the action-field layout shared by
-// Orchard and Ironwood, with pool used for error messages. Per ZIP 229,
-// Ironwood Action descriptions use the same OrchardAction encoding.
-func parseOrchardActionShapeBundle(data []byte, pool string) ([]byte, []action, error) {
+// Orchard and Ironwood, with pool used for error messages. It returns a nil
+// bundle if there are no actions. Per ZIP 229, Ironwood Action descriptions
+// use the same OrchardAction encoding.
+func parseOrchardActionShapeBundle(data []byte, pool string) ([]byte, *OrchardBundle, error) {
 	s := bytestring.String(data)
-	var err error
+	field := strings.ToLower(pool)
+	fail := func(name string, err error) ([]byte, *OrchardBundle, error) {
+		return nil, nil, inPool(fieldError(data, s, field+"."+name, err), field)
+	}
 	var actionsCount int
 	if !s.ReadCompactSize(&actionsCount) {
-		return nil, nil, fmt.Errorf("could not read nActions%s", pool)
+		return fail("actions", fmt.Errorf("could not read nActions%s", pool))
 	}
 	if err := rejectCountExceedingRemaining("nActions"+pool, actionsCount, len(s), minOrchardActionBytes); err != nil {
-		return nil, nil, err
+		return fail("actions", err)
 	}
 	if actionsCount >= (1 << 16) {
-		return nil, nil, fmt.Errorf("actionsCount (%d) must be less than 2^16", actionsCount)
+		return fail("actions", fmt.Errorf("actionsCount (%d) must be less than 2^16", actionsCount))
+	}
+	if actionsCount == 0 {
+		return s, nil, nil
 	}
-	actions := make([]action, actionsCount)
+	bundle := &OrchardBundle{Actions: make([]OrchardAction, actionsCount)}
 	for i := 0; i < actionsCount; i++ {
-		a := &actions[i]
-		s, err = a.ParseFromSlice([]byte(s))
+		a := &bundle.Actions[i]
+		rest, err := a.ParseFromSlice([]byte(s))
 		if err != nil {
-			return nil, nil, fmt.Errorf("error parsing %s action: %w", pool, err)
+			return nil, nil, inPool(nestedError(err, data, s, fmt.Sprintf("%s.actions[%d]", field, i)), field)
 		}
+		s = rest
 	}
-	if actionsCount > 0 {
-		if !s.Skip(1) {
-			return nil, nil, fmt.Errorf("could not skip flags%s", pool)
-		}
-		if !s.Skip(8) {
-			return nil, nil, fmt.Errorf("could not skip valueBalance%s", pool)
-		}
-		if !s.Skip(32) {
-			return nil, nil, fmt.Errorf("could not skip anchor%s", pool)
-		}
-		var proofsCount int
-		if !s.ReadCompactSize(&proofsCount) {
-			return nil, nil, fmt.Errorf("could not read sizeProofs%s", pool)
-		}
-		if !s.Skip(proofsCount) {
-			return nil, nil, fmt.Errorf("could not skip proofs%s", pool)
-		}
-		if !s.Skip(64 * actionsCount) {
-			return nil, nil, fmt.Errorf("could not skip vSpendAuthSigs%s", pool)
-		}
-		if !s.Skip(64) {
-			return nil, nil, fmt.Errorf("could not skip bindingSig%s", pool)
+	if !s.ReadByte(&bundle.Flags) {
+		return fail("flags", fmt.Errorf("could not read flags%s", pool))
+	}
+	if !s.ReadInt64(&bundle.ValueBalance) {
+		return fail("valueBalance", fmt.Errorf("could not read valueBalance%s", pool))
+	}
+	if !s.ReadBytes((*[]byte)(&bundle.Anchor), 32) {
+		return fail("anchor", fmt.Errorf("could not read anchor%s", pool))
+	}
+	if !s.ReadCompactLengthPrefixed((*bytestring.String)(&bundle.Proofs)) {
+		return fail("proofs", fmt.Errorf("could not read proofs%s", pool))
+	}
+	for i := range bundle.Actions {
+		if !s.ReadBytes((*[]byte)(&bundle.Actions[i].SpendAuthSig), 64) {
+			return fail(fmt.Sprintf("actions[%d].spendAuthSig", i), fmt.Errorf("could not read vSpendAuthSigs%s", pool))
 		}
 	}
-	return s, actions, nil
+	if !s.ReadBytes((*[]byte)(&bundle.BindingSig), 64) {
+		return fail("bindingSig", fmt.Errorf("could not read bindingSig%s", pool))
+	}
+	return s, bundle, nil
 }
 
 // The logic in the following version helpers is copied from
@@ -753,82 +945,302 @@ const NU6_3_VERSION_GROUP_ID uint32 = 0xD884B698
 const NU6_3_CONSENSUS_BRANCH_ID uint32 = 0x37A5165B
 
 func (tx *Transaction) isOverwinterV3() bool {
-	return tx.fOverwintered &&
-		tx.nVersionGroupID == OVERWINTER_VERSION_GROUP_ID &&
-		tx.version == OVERWINTER_TX_VERSION
+	return tx.Overwintered &&
+		tx.VersionGroupID == OVERWINTER_VERSION_GROUP_ID &&
+		tx.Version == OVERWINTER_TX_VERSION
 }
 
 func (tx *Transaction) isSaplingV4() bool {
-	return tx.fOverwintered &&
-		tx.nVersionGroupID == SAPLING_VERSION_GROUP_ID &&
-		tx.version == SAPLING_TX_VERSION
+	return tx.Overwintered &&
+		tx.VersionGroupID == SAPLING_VERSION_GROUP_ID &&
+		tx.Version == SAPLING_TX_VERSION
 }
 
 func (tx *Transaction) isZip225V5() bool {
-	return tx.fOverwintered &&
-		tx.nVersionGroupID == ZIP225_VERSION_GROUP_ID &&
-		tx.version == ZIP225_TX_VERSION
+	return tx.Overwintered &&
+		tx.VersionGroupID == ZIP225_VERSION_GROUP_ID &&
+		tx.Version == ZIP225_TX_VERSION
 }
 
 func (tx *Transaction) isZip229V6() bool {
-	return tx.fOverwintered &&
-		tx.nVersionGroupID == NU6_3_VERSION_GROUP_ID &&
-		tx.version == ZIP229_TX_VERSION
+	return tx.Overwintered &&
+		tx.VersionGroupID == NU6_3_VERSION_GROUP_ID &&
+		tx.Version == ZIP229_TX_VERSION
 }
 
 func (tx *Transaction) isGroth16Proof() bool {
 	// Sapling changed the joinSplit proof from PHGR (BCTV14) to Groth16;
 	// this applies also to versions beyond Sapling.
-	return tx.fOverwintered &&
-		tx.version >= SAPLING_TX_VERSION
+	return tx.Overwintered &&
+		tx.Version >= SAPLING_TX_VERSION
 }
 
-// ParseFromSlice deserializes a single transaction from the given data.
+// ParseFromSlice deserializes a single transaction from the given data. If
+// the transaction can't be parsed, the error is a *ParseError.
 func (tx *Transaction) ParseFromSlice(data []byte) ([]byte, error) {
 	s := bytestring.String(data)
 
-	// declare here to prevent shadowing problems in cryptobyte assignments
-	var err error
-
 	var header uint32
 	if !s.ReadUint32(&header) {
-		return nil, errors.New("could not read header")
+		return nil, fieldError(data, s, "header", errors.New("could not read header"))
 	}
 
-	tx.fOverwintered = (header >> 31) == 1
-	tx.version = header & 0x7FFFFFFF
+	tx.Overwintered = (header >> 31) == 1
+	tx.Version = header & 0x7FFFFFFF
 
-	if tx.fOverwintered {
-		if !s.ReadUint32(&tx.nVersionGroupID) {
-			return nil, errors.New("could not read nVersionGroupId")
+	if tx.Overwintered {
+		if !s.ReadUint32(&tx.VersionGroupID) {
+			return nil, fieldError(data, s, "nVersionGroupId", errors.New("could not read nVersionGroupId"))
 		}
 	}
 
-	if tx.fOverwintered &&
+	if tx.Overwintered &&
 		!(tx.isOverwinterV3() || tx.isSaplingV4() || tx.isZip225V5() || tx.isZip229V6()) {
-		return nil, errors.New("unknown transaction format")
+		return nil, &ParseError{Field: "header", Version: tx.Version, Err: errors.New("unknown transaction format")}
 	}
 	// parse the main part of the transaction
+	var rest []byte
+	var err error
 	if tx.isZip225V5() {
-		s, err = tx.parseV5([]byte(s))
+		rest, err = tx.parseV5([]byte(s))
 	} else if tx.isZip229V6() {
-		s, err = tx.parseV6([]byte(s))
+		rest, err = tx.parseV6([]byte(s))
 	} else {
-		s, err = tx.parsePreV5([]byte(s))
+		rest, err = tx.parsePreV5([]byte(s))
 	}
 	if err != nil {
-		return nil, err
+		e := nestedError(err, data, s, "")
+		e.Version = tx.Version
+		return nil, e
 	}
-	// TODO: implement rawBytes with MarshalBinary() instead
-	txLen := len(data) - len(s)
+	// MarshalBinary would reproduce these bytes exactly (the encodings the
+	// parser accepts are canonical), but keeping them is cheaper.
+	txLen := len(data) - len(rest)
 	tx.rawBytes = data[:txLen]
 
-	return []byte(s), nil
+	return rest, nil
+}
+
+// txBuilder serializes a transaction. It records the first fixed-size field
+// that has the wrong length, since the result wouldn't parse.
+type txBuilder struct {
+	bytestring.Builder
+	err error
+}
+
+func (b *txBuilder) addFixed(name string, v []byte, n int) {
+	if len(v) != n && b.err == nil {
+		b.err = fmt.Errorf("%s is %d bytes, must be %d", name, len(v), n)
+	}
+	b.AddBytes(v)
+}
+
+// MarshalBinary returns the transaction in serialized form, in the format
+// of its version; it's the inverse of ParseFromSlice. It returns an error if
+// the transaction's content can't be represented in that format.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	b := &txBuilder{}
+	header := tx.Version
+	if tx.Overwintered {
+		header |= 1 << 31
+	}
+	b.AddUint32(header)
+	if tx.Overwintered {
+		if !(tx.isOverwinterV3() || tx.isSaplingV4() || tx.isZip225V5() || tx.isZip229V6()) {
+			return nil, errors.New("unknown transaction format")
+		}
+		b.AddUint32(tx.VersionGroupID)
+	}
+	if err := tx.checkPools(); err != nil {
+		return nil, err
+	}
+	if tx.isZip225V5() || tx.isZip229V6() {
+		tx.marshalV5(b)
+	} else {
+		tx.marshalPreV5(b)
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.Bytes(), nil
+}
+
+// checkPools returns an error if the transaction has content that its
+// version's format has no place for.
+func (tx *Transaction) checkPools() error {
+	hasSapling := len(tx.SaplingSpends)+len(tx.SaplingOutputs) > 0
+	switch {
+	case hasSapling && !(tx.isSaplingV4() || tx.isZip225V5() || tx.isZip229V6()):
+		return fmt.Errorf("version %d transaction can't have Sapling spends or outputs", tx.Version)
+	case len(tx.JoinSplits) > 0 && (tx.Version < 2 || tx.isZip225V5() || tx.isZip229V6()):
+		return fmt.Errorf("version %d transaction can't have JoinSplits", tx.Version)
+	case len(tx.orchardActions()) > 0 && !(tx.isZip225V5() || tx.isZip229V6()):
+		return fmt.Errorf("version %d transaction can't have Orchard actions", tx.Version)
+	case len(tx.ironwoodActions()) > 0 && !tx.isZip229V6():
+		return fmt.Errorf("version %d transaction can't have Ironwood actions", tx.Version)
+	}
+	return nil
+}
+
+// marshalTransparent is the inverse of ParseTransparent.
+func (tx *Transaction) marshalTransparent(b *txBuilder) {
+	b.AddCompactSize(len(tx.TransparentInputs))
+	for _, in := range tx.TransparentInputs {
+		b.AddBytes(in.PrevTxHash[:])
+		b.AddUint32(in.PrevTxOutIndex)
+		b.AddCompactLengthPrefixed(in.ScriptSig)
+		b.AddUint32(in.SequenceNumber)
+	}
+	b.AddCompactSize(len(tx.TransparentOutputs))
+	for _, out := range tx.TransparentOutputs {
+		b.AddUint64(out.Value)
+		b.AddCompactLengthPrefixed(out.Script)
+	}
+}
+
+// marshalPreV5 is the inverse of parsePreV5.
+func (tx *Transaction) marshalPreV5(b *txBuilder) {
+	tx.marshalTransparent(b)
+	b.AddUint32(tx.LockTime)
+	if tx.Version <= 1 {
+		return
+	}
+	if tx.isOverwinterV3() || tx.isSaplingV4() {
+		b.AddUint32(tx.ExpiryHeight)
+	}
+	if tx.isSaplingV4() {
+		b.AddInt64(tx.ValueBalanceSapling)
+		b.AddCompactSize(len(tx.SaplingSpends))
+		for _, spend := range tx.SaplingSpends {
+			b.addFixed("Sapling spend cv", spend.Cv, 32)
+			b.addFixed("Sapling spend anchor", spend.Anchor, 32)
+			b.addFixed("Sapling spend nullifier", spend.Nullifier, 32)
+			b.addFixed("Sapling spend rk", spend.Rk, 32)
+			b.addFixed("Sapling spend zkproof", spend.Zkproof, 192)
+			b.addFixed("Sapling spendAuthSig", spend.SpendAuthSig, 64)
+		}
+		b.AddCompactSize(len(tx.SaplingOutputs))
+		for _, output := range tx.SaplingOutputs {
+			marshalSaplingOutput(b, output)
+			b.addFixed("Sapling output zkproof", output.Zkproof, 192)
+		}
+	}
+	b.AddCompactSize(len(tx.JoinSplits))
+	for _, js := range tx.JoinSplits {
+		b.AddUint64(js.VpubOld)
+		b.AddUint64(js.VpubNew)
+		b.addFixed("JoinSplit anchor", js.Anchor, 32)
+		for _, nf := range js.Nullifiers {
+			b.addFixed("JoinSplit nullifier", nf, 32)
+		}
+		for _, cm := range js.Commitments {
+			b.addFixed("JoinSplit commitment", cm, 32)
+		}
+		b.addFixed("JoinSplit ephemeralKey", js.EphemeralKey, 32)
+		b.addFixed("JoinSplit randomSeed", js.RandomSeed, 32)
+		for _, mac := range js.Vmacs {
+			b.addFixed("JoinSplit vmac", mac, 32)
+		}
+		if tx.isGroth16Proof() {
+			b.addFixed("JoinSplit Groth16 proof", js.Proof, 192)
+		} else {
+			b.addFixed("JoinSplit PHGR proof", js.Proof, 296)
+		}
+		for _, ct := range js.EncCiphertexts {
+			b.addFixed("JoinSplit encCiphertext", ct, 601)
+		}
+	}
+	if len(tx.JoinSplits) > 0 {
+		b.addFixed("joinSplitPubKey", tx.JoinSplitPubKey, 32)
+		b.addFixed("joinSplitSig", tx.JoinSplitSig, 64)
+	}
+	if tx.isSaplingV4() && len(tx.SaplingSpends)+len(tx.SaplingOutputs) > 0 {
+		b.addFixed("bindingSigSapling", tx.BindingSigSapling, 64)
+	}
+}
+
+// marshalV5 is the inverse of parseV5 and parseV6.
+func (tx *Transaction) marshalV5(b *txBuilder) {
+	b.AddUint32(tx.ConsensusBranchID)
+	b.AddUint32(tx.LockTime)
+	b.AddUint32(tx.ExpiryHeight)
+	tx.marshalTransparent(b)
+
+	b.AddCompactSize(len(tx.SaplingSpends))
+	for _, spend := range tx.SaplingSpends {
+		b.addFixed("Sapling spend cv", spend.Cv, 32)
+		b.addFixed("Sapling spend nullifier", spend.Nullifier, 32)
+		b.addFixed("Sapling spend rk", spend.Rk, 32)
+	}
+	b.AddCompactSize(len(tx.SaplingOutputs))
+	for _, output := range tx.SaplingOutputs {
+		marshalSaplingOutput(b, output)
+	}
+	if len(tx.SaplingSpends)+len(tx.SaplingOutputs) > 0 {
+		b.AddInt64(tx.ValueBalanceSapling)
+	}
+	if len(tx.SaplingSpends) > 0 {
+		b.addFixed("anchorSapling", tx.SaplingAnchor, 32)
+	}
+	for _, spend := range tx.SaplingSpends {
+		b.addFixed("Sapling spend zkproof", spend.Zkproof, 192)
+	}
+	for _, spend := range tx.SaplingSpends {
+		b.addFixed("Sapling spendAuthSig", spend.SpendAuthSig, 64)
+	}
+	for _, output := range tx.SaplingOutputs {
+		b.addFixed("Sapling output zkproof", output.Zkproof, 192)
+	}
+	if len(tx.SaplingSpends)+len(tx.SaplingOutputs) > 0 {
+		b.addFixed("bindingSigSapling", tx.BindingSigSapling, 64)
+	}
+
+	marshalOrchardActionShapeBundle(b, tx.Orchard, "Orchard")
+	if tx.isZip229V6() {
+		marshalOrchardActionShapeBundle(b, tx.Ironwood, "Ironwood")
+	}
+}
+
+// marshalSaplingOutput writes the fields of a Sapling output that all
+// transaction versions serialize together.
+func marshalSaplingOutput(b *txBuilder, output SaplingOutput) {
+	b.addFixed("Sapling output cv", output.Cv, 32)
+	b.addFixed("Sapling output cmu", output.Cmu, 32)
+	b.addFixed("Sapling output ephemeralKey", output.EphemeralKey, 32)
+	b.addFixed("Sapling output encCiphertext", output.EncCiphertext, 580)
+	b.addFixed("Sapling output outCiphertext", output.OutCiphertext, 80)
+}
+
+// marshalOrchardActionShapeBundle is the inverse of
+// parseOrchardActionShapeBundle; a nil bundle is written as no actions.
+func marshalOrchardActionShapeBundle(b *txBuilder, bundle *OrchardBundle, pool string) {
+	if bundle == nil || len(bundle.Actions) == 0 {
+		b.AddCompactSize(0)
+		return
+	}
+	b.AddCompactSize(len(bundle.Actions))
+	for _, a := range bundle.Actions {
+		b.addFixed(pool+" action cv", a.Cv, 32)
+		b.addFixed(pool+" action nullifier", a.Nullifier, 32)
+		b.addFixed(pool+" action rk", a.Rk, 32)
+		b.addFixed(pool+" action cmx", a.Cmx, 32)
+		b.addFixed(pool+" action ephemeralKey", a.EphemeralKey, 32)
+		b.addFixed(pool+" action encCiphertext", a.EncCiphertext, 580)
+		b.addFixed(pool+" action outCiphertext", a.OutCiphertext, 80)
+	}
+	b.AddUint8(bundle.Flags)
+	b.AddInt64(bundle.ValueBalance)
+	b.addFixed("anchor"+pool, bundle.Anchor, 32)
+	b.AddCompactLengthPrefixed(bundle.Proofs)
+	for _, a := range bundle.Actions {
+		b.addFixed(pool+" spendAuthSig", a.SpendAuthSig, 64)
+	}
+	b.addFixed("bindingSig"+pool, bundle.BindingSig, 64)
 }
 
 // NewTransaction is the constructor for a full transaction.
 func NewTransaction() *Transaction {
 	return &Transaction{
-		rawTransaction: new(rawTransaction),
+		TransactionData: new(TransactionData),
 	}
 }
@@ -5,11 +5,16 @@ package parser
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"os"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/zcash/lightwalletd/hash32"
 )
 
 // Some of these values may be "null" (which translates to nil in Go) in
@@ -17,16 +22,26 @@ import (
 // variable is non-null. (There is an "optional" package we could use for
 // these but it doesn't seem worth pulling it in.)
 type TxTestData struct {
-	Tx                 string
-	Txid               string
-	Version            int
-	NVersionGroupId    int
-	NConsensusBranchId int
-	Tx_in_count        int
-	Tx_out_count       int
-	NSpendsSapling     int
-	NoutputsSapling    int
-	NActionsOrchard    int
+	Tx                  string
+	Txid                string
+	Version             int
+	NVersionGroupId     int
+	NConsensusBranchId  int
+	Lock_time           uint32
+	NExpiryHeight       uint32
+	Tx_in_count         int
+	Tx_out_count        int
+	NSpendsSapling      int
+	NoutputsSapling     int
+	ValueBalanceSapling int64
+	AnchorSapling       string
+	BindingSigSapling   string
+	NActionsOrchard     int
+	FlagsOrchard        byte
+	ValueBalanceOrchard int64
+	AnchorOrchard       string
+	ProofsOrchard       string
+	BindingSigOrchard   string
 }
 
 // https://jhall.io/posts/go-json-tricks-array-as-structs/
@@ -40,11 +55,36 @@ func (r *TxTestData) UnmarshalJSON(p []byte) error {
 	r.Version = int(t[2].(float64))
 	r.NVersionGroupId = int(t[3].(float64))
 	r.NConsensusBranchId = int(t[4].(float64))
+	r.Lock_time = uint32(t[5].(float64))
+	r.NExpiryHeight = uint32(t[6].(float64))
 	r.Tx_in_count = int(t[7].(float64))
 	r.Tx_out_count = int(t[8].(float64))
 	r.NSpendsSapling = int(t[9].(float64))
 	r.NoutputsSapling = int(t[10].(float64))
 	r.NActionsOrchard = int(t[14].(float64))
+	// The value balances are null if there are no Sapling spends and
+	// outputs, or no Orchard actions; the parser then leaves them zero.
+	if t[11] != nil {
+		r.ValueBalanceSapling = int64(t[11].(float64))
+	}
+	if t[16] != nil {
+		r.ValueBalanceOrchard = int64(t[16].(float64))
+	}
+	// Likewise the other bundle fields; a missing byte string is empty.
+	str := func(v any) string {
+		if v == nil {
+			return ""
+		}
+		return v.(string)
+	}
+	r.AnchorSapling = str(t[12])
+	r.BindingSigSapling = str(t[13])
+	if t[15] != nil {
+		r.FlagsOrchard = byte(t[15].(float64))
+	}
+	r.AnchorOrchard = str(t[17])
+	r.ProofsOrchard = str(t[18])
+	r.BindingSigOrchard = str(t[19])
 	return nil
 }
 
@@ -89,30 +129,135 @@ func TestV5TransactionParser(t *testing.T) {
 		// Currently, we can't check the txid because we get that from
 		// zcashd (getblock rpc) rather than computing it ourselves.
 		// https://github.com/zcash/lightwalletd/issues/392
-		if tx.version != uint32(txtestdata.Version) {
+		if tx.Version != uint32(txtestdata.Version) {
 			t.Fatal("version miscompare")
 		}
-		if tx.nVersionGroupID != uint32(txtestdata.NVersionGroupId) {
+		if tx.VersionGroupID != uint32(txtestdata.NVersionGroupId) {
 			t.Fatal("nVersionGroupId miscompare")
 		}
-		if tx.consensusBranchID != uint32(txtestdata.NConsensusBranchId) {
+		if tx.ConsensusBranchID != uint32(txtestdata.NConsensusBranchId) {
 			t.Fatal("consensusBranchID miscompare")
 		}
-		if len(tx.transparentInputs) != int(txtestdata.Tx_in_count) {
+		if len(tx.TransparentInputs) != int(txtestdata.Tx_in_count) {
 			t.Fatal("tx_in_count miscompare")
 		}
-		if len(tx.transparentOutputs) != int(txtestdata.Tx_out_count) {
+		if len(tx.TransparentOutputs) != int(txtestdata.Tx_out_count) {
 			t.Fatal("tx_out_count miscompare")
 		}
-		if len(tx.shieldedSpends) != int(txtestdata.NSpendsSapling) {
+		if len(tx.SaplingSpends) != int(txtestdata.NSpendsSapling) {
 			t.Fatal("NSpendsSapling miscompare")
 		}
-		if len(tx.shieldedOutputs) != int(txtestdata.NoutputsSapling) {
+		if len(tx.SaplingOutputs) != int(txtestdata.NoutputsSapling) {
 			t.Fatal("NOutputsSapling miscompare")
 		}
-		if len(tx.orchardActions) != int(txtestdata.NActionsOrchard) {
+		if len(tx.orchardActions()) != int(txtestdata.NActionsOrchard) {
 			t.Fatal("NActionsOrchard miscompare")
 		}
+		if tx.ValueBalanceSapling != txtestdata.ValueBalanceSapling {
+			t.Fatal("valueBalanceSapling miscompare")
+		}
+		if tx.LockTime != txtestdata.Lock_time {
+			t.Fatal("lock_time miscompare")
+		}
+		if tx.ExpiryHeight != txtestdata.NExpiryHeight {
+			t.Fatal("nExpiryHeight miscompare")
+		}
+		// The test vectors show anchors in reversed (display) order, and
+		// give a Sapling anchor even if there are no spends to serialize it.
+		if len(tx.SaplingSpends) > 0 && reversedHex(tx.SaplingAnchor) != txtestdata.AnchorSapling {
+			t.Fatal("anchorSapling miscompare")
+		}
+		if hex.EncodeToString(tx.BindingSigSapling) != txtestdata.BindingSigSapling {
+			t.Fatal("bindingSigSapling miscompare")
+		}
+		orchard := tx.Orchard
+		if orchard == nil {
+			orchard = &OrchardBundle{}
+		}
+		if orchard.Flags != txtestdata.FlagsOrchard {
+			t.Fatal("flagsOrchard miscompare")
+		}
+		if orchard.ValueBalance != txtestdata.ValueBalanceOrchard {
+			t.Fatal("valueBalanceOrchard miscompare")
+		}
+		if reversedHex(orchard.Anchor) != txtestdata.AnchorOrchard {
+			t.Fatal("anchorOrchard miscompare")
+		}
+		if hex.EncodeToString(orchard.Proofs) != txtestdata.ProofsOrchard {
+			t.Fatal("proofsOrchard miscompare")
+		}
+		if hex.EncodeToString(orchard.BindingSig) != txtestdata.BindingSigOrchard {
+			t.Fatal("bindingSigOrchard miscompare")
+		}
+	}
+}
+
+// reversedHex returns the hex encoding of the given bytes in reverse order.
+func reversedHex(b []byte) string {
+	r := slices.Clone(b)
+	slices.Reverse(r)
+	return hex.EncodeToString(r)
+}
+
+func TestTransactionJSON(t *testing.T) {
+	var raw bytes.Buffer
+	raw.Write([]byte{
+		0x06, 0x00, 0x00, 0x80, // fOverwintered | version 6
+		0x98, 0xb6, 0x84, 0xd8, // version group ID (NU6.3)
+		0x5b, 0x16, 0xa5, 0x37, // consensus branch ID (NU6.3)
+		0x07, 0x00, 0x00, 0x00, // lock time
+		0x09, 0x00, 0x00, 0x00, // expiry height
+		0x00,                                           // tx_in_count
+		0x01, 0xb8, 0x0b, 0, 0, 0, 0, 0, 0, 0x01, 0x51, // tx_out_count, 3000 zatoshis, script OP_TRUE
+		0x00, // nShieldedSpend
+		0x00, // nShieldedOutput
+	})
+	appendOrchardLikeBundle(&raw, 0, 0)     // Orchard bundle
+	appendOrchardLikeBundle(&raw, 1, 10000) // Ironwood bundle
+
+	tx := NewTransaction()
+	if _, err := tx.ParseFromSlice(raw.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	tx.SetTxID(hash32.T{1})
+	j, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(j, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["txid"] != hash32.Encode(hash32.Reverse(hash32.T{1})) ||
+		decoded["size"] != float64(raw.Len()) ||
+		decoded["version"] != float64(6) ||
+		decoded["locktime"] != float64(7) ||
+		decoded["expiryheight"] != float64(9) {
+		t.Fatal("unexpected JSON", string(j))
+	}
+	if _, ok := decoded["orchard"]; ok {
+		t.Fatal("an empty Orchard bundle should be omitted")
+	}
+	vout := decoded["vout"].([]any)[0].(map[string]any)
+	if vout["valueZat"] != float64(3000) || vout["scriptPubKey"] != "51" {
+		t.Fatal("unexpected vout JSON", vout)
+	}
+	ironwood := decoded["ironwood"].(map[string]any)
+	action := ironwood["actions"].([]any)[0].(map[string]any)
+	if ironwood["valueBalanceZat"] != float64(10000) ||
+		action["cv"] != strings.Repeat("01", 32) ||
+		action["spendAuthSig"] != strings.Repeat("00", 64) {
+		t.Fatal("unexpected Ironwood JSON", ironwood)
+	}
+
+	// The decoded model round-trips through JSON.
+	var data TransactionData
+	if err := json.Unmarshal(j, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data.Ironwood.Actions[0].Cmx, tx.Ironwood.Actions[0].Cmx) ||
+		!bytes.Equal(data.TransparentOutputs[0].Script, []byte{0x51}) {
+		t.Fatal("JSON round trip miscompare")
 	}
 }
 
@@ -138,31 +283,31 @@ func TestZip229V6TransactionParser(t *testing.T) {
 	if len(rest) != 0 {
 		t.Fatalf("Test did not consume entire buffer, %d remaining", len(rest))
 	}
-	if tx.version != ZIP229_TX_VERSION {
+	if tx.Version != ZIP229_TX_VERSION {
 		t.Fatal("version miscompare")
 	}
-	if tx.nVersionGroupID != NU6_3_VERSION_GROUP_ID {
+	if tx.VersionGroupID != NU6_3_VERSION_GROUP_ID {
 		t.Fatal("nVersionGroupId miscompare")
 	}
-	if tx.consensusBranchID != NU6_3_CONSENSUS_BRANCH_ID {
+	if tx.ConsensusBranchID != NU6_3_CONSENSUS_BRANCH_ID {
 		t.Fatal("consensusBranchID miscompare")
 	}
-	if len(tx.transparentInputs) != 0 {
+	if len(tx.TransparentInputs) != 0 {
 		t.Fatal("tx_in_count miscompare")
 	}
-	if len(tx.transparentOutputs) != 0 {
+	if len(tx.TransparentOutputs) != 0 {
 		t.Fatal("tx_out_count miscompare")
 	}
-	if len(tx.shieldedSpends) != 0 {
+	if len(tx.SaplingSpends) != 0 {
 		t.Fatal("NSpendsSapling miscompare")
 	}
-	if len(tx.shieldedOutputs) != 0 {
+	if len(tx.SaplingOutputs) != 0 {
 		t.Fatal("NOutputsSapling miscompare")
 	}
-	if len(tx.orchardActions) != 0 {
+	if len(tx.orchardActions()) != 0 {
 		t.Fatal("NActionsOrchard miscompare")
 	}
-	if len(tx.ironwoodActions) != 0 {
+	if len(tx.ironwoodActions()) != 0 {
 		t.Fatal("NActionsIronwood miscompare")
 	}
 }
@@ -186,7 +331,7 @@ func TestZip229V6TransactionParserAcceptsOtherConsensusBranchID(t *testing.T) {
 	if len(rest) != 0 {
 		t.Fatalf("Test did not consume entire buffer, %d remaining", len(rest))
 	}
-	if tx.consensusBranchID != 0x4C89A1F3 {
+	if tx.ConsensusBranchID != 0x4C89A1F3 {
 		t.Fatal("consensusBranchID miscompare")
 	}
 }
@@ -204,9 +349,9 @@ func TestZip229V6TransactionParserKeepsIronwoodBundle(t *testing.T) {
 		0x00, // nShieldedSpend
 		0x00, // nShieldedOutput
 	})
-	appendOrchardLikeBundle(&raw, 1) // Orchard bundle
-	appendOrchardLikeBundle(&raw, 1) // Ironwood bundle
-	raw.Write([]byte{0xaa, 0xbb})    // trailing bytes
+	appendOrchardLikeBundle(&raw, 1, 0) // Orchard bundle
+	appendOrchardLikeBundle(&raw, 1, 0) // Ironwood bundle
+	raw.Write([]byte{0xaa, 0xbb})       // trailing bytes
 
 	tx := NewTransaction()
 	rest, err := tx.ParseFromSlice(raw.Bytes())
@@ -219,10 +364,10 @@ func TestZip229V6TransactionParserKeepsIronwoodBundle(t *testing.T) {
 	if !bytes.Equal(rest, []byte{0xaa, 0xbb}) {
 		t.Fatal("trailing bytes miscompare")
 	}
-	if len(tx.orchardActions) != 1 {
+	if len(tx.orchardActions()) != 1 {
 		t.Fatal("NActionsOrchard miscompare")
 	}
-	if len(tx.ironwoodActions) != 1 {
+	if len(tx.ironwoodActions()) != 1 {
 		t.Fatal("NActionsIronwood miscompare")
 	}
 	compact := tx.ToCompact(0)
@@ -237,14 +382,14 @@ func TestZip229V6TransactionParserKeepsIronwoodBundle(t *testing.T) {
 	}
 }
 
-func appendOrchardLikeBundle(raw *bytes.Buffer, actionsCount int) {
+func appendOrchardLikeBundle(raw *bytes.Buffer, actionsCount int, valueBalance int64) {
 	raw.WriteByte(byte(actionsCount))
 	for i := 0; i < actionsCount; i++ {
 		raw.Write(bytes.Repeat([]byte{byte(i + 1)}, 820))
 	}
 	if actionsCount > 0 {
-		raw.WriteByte(0x00)                      // flags
-		raw.Write(make([]byte, 8))               // value balance
+		raw.WriteByte(0x00) // flags
+		binary.Write(raw, binary.LittleEndian, valueBalance)
 		raw.Write(make([]byte, 32))              // anchor
 		raw.WriteByte(0x00)                      // proofs length
 		raw.Write(make([]byte, 64*actionsCount)) // spend auth signatures
@@ -252,6 +397,60 @@ func appendOrchardLikeBundle(raw *bytes.Buffer, actionsCount int) {
 	}
 }
 
+func TestTransactionFee(t *testing.T) {
+	v6Header := []byte{
+		0x06, 0x00, 0x00, 0x80, // fOverwintered | version 6
+		0x98, 0xb6, 0x84, 0xd8, // version group ID (NU6.3)
+		0x5b, 0x16, 0xa5, 0x37, // consensus branch ID (NU6.3)
+		0x00, 0x00, 0x00, 0x00, // lock time
+		0x00, 0x00, 0x00, 0x00, // expiry height
+	}
+	for _, tt := range []struct {
+		name     string
+		vin      []byte // tx_in_count and inputs
+		vout     []byte // tx_out_count and outputs
+		orchard  int64
+		ironwood int64
+		fee      uint32
+		ok       bool
+	}{
+		{"shielded only", []byte{0}, []byte{0}, 10000, 5000, 15000, true},
+		{"transparent output", []byte{0},
+			append([]byte{1, 0xb8, 0x0b, 0, 0, 0, 0, 0, 0}, 0), // 3000 zatoshis, empty script
+			10000, 5000, 12000, true},
+		{"transparent input",
+			append(append([]byte{1}, make([]byte, 36)...), 0, 0xff, 0xff, 0xff, 0xff),
+			[]byte{0}, 10000, 5000, 0, false},
+		{"negative", []byte{0}, []byte{0}, 10000, -15000, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw bytes.Buffer
+			raw.Write(v6Header)
+			raw.Write(tt.vin)
+			raw.Write(tt.vout)
+			raw.Write([]byte{0x00, 0x00}) // nShieldedSpend, nShieldedOutput
+			appendOrchardLikeBundle(&raw, 1, tt.orchard)
+			appendOrchardLikeBundle(&raw, 1, tt.ironwood)
+
+			tx := NewTransaction()
+			rest, err := tx.ParseFromSlice(raw.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Fatalf("Test did not consume entire buffer, %d remaining", len(rest))
+			}
+			fee, ok := tx.Fee()
+			if ok != tt.ok || fee != uint64(tt.fee) {
+				t.Fatalf("Fee() returned %d, %v", fee, ok)
+			}
+			if tx.ToCompact(1).Fee != tt.fee {
+				t.Fatal("compact fee miscompare")
+			}
+		})
+	}
+}
+
 func TestParseTransparentRejectsCountsThatCannotFit(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -313,10 +512,10 @@ func TestBoundsChecksAcceptMinimallySizedElements(t *testing.T) {
 	if len(rest) != 0 {
 		t.Fatalf("did not consume entire buffer, %d remaining", len(rest))
 	}
-	if len(tx.transparentInputs) != 1 {
+	if len(tx.TransparentInputs) != 1 {
 		t.Fatal("tx_in_count miscompare")
 	}
-	if len(tx.transparentOutputs) != 1 {
+	if len(tx.TransparentOutputs) != 1 {
 		t.Fatal("tx_out_count miscompare")
 	}
 }
@@ -368,9 +567,9 @@ func TestParsePreV5RejectsCountsThatCannotFit(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tx := NewTransaction()
-			tx.fOverwintered = true
-			tx.version = SAPLING_TX_VERSION
-			tx.nVersionGroupID = SAPLING_VERSION_GROUP_ID
+			tx.Overwintered = true
+			tx.Version = SAPLING_TX_VERSION
+			tx.VersionGroupID = SAPLING_VERSION_GROUP_ID
 
 			_, err := tx.parsePreV5(tt.data)
 			requireErrorContains(t, err, tt.wantErr)
@@ -414,9 +613,9 @@ func TestParseV5RejectsCountsThatCannotFit(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tx := NewTransaction()
-			tx.fOverwintered = true
-			tx.version = ZIP225_TX_VERSION
-			tx.nVersionGroupID = ZIP225_VERSION_GROUP_ID
+			tx.Overwintered = true
+			tx.Version = ZIP225_TX_VERSION
+			tx.VersionGroupID = ZIP225_VERSION_GROUP_ID
 
 			_, err := tx.parseV5(tt.data)
 			requireErrorContains(t, err, tt.wantErr)
@@ -435,3 +634,185 @@ func TestParseOrchardActionShapeBundleNamesItsPool(t *testing.T) {
 		})
 	}
 }
+
+// readHexVectors returns the contents of a file of hex test vectors (blocks
+// or transactions), one per line, ignoring comment lines.
+func readHexVectors(t testing.TB, name string) [][]byte {
+	contents, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txs [][]byte
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rawTxData, err := hex.DecodeString(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, rawTxData)
+	}
+	return txs
+}
+
+// testTransactions returns the test vector transactions of every version:
+// zip143_raw_tx (v3), zip243_raw_tx (v4), tx_v5.json, the transactions of
+// the blocks in testdata/blocks, and a v6 transaction.
+func testTransactions(t testing.TB) [][]byte {
+	txs := readHexVectors(t, "../testdata/zip143_raw_tx")
+	txs = append(txs, readHexVectors(t, "../testdata/zip243_raw_tx")...)
+
+	s, err := os.ReadFile("../testdata/tx_v5.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testdata []json.RawMessage
+	if err := json.Unmarshal(s, &testdata); err != nil {
+		t.Fatal(err)
+	}
+	for _, onetx := range testdata[2:] {
+		var txtestdata TxTestData
+		if err := json.Unmarshal(onetx, &txtestdata); err != nil {
+			t.Fatal(err)
+		}
+		rawTxData, _ := hex.DecodeString(txtestdata.Tx)
+		txs = append(txs, rawTxData)
+	}
+
+	for _, blockData := range readHexVectors(t, "../testdata/blocks") {
+		block := NewBlock()
+		if _, err := block.ParseFromSlice(blockData); err != nil {
+			t.Fatal(err)
+		}
+		for _, tx := range block.Transactions() {
+			txs = append(txs, tx.Bytes())
+		}
+	}
+
+	var v6 bytes.Buffer
+	v6.Write([]byte{
+		0x06, 0x00, 0x00, 0x80, // fOverwintered | version 6
+		0x98, 0xb6, 0x84, 0xd8, // version group ID (NU6.3)
+		0x5b, 0x16, 0xa5, 0x37, // consensus branch ID (NU6.3)
+		0x00, 0x00, 0x00, 0x00, // lock time
+		0x00, 0x00, 0x00, 0x00, // expiry height
+		0x00, // tx_in_count
+		0x00, // tx_out_count
+		0x00, // nShieldedSpend
+		0x00, // nShieldedOutput
+	})
+	appendOrchardLikeBundle(&v6, 2, -5) // Orchard bundle
+	appendOrchardLikeBundle(&v6, 1, 7)  // Ironwood bundle
+	txs = append(txs, v6.Bytes())
+
+	return txs
+}
+
+func TestTransactionMarshalBinary(t *testing.T) {
+	txs := testTransactions(t)
+	for i, rawTxData := range txs {
+		tx := NewTransaction()
+		rest, err := tx.ParseFromSlice(rawTxData)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		if len(rest) != 0 {
+			t.Fatalf("tx %d: %d bytes remaining", i, len(rest))
+		}
+		marshaled, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("tx %d (version %d): %v", i, tx.Version, err)
+		}
+		if !bytes.Equal(marshaled, rawTxData) {
+			t.Fatalf("tx %d (version %d) doesn't round-trip:\nhave: %x\nwant: %x",
+				i, tx.Version, marshaled, rawTxData)
+		}
+	}
+}
+
+func TestTransactionMarshalBinaryBuilt(t *testing.T) {
+	tx := NewTransaction()
+	tx.Overwintered = true
+	tx.Version = SAPLING_TX_VERSION
+	tx.VersionGroupID = SAPLING_VERSION_GROUP_ID
+	tx.TransparentInputs = []TxIn{{PrevTxOutIndex: 1, ScriptSig: HexBytes{0x51}, SequenceNumber: 0xffffffff}}
+	tx.TransparentOutputs = []TxOut{{Value: 10000, Script: HexBytes{0x6a}}}
+
+	// The transaction wasn't parsed, so Bytes() serializes it.
+	rawTxData := tx.Bytes()
+	if rawTxData == nil {
+		t.Fatal("Bytes() of a built transaction failed")
+	}
+	reparsed := NewTransaction()
+	rest, err := reparsed.ParseFromSlice(rawTxData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("%d bytes remaining", len(rest))
+	}
+	if reparsed.TransparentOutputs[0].Value != 10000 ||
+		!bytes.Equal(reparsed.TransparentInputs[0].ScriptSig, []byte{0x51}) {
+		t.Fatal("built transaction doesn't round-trip")
+	}
+
+	tx.SaplingOutputs = []SaplingOutput{{Cv: make([]byte, 31)}}
+	_, err = tx.MarshalBinary()
+	requireErrorContains(t, err, "Sapling output cv is 31 bytes, must be 32")
+	if tx.Bytes() != nil {
+		t.Fatal("Bytes() of an unserializable transaction should be nil")
+	}
+
+	tx.SaplingOutputs = nil
+	tx.Orchard = &OrchardBundle{Actions: make([]OrchardAction, 1)}
+	_, err = tx.MarshalBinary()
+	requireErrorContains(t, err, "version 4 transaction can't have Orchard actions")
+
+	tx.Orchard = nil
+	tx.VersionGroupID = ZIP225_VERSION_GROUP_ID
+	_, err = tx.MarshalBinary()
+	requireErrorContains(t, err, "unknown transaction format")
+}
+
+func TestTransactionParseError(t *testing.T) {
+	var raw bytes.Buffer
+	raw.Write([]byte{
+		0x06, 0x00, 0x00, 0x80, // fOverwintered | version 6
+		0x98, 0xb6, 0x84, 0xd8, // version group ID (NU6.3)
+		0x5b, 0x16, 0xa5, 0x37, // consensus branch ID (NU6.3)
+		0x00, 0x00, 0x00, 0x00, // lock time
+		0x00, 0x00, 0x00, 0x00, // expiry height
+		0x00, // tx_in_count
+		0x00, // tx_out_count
+		0x00, // nShieldedSpend
+		0x00, // nShieldedOutput
+	})
+	appendOrchardLikeBundle(&raw, 1, 0) // Orchard bundle
+	actionOffset := raw.Len() + 1
+	appendOrchardLikeBundle(&raw, 1, 0) // Ironwood bundle
+
+	// Truncate the Ironwood action's signature, which follows the action,
+	// flags, value balance, anchor and (empty) proofs.
+	sigOffset := actionOffset + 820 + 1 + 8 + 32 + 1
+	_, err := NewTransaction().ParseFromSlice(raw.Bytes()[:sigOffset+10])
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	want := ParseError{Offset: sigOffset, Field: "ironwood.actions[0].spendAuthSig", Version: 6, Pool: "ironwood"}
+	if parseErr.Offset != want.Offset || parseErr.Field != want.Field ||
+		parseErr.Version != want.Version || parseErr.Pool != want.Pool {
+		t.Fatalf("have: %+v\nwant: %+v", *parseErr, want)
+	}
+	requireErrorContains(t, err, "could not read vSpendAuthSigsIronwood")
+
+	// An unknown version group is reported at the header.
+	data := slices.Clone(raw.Bytes())
+	data[4] ^= 0xff
+	_, err = NewTransaction().ParseFromSlice(data)
+	if !errors.As(err, &parseErr) || parseErr.Field != "header" || parseErr.Offset != 0 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	requireErrorContains(t, err, "unknown transaction format")
+}
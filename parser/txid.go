@@ -0,0 +1,218 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/zcash/lightwalletd/hash32"
+	"github.com/zcash/lightwalletd/internal/blake2b"
+	"github.com/zcash/lightwalletd/parser/internal/bytestring"
+)
+
+// ErrTxIDUnknown is returned when a transaction's txid can't be computed
+// (lightwalletd doesn't implement the txid digest of its version) and wasn't
+// set with SetTxID.
+var ErrTxIDUnknown = errors.New("txid unknown")
+
+// ComputeTxID computes the transaction's txid, in little-endian wire order:
+// the SHA256d of its serialization for versions 1 through 4, and the ZIP 244
+// digest for version 5. It returns an error wrapping ErrTxIDUnknown for
+// later versions.
+func (tx *Transaction) ComputeTxID() (hash32.T, error) {
+	switch {
+	case tx.Version <= 4:
+		rawBytes := tx.rawBytes
+		if rawBytes == nil {
+			var err error
+			if rawBytes, err = tx.MarshalBinary(); err != nil {
+				return hash32.Nil, err
+			}
+		}
+		digest := sha256.Sum256(rawBytes)
+		return sha256.Sum256(digest[:]), nil
+	case tx.isZip225V5():
+		return tx.zip244TxID(), nil
+	}
+	return hash32.Nil, fmt.Errorf("v%d transaction: %w", tx.Version, ErrTxIDUnknown)
+}
+
+// txidOrComputed returns the transaction's txid: the computed one if
+// possible, in which case it must match the one set with SetTxID (if any).
+func (tx *Transaction) txidOrComputed() (hash32.T, error) {
+	txid, err := tx.ComputeTxID()
+	if err != nil {
+		if errors.Is(err, ErrTxIDUnknown) && tx.txID != hash32.Nil {
+			return tx.txID, nil
+		}
+		return hash32.Nil, err
+	}
+	if tx.txID != hash32.Nil && tx.txID != txid {
+		return hash32.Nil, fmt.Errorf("txid %s doesn't match the computed txid %s",
+			tx.GetDisplayHashString(), hash32.Encode(hash32.Reverse(txid)))
+	}
+	return txid, nil
+}
+
+// zip244Hash returns the BLAKE2b-256 digest of the concatenated data with the
+// given personalization, as ZIP 244 specifies for each node of the digest tree.
+func zip244Hash(personal string, data ...[]byte) []byte {
+	var p [16]byte
+	copy(p[:], personal)
+	var b bytestring.Builder
+	for _, d := range data {
+		b.AddBytes(d)
+	}
+	return blake2b.Sum(32, p, b.Bytes())
+}
+
+// zip244TxID returns the txid of a v5 transaction, as specified by ZIP 244.
+func (tx *Transaction) zip244TxID() hash32.T {
+	var header bytestring.Builder
+	version := tx.Version
+	if tx.Overwintered {
+		version |= 1 << 31
+	}
+	header.AddUint32(version)
+	header.AddUint32(tx.VersionGroupID)
+	header.AddUint32(tx.ConsensusBranchID)
+	header.AddUint32(tx.LockTime)
+	header.AddUint32(tx.ExpiryHeight)
+
+	personal := []byte("ZcashTxHash_\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(personal[12:], tx.ConsensusBranchID)
+	return hash32.FromSlice(zip244Hash(string(personal),
+		zip244Hash("ZTxIdHeadersHash", header.Bytes()),
+		tx.zip244TransparentDigest(),
+		tx.zip244SaplingDigest(),
+		zip244OrchardDigest(tx.Orchard),
+	))
+}
+
+func (tx *Transaction) zip244TransparentDigest() []byte {
+	if len(tx.TransparentInputs)+len(tx.TransparentOutputs) == 0 {
+		return zip244Hash("ZTxIdTranspaHash")
+	}
+	var prevouts, sequences, outputs bytestring.Builder
+	for _, in := range tx.TransparentInputs {
+		prevouts.AddBytes(in.PrevTxHash[:])
+		prevouts.AddUint32(in.PrevTxOutIndex)
+		sequences.AddUint32(in.SequenceNumber)
+	}
+	for _, out := range tx.TransparentOutputs {
+		outputs.AddUint64(out.Value)
+		outputs.AddCompactLengthPrefixed(out.Script)
+	}
+	return zip244Hash("ZTxIdTranspaHash",
+		zip244Hash("ZTxIdPrevoutHash", prevouts.Bytes()),
+		zip244Hash("ZTxIdSequencHash", sequences.Bytes()),
+		zip244Hash("ZTxIdOutputsHash", outputs.Bytes()),
+	)
+}
+
+func (tx *Transaction) zip244SaplingDigest() []byte {
+	if len(tx.SaplingSpends)+len(tx.SaplingOutputs) == 0 {
+		return zip244Hash("ZTxIdSaplingHash")
+	}
+	spendsDigest := zip244Hash("ZTxIdSSpendsHash")
+	if len(tx.SaplingSpends) > 0 {
+		var compact, noncompact bytestring.Builder
+		for _, spend := range tx.SaplingSpends {
+			compact.AddBytes(spend.Nullifier)
+			noncompact.AddBytes(spend.Cv)
+			noncompact.AddBytes(tx.SaplingAnchor)
+			noncompact.AddBytes(spend.Rk)
+		}
+		spendsDigest = zip244Hash("ZTxIdSSpendsHash",
+			zip244Hash("ZTxIdSSpendCHash", compact.Bytes()),
+			zip244Hash("ZTxIdSSpendNHash", noncompact.Bytes()),
+		)
+	}
+	outputsDigest := zip244Hash("ZTxIdSOutputHash")
+	if len(tx.SaplingOutputs) > 0 {
+		var compact, memos, noncompact bytestring.Builder
+		for _, output := range tx.SaplingOutputs {
+			compact.AddBytes(output.Cmu)
+			compact.AddBytes(output.EphemeralKey)
+			compact.AddBytes(output.EncCiphertext[:52])
+			memos.AddBytes(output.EncCiphertext[52:564])
+			noncompact.AddBytes(output.Cv)
+			noncompact.AddBytes(output.EncCiphertext[564:])
+			noncompact.AddBytes(output.OutCiphertext)
+		}
+		outputsDigest = zip244Hash("ZTxIdSOutputHash",
+			zip244Hash("ZTxIdSOutC__Hash", compact.Bytes()),
+			zip244Hash("ZTxIdSOutM__Hash", memos.Bytes()),
+			zip244Hash("ZTxIdSOutN__Hash", noncompact.Bytes()),
+		)
+	}
+	var valueBalance bytestring.Builder
+	valueBalance.AddInt64(tx.ValueBalanceSapling)
+	return zip244Hash("ZTxIdSaplingHash", spendsDigest, outputsDigest, valueBalance.Bytes())
+}
+
+func zip244OrchardDigest(bundle *OrchardBundle) []byte {
+	if bundle == nil || len(bundle.Actions) == 0 {
+		return zip244Hash("ZTxIdOrchardHash")
+	}
+	var compact, memos, noncompact, rest bytestring.Builder
+	for _, a := range bundle.Actions {
+		compact.AddBytes(a.Nullifier)
+		compact.AddBytes(a.Cmx)
+		compact.AddBytes(a.EphemeralKey)
+		compact.AddBytes(a.EncCiphertext[:52])
+		memos.AddBytes(a.EncCiphertext[52:564])
+		noncompact.AddBytes(a.Cv)
+		noncompact.AddBytes(a.Rk)
+		noncompact.AddBytes(a.EncCiphertext[564:])
+		noncompact.AddBytes(a.OutCiphertext)
+	}
+	rest.AddUint8(bundle.Flags)
+	rest.AddInt64(bundle.ValueBalance)
+	rest.AddBytes(bundle.Anchor)
+	return zip244Hash("ZTxIdOrchardHash",
+		zip244Hash("ZTxIdOrcActCHash", compact.Bytes()),
+		zip244Hash("ZTxIdOrcActMHash", memos.Bytes()),
+		zip244Hash("ZTxIdOrcActNHash", noncompact.Bytes()),
+		rest.Bytes(),
+	)
+}
+
+// MerkleRoot returns the root of the Merkle tree of the transactions' txids,
+// as committed to by a block header's hashMerkleRoot (the Bitcoin
+// construction: SHA256d of each pair, duplicating the last node of odd
+// levels). Each txid is computed if possible (see ComputeTxID), and must then
+// match the txid set with SetTxID, if any; otherwise the set txid is used.
+func MerkleRoot(vtx []*Transaction) (hash32.T, error) {
+	if len(vtx) == 0 {
+		return hash32.Nil, errors.New("no transactions")
+	}
+	level := make([]hash32.T, len(vtx))
+	for i, tx := range vtx {
+		txid, err := tx.txidOrComputed()
+		if err != nil {
+			return hash32.Nil, fmt.Errorf("vtx[%d]: %w", i, err)
+		}
+		level[i] = txid
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([]hash32.T, len(level)/2)
+		var pair [64]byte
+		for i := range next {
+			copy(pair[:32], level[2*i][:])
+			copy(pair[32:], level[2*i+1][:])
+			digest := sha256.Sum256(pair[:])
+			next[i] = sha256.Sum256(digest[:])
+		}
+		level = next
+	}
+	return level[0], nil
+}
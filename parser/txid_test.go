@@ -0,0 +1,90 @@
+// Copyright (c) 2019-present The Zcash developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or https://www.opensource.org/licenses/mit-license.php .
+package parser
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/zcash/lightwalletd/hash32"
+)
+
+func TestComputeTxIDV5(t *testing.T) {
+	s, err := os.ReadFile("../testdata/tx_v5.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testdata []json.RawMessage
+	if err := json.Unmarshal(s, &testdata); err != nil {
+		t.Fatal(err)
+	}
+	for _, onetx := range testdata[2:] {
+		var txtestdata TxTestData
+		if err := json.Unmarshal(onetx, &txtestdata); err != nil {
+			t.Fatal(err)
+		}
+		rawTxData, _ := hex.DecodeString(txtestdata.Tx)
+		tx := NewTransaction()
+		if _, err := tx.ParseFromSlice(rawTxData); err != nil {
+			t.Fatal(err)
+		}
+		txid, err := tx.ComputeTxID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The vectors' txids are in big-endian (display) order.
+		if hash32.Encode(hash32.Reverse(txid)) != txtestdata.Txid {
+			t.Fatalf("txid %s, want %s", hash32.Encode(hash32.Reverse(txid)), txtestdata.Txid)
+		}
+	}
+}
+
+func TestVerifyMerkleRoot(t *testing.T) {
+	for _, blockData := range readHexVectors(t, "../testdata/blocks") {
+		block := NewBlock()
+		if _, err := block.ParseFromSlice(blockData); err != nil {
+			t.Fatal(err)
+		}
+		if err := block.VerifyMerkleRoot(); err != nil {
+			t.Fatalf("block %d: %v", block.GetHeight(), err)
+		}
+
+		// Reordering the transactions changes the root (all but the
+		// single-transaction blocks).
+		vtx := block.Transactions()
+		if len(vtx) > 1 {
+			hdr := block.RawHeader()
+			swapped := append([]*Transaction{vtx[1], vtx[0]}, vtx[2:]...)
+			if err := NewBlockFromTransactions(&hdr, swapped).VerifyMerkleRoot(); err == nil {
+				t.Fatalf("block %d: reordered transactions accepted", block.GetHeight())
+			}
+		}
+
+		// A supplied txid must match the computed one.
+		vtx[0].SetTxID(hash32.T{1})
+		if err := block.VerifyMerkleRoot(); err == nil {
+			t.Fatalf("block %d: wrong txid accepted", block.GetHeight())
+		}
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	// A v6 transaction's txid can't be computed, so it must be supplied.
+	tx := NewTransaction()
+	tx.TransactionData = &TransactionData{Overwintered: true, Version: 6, VersionGroupID: NU6_3_VERSION_GROUP_ID}
+	if _, err := MerkleRoot([]*Transaction{tx}); !errors.Is(err, ErrTxIDUnknown) {
+		t.Fatal("unexpected error", err)
+	}
+	tx.SetTxID(hash32.T{1})
+	root, err := MerkleRoot([]*Transaction{tx})
+	if err != nil || root != (hash32.T{1}) {
+		t.Fatal("a single transaction's txid should be the root", root, err)
+	}
+	if _, err := MerkleRoot(nil); err == nil {
+		t.Fatal("a block must have transactions")
+	}
+}
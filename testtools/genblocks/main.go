@@ -26,13 +26,14 @@ package main
 import (
 	"bufio"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path"
 	"strconv"
-	"strings"
 
 	"github.com/zcash/lightwalletd/hash32"
 	"github.com/zcash/lightwalletd/parser"
@@ -60,68 +61,81 @@ func main() {
 		}
 		scan := bufio.NewScanner(testBlocks)
 
-		fakeCoinbase := "0400008085202f890100000000000000000000000000000000000000000000000000" +
-			"00000000000000ffffffff2a03d12c0c00043855975e464b8896790758f824ceac97836" +
-			"22c17ed38f1669b8a45ce1da857dbbe7950e2ffffffff02a0ebce1d000000001976a914" +
-			"7ed15946ec14ae0cd8fa8991eb6084452eb3f77c88ac405973070000000017a914e445cf" +
-			"a944b6f2bdacefbda904a81d5fdd26d77f8700000000000000000000000000000000000000"
-
-		// This coinbase transaction was pulled from block 797905, whose
-		// little-endian encoding is 0xD12C0C00. Replace it with the block
-		// number we want.
-		fakeCoinbase = strings.Replace(fakeCoinbase, "d12c0c00",
-			fmt.Sprintf("%02x", curHeight&0xFF)+
-				fmt.Sprintf("%02x", (curHeight>>8)&0xFF)+
-				fmt.Sprintf("%02x", (curHeight>>16)&0xFF)+
-				fmt.Sprintf("%02x", (curHeight>>24)&0xFF), 1)
+		// This coinbase transaction was pulled from block 797905; its
+		// scriptSig starts with a push of the (little-endian) height, which
+		// is replaced with the height we want.
+		fakeCoinbaseBytes, err := hex.DecodeString(
+			"0400008085202f890100000000000000000000000000000000000000000000000000" +
+				"00000000000000ffffffff2a03d12c0c00043855975e464b8896790758f824ceac97836" +
+				"22c17ed38f1669b8a45ce1da857dbbe7950e2ffffffff02a0ebce1d000000001976a914" +
+				"7ed15946ec14ae0cd8fa8991eb6084452eb3f77c88ac405973070000000017a914e445cf" +
+				"a944b6f2bdacefbda904a81d5fdd26d77f8700000000000000000000000000000000000000")
+		if err != nil {
+			panic(err)
+		}
+		fakeCoinbase := parser.NewTransaction()
+		if _, err := fakeCoinbase.ParseFromSlice(fakeCoinbaseBytes); err != nil {
+			panic(fmt.Sprint("Cannot parse coinbase transaction: ", err))
+		}
+		binary.LittleEndian.PutUint32(fakeCoinbase.TransparentInputs[0].ScriptSig[1:5], uint32(curHeight))
+		transactions := []*parser.Transaction{fakeCoinbase}
 
-		var numTransactions uint = 1 // coinbase
-		allTransactionsHex := ""
 		for scan.Scan() { // each line (hex-encoded transaction)
-			allTransactionsHex += scan.Text()
-			numTransactions++
+			txBytes, err := hex.DecodeString(scan.Text())
+			if err != nil {
+				panic(fmt.Sprint("Cannot decode transaction: ", err))
+			}
+			tx := parser.NewTransaction()
+			rest, err := tx.ParseFromSlice(txBytes)
+			if err != nil {
+				panic(fmt.Sprint("Cannot parse transaction: ", err))
+			}
+			if len(rest) != 0 {
+				panic("transaction is too long")
+			}
+			transactions = append(transactions, tx)
 		}
 		if err = scan.Err(); err != nil {
 			panic("line too long!")
 		}
-		if numTransactions > 65535 {
-			panic(fmt.Sprint("too many transactions ", numTransactions,
+		if len(transactions) > 65535 {
+			panic(fmt.Sprint("too many transactions ", len(transactions),
 				" maximum 65535"))
 		}
 
-		hashOfTxnsAndHeight := sha256.Sum256([]byte(allTransactionsHex + "#" + string(rune(curHeight))))
-
-		// These fields do not need to be valid for the lightwalletd/wallet stack to work.
-		// The lightwalletd/wallet stack rely on the miners to validate these.
-		// Make the block header depend on height + all transactions (in an incorrect way)
-		blockHeader := &parser.BlockHeader{
-			RawBlockHeader: &parser.RawBlockHeader{
-				Version:              4,
-				HashPrevBlock:        prevhash,
-				HashMerkleRoot:       hashOfTxnsAndHeight,
-				HashFinalSaplingRoot: hash32.Nil,
-				Time:                 1,
-				NBitsBytes:           [4]byte{},
-				Nonce:                [32]byte{},
-				Solution:             make([]byte, 1344),
-			},
+		// lightwalletd can't compute the txids of v6 transactions; use
+		// SHA256d for those, which darksidewalletd can't check.
+		for _, tx := range transactions {
+			if _, err := tx.ComputeTxID(); errors.Is(err, parser.ErrTxIDUnknown) {
+				digest := sha256.Sum256(tx.Bytes())
+				tx.SetTxID(sha256.Sum256(digest[:]))
+			}
 		}
-
-		headerBytes, err := blockHeader.MarshalBinary()
+		merkleRoot, err := parser.MerkleRoot(transactions)
 		if err != nil {
-			panic(fmt.Sprint("Cannot marshal block header: ", err))
+			panic(fmt.Sprint("Cannot compute merkle root: ", err))
 		}
-		fmt.Print(hex.EncodeToString(headerBytes))
 
-		// After the header, there's a compactsize representation of the number of transactions.
-		if numTransactions < 253 {
-			fmt.Printf("%02x", numTransactions)
-		} else {
-			fmt.Printf("%02x%02x%02x", 253, numTransactions%256, numTransactions/256)
+		// The other fields do not need to be valid for the lightwalletd/wallet stack to work.
+		// The lightwalletd/wallet stack rely on the miners to validate these.
+		block := parser.NewBlockFromTransactions(&parser.RawBlockHeader{
+			Version:              4,
+			HashPrevBlock:        prevhash,
+			HashMerkleRoot:       merkleRoot,
+			HashFinalSaplingRoot: hash32.Nil,
+			Time:                 1,
+			NBitsBytes:           [4]byte{},
+			Nonce:                [32]byte{},
+			Solution:             make([]byte, 1344),
+		}, transactions)
+
+		blockBytes, err := block.MarshalBinary()
+		if err != nil {
+			panic(fmt.Sprint("Cannot marshal block: ", err))
 		}
-		fmt.Printf("%s%s\n", fakeCoinbase, allTransactionsHex)
+		fmt.Println(hex.EncodeToString(blockBytes))
 
 		curHeight++
-		prevhash = blockHeader.GetEncodableHash()
+		prevhash = block.GetEncodableHash()
 	}
 }
@@ -94,10 +94,9 @@ func (x *ChainMetadata) GetIronwoodCommitmentTreeSize() uint32 {
 //  3. Update your witnesses to generate new spend proofs.
 //  4. Spend UTXOs associated to t-addresses of your wallet.
 //
-// Currently, the `header` field should always be unset (empty). In the future,
-// the presence or absence of header data may be made dependent on request
-// parameters, although it is likely that such flexibility will only be provided via
-// newly-added service methods, not via existing APIs.
+// The `header` field is unset (empty) in blocks returned by `GetBlock`,
+// `GetBlockRange`, and the other existing service methods; it's set only in the
+// header-only blocks returned by `GetBlockHeaderRange`.
 type CompactBlock struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Height        uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`              // the height of this block
@@ -213,6 +212,9 @@ type CompactTx struct {
 	//
 	//	valueBalanceSapling + valueBalanceOrchard + valueBalanceIronwood
 	//	+ sum(vPubNew) - sum(vPubOld) - sum(tOut)
+	// A server that resolves prevouts (see `CompactTxIn.prevoutValue`) can
+	// also provide the fee of a transaction with transparent inputs, by adding
+	// sum(prevoutValue) to the above.
 	Fee             uint32                  `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
 	Spends          []*CompactSaplingSpend  `protobuf:"bytes,4,rep,name=spends,proto3" json:"spends,omitempty"`
 	Outputs         []*CompactSaplingOutput `protobuf:"bytes,5,rep,name=outputs,proto3" json:"outputs,omitempty"`
@@ -333,9 +335,15 @@ type CompactTxIn struct {
 	PrevoutTxid []byte `protobuf:"bytes,1,opt,name=prevoutTxid,proto3" json:"prevoutTxid,omitempty"`
 	// The index of the output being spent in the `vout` array of the
 	// transaction referred to by `prevoutTxid`.
-	PrevoutIndex  uint32 `protobuf:"varint,2,opt,name=prevoutIndex,proto3" json:"prevoutIndex,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	PrevoutIndex uint32 `protobuf:"varint,2,opt,name=prevoutIndex,proto3" json:"prevoutIndex,omitempty"`
+	// The value, in Zatoshis, of the output being spent: present if the server
+	// resolves prevouts (this is optional server behavior), otherwise zero.
+	PrevoutValue uint64 `protobuf:"varint,3,opt,name=prevoutValue,proto3" json:"prevoutValue,omitempty"`
+	// The script pubkey of the output being spent: present if the server
+	// resolves prevouts, otherwise empty.
+	PrevoutScriptPubKey []byte `protobuf:"bytes,4,opt,name=prevoutScriptPubKey,proto3" json:"prevoutScriptPubKey,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *CompactTxIn) Reset() {
@@ -382,6 +390,20 @@ func (x *CompactTxIn) GetPrevoutIndex() uint32 {
 	return 0
 }
 
+func (x *CompactTxIn) GetPrevoutValue() uint64 {
+	if x != nil {
+		return x.PrevoutValue
+	}
+	return 0
+}
+
+func (x *CompactTxIn) GetPrevoutScriptPubKey() []byte {
+	if x != nil {
+		return x.PrevoutScriptPubKey
+	}
+	return nil
+}
+
 // A transparent output being created by the transaction.
 //
 // This contains identical data to the `TxOut` type in the transaction itself, and
@@ -647,10 +669,12 @@ const file_compact_formats_proto_rawDesc = "" +
 	"\aactions\x18\x06 \x03(\v2+.cash.z.wallet.sdk.rpc.CompactOrchardActionR\aactions\x12U\n" +
 	"\x0fironwoodActions\x18\t \x03(\v2+.cash.z.wallet.sdk.rpc.CompactOrchardActionR\x0fironwoodActions\x124\n" +
 	"\x03vin\x18\a \x03(\v2\".cash.z.wallet.sdk.rpc.CompactTxInR\x03vin\x120\n" +
-	"\x04vout\x18\b \x03(\v2\x1c.cash.z.wallet.sdk.rpc.TxOutR\x04vout\"S\n" +
+	"\x04vout\x18\b \x03(\v2\x1c.cash.z.wallet.sdk.rpc.TxOutR\x04vout\"\xa9\x01\n" +
 	"\vCompactTxIn\x12 \n" +
 	"\vprevoutTxid\x18\x01 \x01(\fR\vprevoutTxid\x12\"\n" +
-	"\fprevoutIndex\x18\x02 \x01(\rR\fprevoutIndex\"A\n" +
+	"\fprevoutIndex\x18\x02 \x01(\rR\fprevoutIndex\x12\"\n" +
+	"\fprevoutValue\x18\x03 \x01(\x04R\fprevoutValue\x120\n" +
+	"\x13prevoutScriptPubKey\x18\x04 \x01(\fR\x13prevoutScriptPubKey\"A\n" +
 	"\x05TxOut\x12\x14\n" +
 	"\x05value\x18\x01 \x01(\x04R\x05value\x12\"\n" +
 	"\fscriptPubKey\x18\x02 \x01(\fR\fscriptPubKey\"%\n" +
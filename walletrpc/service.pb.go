@@ -258,7 +258,7 @@ func (x *BlockRange) GetPoolTypes() []PoolType {
 
 // A TxFilter contains the information needed to identify a particular
 // transaction: either a block and an index, or a direct transaction hash.
-// Currently, only specification by hash is supported.
+// If `hash` is set, it takes precedence over `block` and `index`.
 type TxFilter struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Block         *BlockID               `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`  // block identifier, height or hash
@@ -714,10 +714,19 @@ func (x *LightdInfo) GetLightwalletProtocolVersion() string {
 //
 // The `poolTypes` field of the `range` argument should be ignored.
 // Implementations MAY consider it an error if any pool types are specified.
+//
+// Results may be paginated: if `cursor` or `maxEntries` is set, a response
+// that stops before the end of the results (at `maxEntries` transactions, at
+// the end of the widest block range the server will scan, or on an error)
+// carries an opaque continuation cursor in its `continuation-cursor-bin`
+// trailer. Passing it as `cursor` in a request with the same address and
+// range resumes with the transaction after the last one returned.
 type TransparentAddressBlockFilter struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"` // t-address
-	Range         *BlockRange            `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`     // start, end heights only
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`        // t-address
+	Range         *BlockRange            `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`            // start, end heights only
+	Cursor        []byte                 `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`          // continuation cursor from an earlier response
+	MaxEntries    uint32                 `protobuf:"varint,4,opt,name=maxEntries,proto3" json:"maxEntries,omitempty"` // zero means unlimited
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -766,6 +775,209 @@ func (x *TransparentAddressBlockFilter) GetRange() *BlockRange {
 	return nil
 }
 
+func (x *TransparentAddressBlockFilter) GetCursor() []byte {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+func (x *TransparentAddressBlockFilter) GetMaxEntries() uint32 {
+	if x != nil {
+		return x.MaxEntries
+	}
+	return 0
+}
+
+// Request parameters for the `GetTaddressHistory` RPC. Results may be
+// paginated with `cursor` and `maxEntries`, as for
+// `TransparentAddressBlockFilter`.
+type GetTaddressHistoryArg struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Address                string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`                                // t-address (or unified or TEX address)
+	Range                  *BlockRange            `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`                                    // start, end heights only
+	IncludeRawTransactions bool                   `protobuf:"varint,3,opt,name=includeRawTransactions,proto3" json:"includeRawTransactions,omitempty"` // set `rawTransaction` in the results
+	Cursor                 []byte                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`                                  // continuation cursor from an earlier response
+	MaxEntries             uint32                 `protobuf:"varint,5,opt,name=maxEntries,proto3" json:"maxEntries,omitempty"`                         // zero means unlimited
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GetTaddressHistoryArg) Reset() {
+	*x = GetTaddressHistoryArg{}
+	mi := &file_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaddressHistoryArg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaddressHistoryArg) ProtoMessage() {}
+
+func (x *GetTaddressHistoryArg) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaddressHistoryArg.ProtoReflect.Descriptor instead.
+func (*GetTaddressHistoryArg) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetTaddressHistoryArg) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *GetTaddressHistoryArg) GetRange() *BlockRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *GetTaddressHistoryArg) GetIncludeRawTransactions() bool {
+	if x != nil {
+		return x.IncludeRawTransactions
+	}
+	return false
+}
+
+func (x *GetTaddressHistoryArg) GetCursor() []byte {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+func (x *GetTaddressHistoryArg) GetMaxEntries() uint32 {
+	if x != nil {
+		return x.MaxEntries
+	}
+	return 0
+}
+
+// A transaction that pays or spends from a transparent address, with its
+// block context and the value it moves to and from the address.
+type TaddressTransaction struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Txid           []byte                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`                       // little-endian, as in `CompactTx.txid`
+	Height         uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`                  // height of the block it's mined in
+	BlockTime      uint32                 `protobuf:"varint,3,opt,name=blockTime,proto3" json:"blockTime,omitempty"`            // Unix epoch time when the block was mined
+	TxIndex        uint64                 `protobuf:"varint,4,opt,name=txIndex,proto3" json:"txIndex,omitempty"`                // index of the transaction within the block
+	ReceivedZat    uint64                 `protobuf:"varint,5,opt,name=receivedZat,proto3" json:"receivedZat,omitempty"`        // total value of its outputs paying the address
+	SpentZat       uint64                 `protobuf:"varint,6,opt,name=spentZat,proto3" json:"spentZat,omitempty"`              // total value of the address's outputs its inputs spend
+	VoutIndices    []uint32               `protobuf:"varint,7,rep,packed,name=voutIndices,proto3" json:"voutIndices,omitempty"` // indices of its outputs paying the address
+	VinIndices     []uint32               `protobuf:"varint,8,rep,packed,name=vinIndices,proto3" json:"vinIndices,omitempty"`   // indices of its inputs spending the address's outputs
+	RawTransaction []byte                 `protobuf:"bytes,9,opt,name=rawTransaction,proto3" json:"rawTransaction,omitempty"`   // the full transaction, if requested
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TaddressTransaction) Reset() {
+	*x = TaddressTransaction{}
+	mi := &file_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaddressTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaddressTransaction) ProtoMessage() {}
+
+func (x *TaddressTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaddressTransaction.ProtoReflect.Descriptor instead.
+func (*TaddressTransaction) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TaddressTransaction) GetTxid() []byte {
+	if x != nil {
+		return x.Txid
+	}
+	return nil
+}
+
+func (x *TaddressTransaction) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *TaddressTransaction) GetBlockTime() uint32 {
+	if x != nil {
+		return x.BlockTime
+	}
+	return 0
+}
+
+func (x *TaddressTransaction) GetTxIndex() uint64 {
+	if x != nil {
+		return x.TxIndex
+	}
+	return 0
+}
+
+func (x *TaddressTransaction) GetReceivedZat() uint64 {
+	if x != nil {
+		return x.ReceivedZat
+	}
+	return 0
+}
+
+func (x *TaddressTransaction) GetSpentZat() uint64 {
+	if x != nil {
+		return x.SpentZat
+	}
+	return 0
+}
+
+func (x *TaddressTransaction) GetVoutIndices() []uint32 {
+	if x != nil {
+		return x.VoutIndices
+	}
+	return nil
+}
+
+func (x *TaddressTransaction) GetVinIndices() []uint32 {
+	if x != nil {
+		return x.VinIndices
+	}
+	return nil
+}
+
+func (x *TaddressTransaction) GetRawTransaction() []byte {
+	if x != nil {
+		return x.RawTransaction
+	}
+	return nil
+}
+
 // Duration is currently used only for testing, so that the Ping rpc
 // can simulate a delay, to create many simultaneous connections. Units
 // are microseconds.
@@ -778,7 +990,7 @@ type Duration struct {
 
 func (x *Duration) Reset() {
 	*x = Duration{}
-	mi := &file_service_proto_msgTypes[9]
+	mi := &file_service_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -790,7 +1002,7 @@ func (x *Duration) String() string {
 func (*Duration) ProtoMessage() {}
 
 func (x *Duration) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[9]
+	mi := &file_service_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -803,7 +1015,7 @@ func (x *Duration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Duration.ProtoReflect.Descriptor instead.
 func (*Duration) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{9}
+	return file_service_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *Duration) GetIntervalUs() int64 {
@@ -826,7 +1038,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_service_proto_msgTypes[10]
+	mi := &file_service_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -838,7 +1050,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[10]
+	mi := &file_service_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -851,7 +1063,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{10}
+	return file_service_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *PingResponse) GetEntry() int64 {
@@ -877,7 +1089,7 @@ type Address struct {
 
 func (x *Address) Reset() {
 	*x = Address{}
-	mi := &file_service_proto_msgTypes[11]
+	mi := &file_service_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -889,7 +1101,7 @@ func (x *Address) String() string {
 func (*Address) ProtoMessage() {}
 
 func (x *Address) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[11]
+	mi := &file_service_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -902,7 +1114,7 @@ func (x *Address) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Address.ProtoReflect.Descriptor instead.
 func (*Address) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{11}
+	return file_service_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *Address) GetAddress() string {
@@ -921,7 +1133,7 @@ type AddressList struct {
 
 func (x *AddressList) Reset() {
 	*x = AddressList{}
-	mi := &file_service_proto_msgTypes[12]
+	mi := &file_service_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -933,7 +1145,7 @@ func (x *AddressList) String() string {
 func (*AddressList) ProtoMessage() {}
 
 func (x *AddressList) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[12]
+	mi := &file_service_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -946,7 +1158,7 @@ func (x *AddressList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddressList.ProtoReflect.Descriptor instead.
 func (*AddressList) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{12}
+	return file_service_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *AddressList) GetAddresses() []string {
@@ -965,7 +1177,7 @@ type Balance struct {
 
 func (x *Balance) Reset() {
 	*x = Balance{}
-	mi := &file_service_proto_msgTypes[13]
+	mi := &file_service_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -977,7 +1189,7 @@ func (x *Balance) String() string {
 func (*Balance) ProtoMessage() {}
 
 func (x *Balance) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[13]
+	mi := &file_service_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -990,7 +1202,7 @@ func (x *Balance) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Balance.ProtoReflect.Descriptor instead.
 func (*Balance) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{13}
+	return file_service_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *Balance) GetValueZat() int64 {
@@ -1000,6 +1212,206 @@ func (x *Balance) GetValueZat() int64 {
 	return 0
 }
 
+// Request parameters for the `GetTaddressBalanceAtHeight` RPC.
+type GetTaddressBalanceAtHeightArg struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Addresses        []string               `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Height           uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`                     // zero means the chain tip
+	MinConfirmations uint32                 `protobuf:"varint,3,opt,name=minConfirmations,proto3" json:"minConfirmations,omitempty"` // for `confirmedValueZat`; zero is treated as one
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetTaddressBalanceAtHeightArg) Reset() {
+	*x = GetTaddressBalanceAtHeightArg{}
+	mi := &file_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaddressBalanceAtHeightArg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaddressBalanceAtHeightArg) ProtoMessage() {}
+
+func (x *GetTaddressBalanceAtHeightArg) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaddressBalanceAtHeightArg.ProtoReflect.Descriptor instead.
+func (*GetTaddressBalanceAtHeightArg) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetTaddressBalanceAtHeightArg) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *GetTaddressBalanceAtHeightArg) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *GetTaddressBalanceAtHeightArg) GetMinConfirmations() uint32 {
+	if x != nil {
+		return x.MinConfirmations
+	}
+	return 0
+}
+
+// The balance of a transparent address as of a block height. Amounts are in
+// zatoshis. A UTXO is an output paying the address, mined at or below the
+// height, that isn't spent by a transaction mined at or below the height.
+type TaddressBalance struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Address           string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`                      // the transparent address (or receiver)
+	ValueZat          int64                  `protobuf:"varint,2,opt,name=valueZat,proto3" json:"valueZat,omitempty"`                   // total value of the UTXOs
+	ReceivedZat       int64                  `protobuf:"varint,3,opt,name=receivedZat,proto3" json:"receivedZat,omitempty"`             // total value of all outputs paying the address
+	SentZat           int64                  `protobuf:"varint,4,opt,name=sentZat,proto3" json:"sentZat,omitempty"`                     // total value of all spent outputs
+	UtxoCount         uint32                 `protobuf:"varint,5,opt,name=utxoCount,proto3" json:"utxoCount,omitempty"`                 // number of UTXOs
+	ConfirmedValueZat int64                  `protobuf:"varint,6,opt,name=confirmedValueZat,proto3" json:"confirmedValueZat,omitempty"` // value of the UTXOs with at least `minConfirmations` confirmations
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TaddressBalance) Reset() {
+	*x = TaddressBalance{}
+	mi := &file_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaddressBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaddressBalance) ProtoMessage() {}
+
+func (x *TaddressBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaddressBalance.ProtoReflect.Descriptor instead.
+func (*TaddressBalance) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TaddressBalance) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *TaddressBalance) GetValueZat() int64 {
+	if x != nil {
+		return x.ValueZat
+	}
+	return 0
+}
+
+func (x *TaddressBalance) GetReceivedZat() int64 {
+	if x != nil {
+		return x.ReceivedZat
+	}
+	return 0
+}
+
+func (x *TaddressBalance) GetSentZat() int64 {
+	if x != nil {
+		return x.SentZat
+	}
+	return 0
+}
+
+func (x *TaddressBalance) GetUtxoCount() uint32 {
+	if x != nil {
+		return x.UtxoCount
+	}
+	return 0
+}
+
+func (x *TaddressBalance) GetConfirmedValueZat() int64 {
+	if x != nil {
+		return x.ConfirmedValueZat
+	}
+	return 0
+}
+
+type TaddressBalanceList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"` // the height the balances are as of
+	Balances      []*TaddressBalance     `protobuf:"bytes,2,rep,name=balances,proto3" json:"balances,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaddressBalanceList) Reset() {
+	*x = TaddressBalanceList{}
+	mi := &file_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaddressBalanceList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaddressBalanceList) ProtoMessage() {}
+
+func (x *TaddressBalanceList) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaddressBalanceList.ProtoReflect.Descriptor instead.
+func (*TaddressBalanceList) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TaddressBalanceList) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *TaddressBalanceList) GetBalances() []*TaddressBalance {
+	if x != nil {
+		return x.Balances
+	}
+	return nil
+}
+
 // Request parameters for the `GetMempoolTx` RPC.
 type GetMempoolTxRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1021,7 +1433,7 @@ type GetMempoolTxRequest struct {
 
 func (x *GetMempoolTxRequest) Reset() {
 	*x = GetMempoolTxRequest{}
-	mi := &file_service_proto_msgTypes[14]
+	mi := &file_service_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1033,7 +1445,7 @@ func (x *GetMempoolTxRequest) String() string {
 func (*GetMempoolTxRequest) ProtoMessage() {}
 
 func (x *GetMempoolTxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[14]
+	mi := &file_service_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1046,7 +1458,7 @@ func (x *GetMempoolTxRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMempoolTxRequest.ProtoReflect.Descriptor instead.
 func (*GetMempoolTxRequest) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{14}
+	return file_service_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *GetMempoolTxRequest) GetExcludeTxidSuffixes() [][]byte {
@@ -1079,7 +1491,7 @@ type TreeState struct {
 
 func (x *TreeState) Reset() {
 	*x = TreeState{}
-	mi := &file_service_proto_msgTypes[15]
+	mi := &file_service_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1091,7 +1503,7 @@ func (x *TreeState) String() string {
 func (*TreeState) ProtoMessage() {}
 
 func (x *TreeState) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[15]
+	mi := &file_service_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1104,7 +1516,7 @@ func (x *TreeState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TreeState.ProtoReflect.Descriptor instead.
 func (*TreeState) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{15}
+	return file_service_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *TreeState) GetNetwork() string {
@@ -1167,7 +1579,7 @@ type GetSubtreeRootsArg struct {
 
 func (x *GetSubtreeRootsArg) Reset() {
 	*x = GetSubtreeRootsArg{}
-	mi := &file_service_proto_msgTypes[16]
+	mi := &file_service_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1179,7 +1591,7 @@ func (x *GetSubtreeRootsArg) String() string {
 func (*GetSubtreeRootsArg) ProtoMessage() {}
 
 func (x *GetSubtreeRootsArg) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[16]
+	mi := &file_service_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1192,7 +1604,7 @@ func (x *GetSubtreeRootsArg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSubtreeRootsArg.ProtoReflect.Descriptor instead.
 func (*GetSubtreeRootsArg) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{16}
+	return file_service_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetSubtreeRootsArg) GetStartIndex() uint32 {
@@ -1227,7 +1639,7 @@ type SubtreeRoot struct {
 
 func (x *SubtreeRoot) Reset() {
 	*x = SubtreeRoot{}
-	mi := &file_service_proto_msgTypes[17]
+	mi := &file_service_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1239,7 +1651,7 @@ func (x *SubtreeRoot) String() string {
 func (*SubtreeRoot) ProtoMessage() {}
 
 func (x *SubtreeRoot) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[17]
+	mi := &file_service_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1252,7 +1664,7 @@ func (x *SubtreeRoot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubtreeRoot.ProtoReflect.Descriptor instead.
 func (*SubtreeRoot) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{17}
+	return file_service_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *SubtreeRoot) GetRootHash() []byte {
@@ -1277,19 +1689,29 @@ func (x *SubtreeRoot) GetCompletingBlockHeight() uint64 {
 }
 
 // Results are sorted by height, which makes it easy to issue another
-// request that picks up from where the previous left off.
+// request that picks up from where the previous left off. More precisely, a
+// response with `maxEntries` results carries an opaque continuation cursor
+// in its `continuation-cursor-bin` trailer; passing it as `cursor` in a
+// request with the same addresses resumes with the UTXO after the last one
+// returned.
+//
+// If `includeMempool` is set, UTXOs that a transaction in the mempool spends
+// are left out, and the unspent outputs of mempool transactions that pay to
+// the addresses are included, with height 0, after the mined UTXOs.
 type GetAddressUtxosArg struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Addresses     []string               `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
-	StartHeight   uint64                 `protobuf:"varint,2,opt,name=startHeight,proto3" json:"startHeight,omitempty"`
-	MaxEntries    uint32                 `protobuf:"varint,3,opt,name=maxEntries,proto3" json:"maxEntries,omitempty"` // zero means unlimited
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Addresses      []string               `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	StartHeight    uint64                 `protobuf:"varint,2,opt,name=startHeight,proto3" json:"startHeight,omitempty"`
+	MaxEntries     uint32                 `protobuf:"varint,3,opt,name=maxEntries,proto3" json:"maxEntries,omitempty"` // zero means unlimited
+	Cursor         []byte                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`          // continuation cursor from an earlier response
+	IncludeMempool bool                   `protobuf:"varint,5,opt,name=includeMempool,proto3" json:"includeMempool,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetAddressUtxosArg) Reset() {
 	*x = GetAddressUtxosArg{}
-	mi := &file_service_proto_msgTypes[18]
+	mi := &file_service_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1301,7 +1723,7 @@ func (x *GetAddressUtxosArg) String() string {
 func (*GetAddressUtxosArg) ProtoMessage() {}
 
 func (x *GetAddressUtxosArg) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[18]
+	mi := &file_service_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1314,7 +1736,7 @@ func (x *GetAddressUtxosArg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAddressUtxosArg.ProtoReflect.Descriptor instead.
 func (*GetAddressUtxosArg) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{18}
+	return file_service_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *GetAddressUtxosArg) GetAddresses() []string {
@@ -1338,6 +1760,20 @@ func (x *GetAddressUtxosArg) GetMaxEntries() uint32 {
 	return 0
 }
 
+func (x *GetAddressUtxosArg) GetCursor() []byte {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+func (x *GetAddressUtxosArg) GetIncludeMempool() bool {
+	if x != nil {
+		return x.IncludeMempool
+	}
+	return false
+}
+
 type GetAddressUtxosReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Address       string                 `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
@@ -1352,7 +1788,7 @@ type GetAddressUtxosReply struct {
 
 func (x *GetAddressUtxosReply) Reset() {
 	*x = GetAddressUtxosReply{}
-	mi := &file_service_proto_msgTypes[19]
+	mi := &file_service_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1364,7 +1800,7 @@ func (x *GetAddressUtxosReply) String() string {
 func (*GetAddressUtxosReply) ProtoMessage() {}
 
 func (x *GetAddressUtxosReply) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[19]
+	mi := &file_service_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1377,7 +1813,7 @@ func (x *GetAddressUtxosReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAddressUtxosReply.ProtoReflect.Descriptor instead.
 func (*GetAddressUtxosReply) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{19}
+	return file_service_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *GetAddressUtxosReply) GetAddress() string {
@@ -1431,7 +1867,7 @@ type GetAddressUtxosReplyList struct {
 
 func (x *GetAddressUtxosReplyList) Reset() {
 	*x = GetAddressUtxosReplyList{}
-	mi := &file_service_proto_msgTypes[20]
+	mi := &file_service_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1443,7 +1879,7 @@ func (x *GetAddressUtxosReplyList) String() string {
 func (*GetAddressUtxosReplyList) ProtoMessage() {}
 
 func (x *GetAddressUtxosReplyList) ProtoReflect() protoreflect.Message {
-	mi := &file_service_proto_msgTypes[20]
+	mi := &file_service_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1456,7 +1892,7 @@ func (x *GetAddressUtxosReplyList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAddressUtxosReplyList.ProtoReflect.Descriptor instead.
 func (*GetAddressUtxosReplyList) Descriptor() ([]byte, []int) {
-	return file_service_proto_rawDescGZIP(), []int{20}
+	return file_service_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *GetAddressUtxosReplyList) GetAddressUtxos() []*GetAddressUtxosReply {
@@ -1466,6 +1902,130 @@ func (x *GetAddressUtxosReplyList) GetAddressUtxos() []*GetAddressUtxosReply {
 	return nil
 }
 
+// Request parameters for the `GetReorgHistory` RPC.
+type GetReorgHistoryArg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     uint64                 `protobuf:"varint,1,opt,name=startTime,proto3" json:"startTime,omitempty"`   // Unix epoch time; return only reorgs at or after this time
+	MaxEntries    uint32                 `protobuf:"varint,2,opt,name=maxEntries,proto3" json:"maxEntries,omitempty"` // zero means unlimited
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReorgHistoryArg) Reset() {
+	*x = GetReorgHistoryArg{}
+	mi := &file_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReorgHistoryArg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReorgHistoryArg) ProtoMessage() {}
+
+func (x *GetReorgHistoryArg) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReorgHistoryArg.ProtoReflect.Descriptor instead.
+func (*GetReorgHistoryArg) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetReorgHistoryArg) GetStartTime() uint64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *GetReorgHistoryArg) GetMaxEntries() uint32 {
+	if x != nil {
+		return x.MaxEntries
+	}
+	return 0
+}
+
+// A ReorgEvent describes a chain reorganization observed by the server: the
+// blocks it removed from its cache and the blocks that replaced them. Block
+// hashes are in the same (little-endian) byte order as `CompactBlock.hash`.
+type ReorgEvent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Time           uint64                 `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`                    // Unix epoch time when the reorg was recorded
+	Depth          uint32                 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`                  // number of blocks removed
+	OrphanedBlocks []*BlockID             `protobuf:"bytes,3,rep,name=orphanedBlocks,proto3" json:"orphanedBlocks,omitempty"` // the removed blocks, in increasing height order
+	NewBlocks      []*BlockID             `protobuf:"bytes,4,rep,name=newBlocks,proto3" json:"newBlocks,omitempty"`           // the replacement blocks, in increasing height order
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReorgEvent) Reset() {
+	*x = ReorgEvent{}
+	mi := &file_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReorgEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReorgEvent) ProtoMessage() {}
+
+func (x *ReorgEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReorgEvent.ProtoReflect.Descriptor instead.
+func (*ReorgEvent) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ReorgEvent) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ReorgEvent) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *ReorgEvent) GetOrphanedBlocks() []*BlockID {
+	if x != nil {
+		return x.OrphanedBlocks
+	}
+	return nil
+}
+
+func (x *ReorgEvent) GetNewBlocks() []*BlockID {
+	if x != nil {
+		return x.NewBlocks
+	}
+	return nil
+}
+
 var File_service_proto protoreflect.FileDescriptor
 
 const file_service_proto_rawDesc = "" +
@@ -1511,10 +2071,34 @@ const file_service_proto_rawDesc = "" +
 	"\x0fdonationAddress\x18\x0f \x01(\tR\x0fdonationAddress\x12 \n" +
 	"\vupgradeName\x18\x10 \x01(\tR\vupgradeName\x12$\n" +
 	"\rupgradeHeight\x18\x11 \x01(\x04R\rupgradeHeight\x12>\n" +
-	"\x1alightwalletProtocolVersion\x18\x12 \x01(\tR\x1alightwalletProtocolVersion\"r\n" +
+	"\x1alightwalletProtocolVersion\x18\x12 \x01(\tR\x1alightwalletProtocolVersion\"\xaa\x01\n" +
 	"\x1dTransparentAddressBlockFilter\x12\x18\n" +
 	"\aaddress\x18\x01 \x01(\tR\aaddress\x127\n" +
-	"\x05range\x18\x02 \x01(\v2!.cash.z.wallet.sdk.rpc.BlockRangeR\x05range\"*\n" +
+	"\x05range\x18\x02 \x01(\v2!.cash.z.wallet.sdk.rpc.BlockRangeR\x05range\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\fR\x06cursor\x12\x1e\n" +
+	"\n" +
+	"maxEntries\x18\x04 \x01(\rR\n" +
+	"maxEntries\"\xda\x01\n" +
+	"\x15GetTaddressHistoryArg\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x127\n" +
+	"\x05range\x18\x02 \x01(\v2!.cash.z.wallet.sdk.rpc.BlockRangeR\x05range\x126\n" +
+	"\x16includeRawTransactions\x18\x03 \x01(\bR\x16includeRawTransactions\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\fR\x06cursor\x12\x1e\n" +
+	"\n" +
+	"maxEntries\x18\x05 \x01(\rR\n" +
+	"maxEntries\"\xa1\x02\n" +
+	"\x13TaddressTransaction\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\fR\x04txid\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x1c\n" +
+	"\tblockTime\x18\x03 \x01(\rR\tblockTime\x12\x18\n" +
+	"\atxIndex\x18\x04 \x01(\x04R\atxIndex\x12 \n" +
+	"\vreceivedZat\x18\x05 \x01(\x04R\vreceivedZat\x12\x1a\n" +
+	"\bspentZat\x18\x06 \x01(\x04R\bspentZat\x12 \n" +
+	"\vvoutIndices\x18\a \x03(\rR\vvoutIndices\x12\x1e\n" +
+	"\n" +
+	"vinIndices\x18\b \x03(\rR\n" +
+	"vinIndices\x12&\n" +
+	"\x0erawTransaction\x18\t \x01(\fR\x0erawTransaction\"*\n" +
 	"\bDuration\x12\x1e\n" +
 	"\n" +
 	"intervalUs\x18\x01 \x01(\x03R\n" +
@@ -1527,7 +2111,21 @@ const file_service_proto_rawDesc = "" +
 	"\vAddressList\x12\x1c\n" +
 	"\taddresses\x18\x01 \x03(\tR\taddresses\"%\n" +
 	"\aBalance\x12\x1a\n" +
-	"\bvalueZat\x18\x01 \x01(\x03R\bvalueZat\"\x8e\x01\n" +
+	"\bvalueZat\x18\x01 \x01(\x03R\bvalueZat\"\x81\x01\n" +
+	"\x1dGetTaddressBalanceAtHeightArg\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\tR\taddresses\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12*\n" +
+	"\x10minConfirmations\x18\x03 \x01(\rR\x10minConfirmations\"\xcf\x01\n" +
+	"\x0fTaddressBalance\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1a\n" +
+	"\bvalueZat\x18\x02 \x01(\x03R\bvalueZat\x12 \n" +
+	"\vreceivedZat\x18\x03 \x01(\x03R\vreceivedZat\x12\x18\n" +
+	"\asentZat\x18\x04 \x01(\x03R\asentZat\x12\x1c\n" +
+	"\tutxoCount\x18\x05 \x01(\rR\tutxoCount\x12,\n" +
+	"\x11confirmedValueZat\x18\x06 \x01(\x03R\x11confirmedValueZat\"q\n" +
+	"\x13TaddressBalanceList\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12B\n" +
+	"\bbalances\x18\x02 \x03(\v2&.cash.z.wallet.sdk.rpc.TaddressBalanceR\bbalances\"\x8e\x01\n" +
 	"\x13GetMempoolTxRequest\x122\n" +
 	"\x15exclude_txid_suffixes\x18\x01 \x03(\fR\x13excludeTxidSuffixes\x12=\n" +
 	"\tpoolTypes\x18\x03 \x03(\x0e2\x1f.cash.z.wallet.sdk.rpc.PoolTypeR\tpoolTypesJ\x04\b\x02\x10\x03\"\xcd\x01\n" +
@@ -1550,13 +2148,15 @@ const file_service_proto_rawDesc = "" +
 	"\vSubtreeRoot\x12\x1a\n" +
 	"\brootHash\x18\x02 \x01(\fR\brootHash\x120\n" +
 	"\x13completingBlockHash\x18\x03 \x01(\fR\x13completingBlockHash\x124\n" +
-	"\x15completingBlockHeight\x18\x04 \x01(\x04R\x15completingBlockHeight\"t\n" +
+	"\x15completingBlockHeight\x18\x04 \x01(\x04R\x15completingBlockHeight\"\xb4\x01\n" +
 	"\x12GetAddressUtxosArg\x12\x1c\n" +
 	"\taddresses\x18\x01 \x03(\tR\taddresses\x12 \n" +
 	"\vstartHeight\x18\x02 \x01(\x04R\vstartHeight\x12\x1e\n" +
 	"\n" +
 	"maxEntries\x18\x03 \x01(\rR\n" +
-	"maxEntries\"\xa6\x01\n" +
+	"maxEntries\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\fR\x06cursor\x12&\n" +
+	"\x0eincludeMempool\x18\x05 \x01(\bR\x0eincludeMempool\"\xa6\x01\n" +
 	"\x14GetAddressUtxosReply\x12\x18\n" +
 	"\aaddress\x18\x06 \x01(\tR\aaddress\x12\x12\n" +
 	"\x04txid\x18\x01 \x01(\fR\x04txid\x12\x14\n" +
@@ -1565,7 +2165,18 @@ const file_service_proto_rawDesc = "" +
 	"\bvalueZat\x18\x04 \x01(\x03R\bvalueZat\x12\x16\n" +
 	"\x06height\x18\x05 \x01(\x04R\x06height\"k\n" +
 	"\x18GetAddressUtxosReplyList\x12O\n" +
-	"\faddressUtxos\x18\x01 \x03(\v2+.cash.z.wallet.sdk.rpc.GetAddressUtxosReplyR\faddressUtxos*Z\n" +
+	"\faddressUtxos\x18\x01 \x03(\v2+.cash.z.wallet.sdk.rpc.GetAddressUtxosReplyR\faddressUtxos\"R\n" +
+	"\x12GetReorgHistoryArg\x12\x1c\n" +
+	"\tstartTime\x18\x01 \x01(\x04R\tstartTime\x12\x1e\n" +
+	"\n" +
+	"maxEntries\x18\x02 \x01(\rR\n" +
+	"maxEntries\"\xbc\x01\n" +
+	"\n" +
+	"ReorgEvent\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x04R\x04time\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\rR\x05depth\x12F\n" +
+	"\x0eorphanedBlocks\x18\x03 \x03(\v2\x1e.cash.z.wallet.sdk.rpc.BlockIDR\x0eorphanedBlocks\x12<\n" +
+	"\tnewBlocks\x18\x04 \x03(\v2\x1e.cash.z.wallet.sdk.rpc.BlockIDR\tnewBlocks*Z\n" +
 	"\bPoolType\x12\x15\n" +
 	"\x11POOL_TYPE_INVALID\x10\x00\x12\x0f\n" +
 	"\vTRANSPARENT\x10\x01\x12\v\n" +
@@ -1575,19 +2186,22 @@ const file_service_proto_rawDesc = "" +
 	"\x10ShieldedProtocol\x12\v\n" +
 	"\asapling\x10\x00\x12\v\n" +
 	"\aorchard\x10\x01\x12\f\n" +
-	"\bironwood\x10\x022\xa8\x0f\n" +
+	"\bironwood\x10\x022\xe7\x12\n" +
 	"\x11CompactTxStreamer\x12T\n" +
 	"\x0eGetLatestBlock\x12 .cash.z.wallet.sdk.rpc.ChainSpec\x1a\x1e.cash.z.wallet.sdk.rpc.BlockID\"\x00\x12Q\n" +
 	"\bGetBlock\x12\x1e.cash.z.wallet.sdk.rpc.BlockID\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x00\x12^\n" +
 	"\x12GetBlockNullifiers\x12\x1e.cash.z.wallet.sdk.rpc.BlockID\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x03\x88\x02\x01\x12[\n" +
-	"\rGetBlockRange\x12!.cash.z.wallet.sdk.rpc.BlockRange\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x000\x01\x12h\n" +
+	"\rGetBlockRange\x12!.cash.z.wallet.sdk.rpc.BlockRange\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x000\x01\x12a\n" +
+	"\x13GetBlockHeaderRange\x12!.cash.z.wallet.sdk.rpc.BlockRange\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x000\x01\x12h\n" +
 	"\x17GetBlockRangeNullifiers\x12!.cash.z.wallet.sdk.rpc.BlockRange\x1a#.cash.z.wallet.sdk.rpc.CompactBlock\"\x03\x88\x02\x010\x01\x12Z\n" +
 	"\x0eGetTransaction\x12\x1f.cash.z.wallet.sdk.rpc.TxFilter\x1a%.cash.z.wallet.sdk.rpc.RawTransaction\"\x00\x12_\n" +
 	"\x0fSendTransaction\x12%.cash.z.wallet.sdk.rpc.RawTransaction\x1a#.cash.z.wallet.sdk.rpc.SendResponse\"\x00\x12s\n" +
 	"\x10GetTaddressTxids\x124.cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter\x1a%.cash.z.wallet.sdk.rpc.RawTransaction\"\x000\x01\x12z\n" +
-	"\x17GetTaddressTransactions\x124.cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter\x1a%.cash.z.wallet.sdk.rpc.RawTransaction\"\x000\x01\x12Z\n" +
+	"\x17GetTaddressTransactions\x124.cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter\x1a%.cash.z.wallet.sdk.rpc.RawTransaction\"\x000\x01\x12r\n" +
+	"\x12GetTaddressHistory\x12,.cash.z.wallet.sdk.rpc.GetTaddressHistoryArg\x1a*.cash.z.wallet.sdk.rpc.TaddressTransaction\"\x000\x01\x12Z\n" +
 	"\x12GetTaddressBalance\x12\".cash.z.wallet.sdk.rpc.AddressList\x1a\x1e.cash.z.wallet.sdk.rpc.Balance\"\x00\x12^\n" +
-	"\x18GetTaddressBalanceStream\x12\x1e.cash.z.wallet.sdk.rpc.Address\x1a\x1e.cash.z.wallet.sdk.rpc.Balance\"\x00(\x01\x12`\n" +
+	"\x18GetTaddressBalanceStream\x12\x1e.cash.z.wallet.sdk.rpc.Address\x1a\x1e.cash.z.wallet.sdk.rpc.Balance\"\x00(\x01\x12\x80\x01\n" +
+	"\x1aGetTaddressBalanceAtHeight\x124.cash.z.wallet.sdk.rpc.GetTaddressBalanceAtHeightArg\x1a*.cash.z.wallet.sdk.rpc.TaddressBalanceList\"\x00\x12`\n" +
 	"\fGetMempoolTx\x12*.cash.z.wallet.sdk.rpc.GetMempoolTxRequest\x1a .cash.z.wallet.sdk.rpc.CompactTx\"\x000\x01\x12[\n" +
 	"\x10GetMempoolStream\x12\x1c.cash.z.wallet.sdk.rpc.Empty\x1a%.cash.z.wallet.sdk.rpc.RawTransaction\"\x000\x01\x12R\n" +
 	"\fGetTreeState\x12\x1e.cash.z.wallet.sdk.rpc.BlockID\x1a .cash.z.wallet.sdk.rpc.TreeState\"\x00\x12V\n" +
@@ -1595,7 +2209,8 @@ const file_service_proto_rawDesc = "" +
 	"\x0fGetSubtreeRoots\x12).cash.z.wallet.sdk.rpc.GetSubtreeRootsArg\x1a\".cash.z.wallet.sdk.rpc.SubtreeRoot\"\x000\x01\x12o\n" +
 	"\x0fGetAddressUtxos\x12).cash.z.wallet.sdk.rpc.GetAddressUtxosArg\x1a/.cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList\"\x00\x12s\n" +
 	"\x15GetAddressUtxosStream\x12).cash.z.wallet.sdk.rpc.GetAddressUtxosArg\x1a+.cash.z.wallet.sdk.rpc.GetAddressUtxosReply\"\x000\x01\x12R\n" +
-	"\rGetLightdInfo\x12\x1c.cash.z.wallet.sdk.rpc.Empty\x1a!.cash.z.wallet.sdk.rpc.LightdInfo\"\x00\x12N\n" +
+	"\rGetLightdInfo\x12\x1c.cash.z.wallet.sdk.rpc.Empty\x1a!.cash.z.wallet.sdk.rpc.LightdInfo\"\x00\x12c\n" +
+	"\x0fGetReorgHistory\x12).cash.z.wallet.sdk.rpc.GetReorgHistoryArg\x1a!.cash.z.wallet.sdk.rpc.ReorgEvent\"\x000\x01\x12N\n" +
 	"\x04Ping\x12\x1f.cash.z.wallet.sdk.rpc.Duration\x1a#.cash.z.wallet.sdk.rpc.PingResponse\"\x00B\x1bZ\x16lightwalletd/walletrpc\xba\x02\x00b\x06proto3"
 
 var (
@@ -1611,7 +2226,7 @@ func file_service_proto_rawDescGZIP() []byte {
 }
 
 var file_service_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_service_proto_goTypes = []any{
 	(PoolType)(0),                         // 0: cash.z.wallet.sdk.rpc.PoolType
 	(ShieldedProtocol)(0),                 // 1: cash.z.wallet.sdk.rpc.ShieldedProtocol
@@ -1624,20 +2239,27 @@ var file_service_proto_goTypes = []any{
 	(*Empty)(nil),                         // 8: cash.z.wallet.sdk.rpc.Empty
 	(*LightdInfo)(nil),                    // 9: cash.z.wallet.sdk.rpc.LightdInfo
 	(*TransparentAddressBlockFilter)(nil), // 10: cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter
-	(*Duration)(nil),                      // 11: cash.z.wallet.sdk.rpc.Duration
-	(*PingResponse)(nil),                  // 12: cash.z.wallet.sdk.rpc.PingResponse
-	(*Address)(nil),                       // 13: cash.z.wallet.sdk.rpc.Address
-	(*AddressList)(nil),                   // 14: cash.z.wallet.sdk.rpc.AddressList
-	(*Balance)(nil),                       // 15: cash.z.wallet.sdk.rpc.Balance
-	(*GetMempoolTxRequest)(nil),           // 16: cash.z.wallet.sdk.rpc.GetMempoolTxRequest
-	(*TreeState)(nil),                     // 17: cash.z.wallet.sdk.rpc.TreeState
-	(*GetSubtreeRootsArg)(nil),            // 18: cash.z.wallet.sdk.rpc.GetSubtreeRootsArg
-	(*SubtreeRoot)(nil),                   // 19: cash.z.wallet.sdk.rpc.SubtreeRoot
-	(*GetAddressUtxosArg)(nil),            // 20: cash.z.wallet.sdk.rpc.GetAddressUtxosArg
-	(*GetAddressUtxosReply)(nil),          // 21: cash.z.wallet.sdk.rpc.GetAddressUtxosReply
-	(*GetAddressUtxosReplyList)(nil),      // 22: cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList
-	(*CompactBlock)(nil),                  // 23: cash.z.wallet.sdk.rpc.CompactBlock
-	(*CompactTx)(nil),                     // 24: cash.z.wallet.sdk.rpc.CompactTx
+	(*GetTaddressHistoryArg)(nil),         // 11: cash.z.wallet.sdk.rpc.GetTaddressHistoryArg
+	(*TaddressTransaction)(nil),           // 12: cash.z.wallet.sdk.rpc.TaddressTransaction
+	(*Duration)(nil),                      // 13: cash.z.wallet.sdk.rpc.Duration
+	(*PingResponse)(nil),                  // 14: cash.z.wallet.sdk.rpc.PingResponse
+	(*Address)(nil),                       // 15: cash.z.wallet.sdk.rpc.Address
+	(*AddressList)(nil),                   // 16: cash.z.wallet.sdk.rpc.AddressList
+	(*Balance)(nil),                       // 17: cash.z.wallet.sdk.rpc.Balance
+	(*GetTaddressBalanceAtHeightArg)(nil), // 18: cash.z.wallet.sdk.rpc.GetTaddressBalanceAtHeightArg
+	(*TaddressBalance)(nil),               // 19: cash.z.wallet.sdk.rpc.TaddressBalance
+	(*TaddressBalanceList)(nil),           // 20: cash.z.wallet.sdk.rpc.TaddressBalanceList
+	(*GetMempoolTxRequest)(nil),           // 21: cash.z.wallet.sdk.rpc.GetMempoolTxRequest
+	(*TreeState)(nil),                     // 22: cash.z.wallet.sdk.rpc.TreeState
+	(*GetSubtreeRootsArg)(nil),            // 23: cash.z.wallet.sdk.rpc.GetSubtreeRootsArg
+	(*SubtreeRoot)(nil),                   // 24: cash.z.wallet.sdk.rpc.SubtreeRoot
+	(*GetAddressUtxosArg)(nil),            // 25: cash.z.wallet.sdk.rpc.GetAddressUtxosArg
+	(*GetAddressUtxosReply)(nil),          // 26: cash.z.wallet.sdk.rpc.GetAddressUtxosReply
+	(*GetAddressUtxosReplyList)(nil),      // 27: cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList
+	(*GetReorgHistoryArg)(nil),            // 28: cash.z.wallet.sdk.rpc.GetReorgHistoryArg
+	(*ReorgEvent)(nil),                    // 29: cash.z.wallet.sdk.rpc.ReorgEvent
+	(*CompactBlock)(nil),                  // 30: cash.z.wallet.sdk.rpc.CompactBlock
+	(*CompactTx)(nil),                     // 31: cash.z.wallet.sdk.rpc.CompactTx
 }
 var file_service_proto_depIdxs = []int32{
 	2,  // 0: cash.z.wallet.sdk.rpc.BlockRange.start:type_name -> cash.z.wallet.sdk.rpc.BlockID
@@ -1645,54 +2267,66 @@ var file_service_proto_depIdxs = []int32{
 	0,  // 2: cash.z.wallet.sdk.rpc.BlockRange.poolTypes:type_name -> cash.z.wallet.sdk.rpc.PoolType
 	2,  // 3: cash.z.wallet.sdk.rpc.TxFilter.block:type_name -> cash.z.wallet.sdk.rpc.BlockID
 	3,  // 4: cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter.range:type_name -> cash.z.wallet.sdk.rpc.BlockRange
-	0,  // 5: cash.z.wallet.sdk.rpc.GetMempoolTxRequest.poolTypes:type_name -> cash.z.wallet.sdk.rpc.PoolType
-	1,  // 6: cash.z.wallet.sdk.rpc.GetSubtreeRootsArg.shieldedProtocol:type_name -> cash.z.wallet.sdk.rpc.ShieldedProtocol
-	21, // 7: cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList.addressUtxos:type_name -> cash.z.wallet.sdk.rpc.GetAddressUtxosReply
-	7,  // 8: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestBlock:input_type -> cash.z.wallet.sdk.rpc.ChainSpec
-	2,  // 9: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlock:input_type -> cash.z.wallet.sdk.rpc.BlockID
-	2,  // 10: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockNullifiers:input_type -> cash.z.wallet.sdk.rpc.BlockID
-	3,  // 11: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRange:input_type -> cash.z.wallet.sdk.rpc.BlockRange
-	3,  // 12: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRangeNullifiers:input_type -> cash.z.wallet.sdk.rpc.BlockRange
-	4,  // 13: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTransaction:input_type -> cash.z.wallet.sdk.rpc.TxFilter
-	5,  // 14: cash.z.wallet.sdk.rpc.CompactTxStreamer.SendTransaction:input_type -> cash.z.wallet.sdk.rpc.RawTransaction
-	10, // 15: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTxids:input_type -> cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter
-	10, // 16: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTransactions:input_type -> cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter
-	14, // 17: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalance:input_type -> cash.z.wallet.sdk.rpc.AddressList
-	13, // 18: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceStream:input_type -> cash.z.wallet.sdk.rpc.Address
-	16, // 19: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolTx:input_type -> cash.z.wallet.sdk.rpc.GetMempoolTxRequest
-	8,  // 20: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolStream:input_type -> cash.z.wallet.sdk.rpc.Empty
-	2,  // 21: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTreeState:input_type -> cash.z.wallet.sdk.rpc.BlockID
-	8,  // 22: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestTreeState:input_type -> cash.z.wallet.sdk.rpc.Empty
-	18, // 23: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetSubtreeRoots:input_type -> cash.z.wallet.sdk.rpc.GetSubtreeRootsArg
-	20, // 24: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxos:input_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosArg
-	20, // 25: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxosStream:input_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosArg
-	8,  // 26: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLightdInfo:input_type -> cash.z.wallet.sdk.rpc.Empty
-	11, // 27: cash.z.wallet.sdk.rpc.CompactTxStreamer.Ping:input_type -> cash.z.wallet.sdk.rpc.Duration
-	2,  // 28: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestBlock:output_type -> cash.z.wallet.sdk.rpc.BlockID
-	23, // 29: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlock:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
-	23, // 30: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockNullifiers:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
-	23, // 31: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRange:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
-	23, // 32: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRangeNullifiers:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
-	5,  // 33: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTransaction:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
-	6,  // 34: cash.z.wallet.sdk.rpc.CompactTxStreamer.SendTransaction:output_type -> cash.z.wallet.sdk.rpc.SendResponse
-	5,  // 35: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTxids:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
-	5,  // 36: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTransactions:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
-	15, // 37: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalance:output_type -> cash.z.wallet.sdk.rpc.Balance
-	15, // 38: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceStream:output_type -> cash.z.wallet.sdk.rpc.Balance
-	24, // 39: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolTx:output_type -> cash.z.wallet.sdk.rpc.CompactTx
-	5,  // 40: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolStream:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
-	17, // 41: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTreeState:output_type -> cash.z.wallet.sdk.rpc.TreeState
-	17, // 42: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestTreeState:output_type -> cash.z.wallet.sdk.rpc.TreeState
-	19, // 43: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetSubtreeRoots:output_type -> cash.z.wallet.sdk.rpc.SubtreeRoot
-	22, // 44: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxos:output_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList
-	21, // 45: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxosStream:output_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosReply
-	9,  // 46: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLightdInfo:output_type -> cash.z.wallet.sdk.rpc.LightdInfo
-	12, // 47: cash.z.wallet.sdk.rpc.CompactTxStreamer.Ping:output_type -> cash.z.wallet.sdk.rpc.PingResponse
-	28, // [28:48] is the sub-list for method output_type
-	8,  // [8:28] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	3,  // 5: cash.z.wallet.sdk.rpc.GetTaddressHistoryArg.range:type_name -> cash.z.wallet.sdk.rpc.BlockRange
+	19, // 6: cash.z.wallet.sdk.rpc.TaddressBalanceList.balances:type_name -> cash.z.wallet.sdk.rpc.TaddressBalance
+	0,  // 7: cash.z.wallet.sdk.rpc.GetMempoolTxRequest.poolTypes:type_name -> cash.z.wallet.sdk.rpc.PoolType
+	1,  // 8: cash.z.wallet.sdk.rpc.GetSubtreeRootsArg.shieldedProtocol:type_name -> cash.z.wallet.sdk.rpc.ShieldedProtocol
+	26, // 9: cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList.addressUtxos:type_name -> cash.z.wallet.sdk.rpc.GetAddressUtxosReply
+	2,  // 10: cash.z.wallet.sdk.rpc.ReorgEvent.orphanedBlocks:type_name -> cash.z.wallet.sdk.rpc.BlockID
+	2,  // 11: cash.z.wallet.sdk.rpc.ReorgEvent.newBlocks:type_name -> cash.z.wallet.sdk.rpc.BlockID
+	7,  // 12: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestBlock:input_type -> cash.z.wallet.sdk.rpc.ChainSpec
+	2,  // 13: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlock:input_type -> cash.z.wallet.sdk.rpc.BlockID
+	2,  // 14: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockNullifiers:input_type -> cash.z.wallet.sdk.rpc.BlockID
+	3,  // 15: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRange:input_type -> cash.z.wallet.sdk.rpc.BlockRange
+	3,  // 16: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockHeaderRange:input_type -> cash.z.wallet.sdk.rpc.BlockRange
+	3,  // 17: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRangeNullifiers:input_type -> cash.z.wallet.sdk.rpc.BlockRange
+	4,  // 18: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTransaction:input_type -> cash.z.wallet.sdk.rpc.TxFilter
+	5,  // 19: cash.z.wallet.sdk.rpc.CompactTxStreamer.SendTransaction:input_type -> cash.z.wallet.sdk.rpc.RawTransaction
+	10, // 20: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTxids:input_type -> cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter
+	10, // 21: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTransactions:input_type -> cash.z.wallet.sdk.rpc.TransparentAddressBlockFilter
+	11, // 22: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressHistory:input_type -> cash.z.wallet.sdk.rpc.GetTaddressHistoryArg
+	16, // 23: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalance:input_type -> cash.z.wallet.sdk.rpc.AddressList
+	15, // 24: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceStream:input_type -> cash.z.wallet.sdk.rpc.Address
+	18, // 25: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceAtHeight:input_type -> cash.z.wallet.sdk.rpc.GetTaddressBalanceAtHeightArg
+	21, // 26: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolTx:input_type -> cash.z.wallet.sdk.rpc.GetMempoolTxRequest
+	8,  // 27: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolStream:input_type -> cash.z.wallet.sdk.rpc.Empty
+	2,  // 28: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTreeState:input_type -> cash.z.wallet.sdk.rpc.BlockID
+	8,  // 29: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestTreeState:input_type -> cash.z.wallet.sdk.rpc.Empty
+	23, // 30: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetSubtreeRoots:input_type -> cash.z.wallet.sdk.rpc.GetSubtreeRootsArg
+	25, // 31: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxos:input_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosArg
+	25, // 32: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxosStream:input_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosArg
+	8,  // 33: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLightdInfo:input_type -> cash.z.wallet.sdk.rpc.Empty
+	28, // 34: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetReorgHistory:input_type -> cash.z.wallet.sdk.rpc.GetReorgHistoryArg
+	13, // 35: cash.z.wallet.sdk.rpc.CompactTxStreamer.Ping:input_type -> cash.z.wallet.sdk.rpc.Duration
+	2,  // 36: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestBlock:output_type -> cash.z.wallet.sdk.rpc.BlockID
+	30, // 37: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlock:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
+	30, // 38: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockNullifiers:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
+	30, // 39: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRange:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
+	30, // 40: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockHeaderRange:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
+	30, // 41: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetBlockRangeNullifiers:output_type -> cash.z.wallet.sdk.rpc.CompactBlock
+	5,  // 42: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTransaction:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
+	6,  // 43: cash.z.wallet.sdk.rpc.CompactTxStreamer.SendTransaction:output_type -> cash.z.wallet.sdk.rpc.SendResponse
+	5,  // 44: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTxids:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
+	5,  // 45: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressTransactions:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
+	12, // 46: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressHistory:output_type -> cash.z.wallet.sdk.rpc.TaddressTransaction
+	17, // 47: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalance:output_type -> cash.z.wallet.sdk.rpc.Balance
+	17, // 48: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceStream:output_type -> cash.z.wallet.sdk.rpc.Balance
+	20, // 49: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTaddressBalanceAtHeight:output_type -> cash.z.wallet.sdk.rpc.TaddressBalanceList
+	31, // 50: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolTx:output_type -> cash.z.wallet.sdk.rpc.CompactTx
+	5,  // 51: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetMempoolStream:output_type -> cash.z.wallet.sdk.rpc.RawTransaction
+	22, // 52: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetTreeState:output_type -> cash.z.wallet.sdk.rpc.TreeState
+	22, // 53: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLatestTreeState:output_type -> cash.z.wallet.sdk.rpc.TreeState
+	24, // 54: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetSubtreeRoots:output_type -> cash.z.wallet.sdk.rpc.SubtreeRoot
+	27, // 55: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxos:output_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosReplyList
+	26, // 56: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetAddressUtxosStream:output_type -> cash.z.wallet.sdk.rpc.GetAddressUtxosReply
+	9,  // 57: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetLightdInfo:output_type -> cash.z.wallet.sdk.rpc.LightdInfo
+	29, // 58: cash.z.wallet.sdk.rpc.CompactTxStreamer.GetReorgHistory:output_type -> cash.z.wallet.sdk.rpc.ReorgEvent
+	14, // 59: cash.z.wallet.sdk.rpc.CompactTxStreamer.Ping:output_type -> cash.z.wallet.sdk.rpc.PingResponse
+	36, // [36:60] is the sub-list for method output_type
+	12, // [12:36] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_service_proto_init() }
@@ -1707,7 +2341,7 @@ func file_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_proto_rawDesc), len(file_service_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   21,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
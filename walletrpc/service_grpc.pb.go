@@ -23,26 +23,30 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CompactTxStreamer_GetLatestBlock_FullMethodName           = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLatestBlock"
-	CompactTxStreamer_GetBlock_FullMethodName                 = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlock"
-	CompactTxStreamer_GetBlockNullifiers_FullMethodName       = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockNullifiers"
-	CompactTxStreamer_GetBlockRange_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockRange"
-	CompactTxStreamer_GetBlockRangeNullifiers_FullMethodName  = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockRangeNullifiers"
-	CompactTxStreamer_GetTransaction_FullMethodName           = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTransaction"
-	CompactTxStreamer_SendTransaction_FullMethodName          = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/SendTransaction"
-	CompactTxStreamer_GetTaddressTxids_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressTxids"
-	CompactTxStreamer_GetTaddressTransactions_FullMethodName  = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressTransactions"
-	CompactTxStreamer_GetTaddressBalance_FullMethodName       = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressBalance"
-	CompactTxStreamer_GetTaddressBalanceStream_FullMethodName = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressBalanceStream"
-	CompactTxStreamer_GetMempoolTx_FullMethodName             = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetMempoolTx"
-	CompactTxStreamer_GetMempoolStream_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetMempoolStream"
-	CompactTxStreamer_GetTreeState_FullMethodName             = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTreeState"
-	CompactTxStreamer_GetLatestTreeState_FullMethodName       = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLatestTreeState"
-	CompactTxStreamer_GetSubtreeRoots_FullMethodName          = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetSubtreeRoots"
-	CompactTxStreamer_GetAddressUtxos_FullMethodName          = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetAddressUtxos"
-	CompactTxStreamer_GetAddressUtxosStream_FullMethodName    = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetAddressUtxosStream"
-	CompactTxStreamer_GetLightdInfo_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLightdInfo"
-	CompactTxStreamer_Ping_FullMethodName                     = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/Ping"
+	CompactTxStreamer_GetLatestBlock_FullMethodName             = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLatestBlock"
+	CompactTxStreamer_GetBlock_FullMethodName                   = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlock"
+	CompactTxStreamer_GetBlockNullifiers_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockNullifiers"
+	CompactTxStreamer_GetBlockRange_FullMethodName              = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockRange"
+	CompactTxStreamer_GetBlockHeaderRange_FullMethodName        = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockHeaderRange"
+	CompactTxStreamer_GetBlockRangeNullifiers_FullMethodName    = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetBlockRangeNullifiers"
+	CompactTxStreamer_GetTransaction_FullMethodName             = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTransaction"
+	CompactTxStreamer_SendTransaction_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/SendTransaction"
+	CompactTxStreamer_GetTaddressTxids_FullMethodName           = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressTxids"
+	CompactTxStreamer_GetTaddressTransactions_FullMethodName    = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressTransactions"
+	CompactTxStreamer_GetTaddressHistory_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressHistory"
+	CompactTxStreamer_GetTaddressBalance_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressBalance"
+	CompactTxStreamer_GetTaddressBalanceStream_FullMethodName   = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressBalanceStream"
+	CompactTxStreamer_GetTaddressBalanceAtHeight_FullMethodName = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTaddressBalanceAtHeight"
+	CompactTxStreamer_GetMempoolTx_FullMethodName               = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetMempoolTx"
+	CompactTxStreamer_GetMempoolStream_FullMethodName           = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetMempoolStream"
+	CompactTxStreamer_GetTreeState_FullMethodName               = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetTreeState"
+	CompactTxStreamer_GetLatestTreeState_FullMethodName         = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLatestTreeState"
+	CompactTxStreamer_GetSubtreeRoots_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetSubtreeRoots"
+	CompactTxStreamer_GetAddressUtxos_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetAddressUtxos"
+	CompactTxStreamer_GetAddressUtxosStream_FullMethodName      = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetAddressUtxosStream"
+	CompactTxStreamer_GetLightdInfo_FullMethodName              = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetLightdInfo"
+	CompactTxStreamer_GetReorgHistory_FullMethodName            = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/GetReorgHistory"
+	CompactTxStreamer_Ping_FullMethodName                       = "/cash.z.wallet.sdk.rpc.CompactTxStreamer/Ping"
 )
 
 // CompactTxStreamerClient is the client API for CompactTxStreamer service.
@@ -80,6 +84,21 @@ type CompactTxStreamerClient interface {
 	// If range.start <= range.end, blocks are returned increasing height order;
 	// otherwise blocks are returned in decreasing height order.
 	GetBlockRange(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompactBlock], error)
+	// Return a list of consecutive block headers in the specified range, which
+	// is inclusive of `range.end`, in the same order as `GetBlockRange`.
+	//
+	// Each returned `CompactBlock` has its `header` field set to the full
+	// serialized block header (including the Equihash solution), along with
+	// `height`, `hash`, `prevHash`, and `time`; `vtx` is empty and
+	// `chainMetadata` is unset. This lets clients verify the chain's proof of
+	// work and hash linkage independently of compact block contents. The
+	// `poolTypes` field of the request is ignored.
+	//
+	// Headers aren't kept with the compact blocks; a server keeping full
+	// blocks (lightwalletd's `--raw-archive`) serves them locally, but
+	// otherwise each header costs the server two requests to its backend
+	// node, so long ranges may be slow.
+	GetBlockHeaderRange(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompactBlock], error)
 	// Deprecated: Do not use.
 	// Return a stream of compact blocks for the specified range, where each
 	// block contains only nullifier information for the shielded pools
@@ -104,8 +123,18 @@ type CompactTxStreamerClient interface {
 	// Return the transactions corresponding to the given t-address within the given block range.
 	// Mempool transactions are not included in the results.
 	GetTaddressTransactions(ctx context.Context, in *TransparentAddressBlockFilter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RawTransaction], error)
+	// Return the transactions that pay or spend from the given transparent
+	// address within the given block range, in block order, each with its
+	// block context and the value it moves, without the full transaction
+	// unless requested. Mempool transactions are not included.
+	GetTaddressHistory(ctx context.Context, in *GetTaddressHistoryArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaddressTransaction], error)
 	GetTaddressBalance(ctx context.Context, in *AddressList, opts ...grpc.CallOption) (*Balance, error)
 	GetTaddressBalanceStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Address, Balance], error)
+	// Return the balance of each of the given transparent addresses as of the
+	// given block height (the chain tip if zero), in the order given, with
+	// duplicates removed. Unified and TEX addresses are replaced by their
+	// transparent receivers. Mempool transactions are not included.
+	GetTaddressBalanceAtHeight(ctx context.Context, in *GetTaddressBalanceAtHeightArg, opts ...grpc.CallOption) (*TaddressBalanceList, error)
 	// Returns a stream of the compact transaction representation for transactions
 	// currently in the mempool. The results of this operation may be a few
 	// seconds out of date. If the `exclude_txid_suffixes` list is empty,
@@ -135,6 +164,8 @@ type CompactTxStreamerClient interface {
 	GetAddressUtxosStream(ctx context.Context, in *GetAddressUtxosArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetAddressUtxosReply], error)
 	// Return information about this lightwalletd instance and the blockchain
 	GetLightdInfo(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LightdInfo, error)
+	// Return the chain reorganizations this server has observed, oldest first.
+	GetReorgHistory(ctx context.Context, in *GetReorgHistoryArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReorgEvent], error)
 	// Testing-only, requires lightwalletd --ping-very-insecure (do not enable in production)
 	Ping(ctx context.Context, in *Duration, opts ...grpc.CallOption) (*PingResponse, error)
 }
@@ -197,10 +228,29 @@ func (c *compactTxStreamerClient) GetBlockRange(ctx context.Context, in *BlockRa
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetBlockRangeClient = grpc.ServerStreamingClient[CompactBlock]
 
+func (c *compactTxStreamerClient) GetBlockHeaderRange(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompactBlock], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[1], CompactTxStreamer_GetBlockHeaderRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BlockRange, CompactBlock]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetBlockHeaderRangeClient = grpc.ServerStreamingClient[CompactBlock]
+
 // Deprecated: Do not use.
 func (c *compactTxStreamerClient) GetBlockRangeNullifiers(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompactBlock], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[1], CompactTxStreamer_GetBlockRangeNullifiers_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[2], CompactTxStreamer_GetBlockRangeNullifiers_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +289,7 @@ func (c *compactTxStreamerClient) SendTransaction(ctx context.Context, in *RawTr
 
 func (c *compactTxStreamerClient) GetTaddressTxids(ctx context.Context, in *TransparentAddressBlockFilter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RawTransaction], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[2], CompactTxStreamer_GetTaddressTxids_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[3], CompactTxStreamer_GetTaddressTxids_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -258,7 +308,7 @@ type CompactTxStreamer_GetTaddressTxidsClient = grpc.ServerStreamingClient[RawTr
 
 func (c *compactTxStreamerClient) GetTaddressTransactions(ctx context.Context, in *TransparentAddressBlockFilter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RawTransaction], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[3], CompactTxStreamer_GetTaddressTransactions_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[4], CompactTxStreamer_GetTaddressTransactions_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -275,6 +325,25 @@ func (c *compactTxStreamerClient) GetTaddressTransactions(ctx context.Context, i
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetTaddressTransactionsClient = grpc.ServerStreamingClient[RawTransaction]
 
+func (c *compactTxStreamerClient) GetTaddressHistory(ctx context.Context, in *GetTaddressHistoryArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaddressTransaction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[5], CompactTxStreamer_GetTaddressHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetTaddressHistoryArg, TaddressTransaction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetTaddressHistoryClient = grpc.ServerStreamingClient[TaddressTransaction]
+
 func (c *compactTxStreamerClient) GetTaddressBalance(ctx context.Context, in *AddressList, opts ...grpc.CallOption) (*Balance, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Balance)
@@ -287,7 +356,7 @@ func (c *compactTxStreamerClient) GetTaddressBalance(ctx context.Context, in *Ad
 
 func (c *compactTxStreamerClient) GetTaddressBalanceStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Address, Balance], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[4], CompactTxStreamer_GetTaddressBalanceStream_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[6], CompactTxStreamer_GetTaddressBalanceStream_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -298,9 +367,19 @@ func (c *compactTxStreamerClient) GetTaddressBalanceStream(ctx context.Context,
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetTaddressBalanceStreamClient = grpc.ClientStreamingClient[Address, Balance]
 
+func (c *compactTxStreamerClient) GetTaddressBalanceAtHeight(ctx context.Context, in *GetTaddressBalanceAtHeightArg, opts ...grpc.CallOption) (*TaddressBalanceList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaddressBalanceList)
+	err := c.cc.Invoke(ctx, CompactTxStreamer_GetTaddressBalanceAtHeight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *compactTxStreamerClient) GetMempoolTx(ctx context.Context, in *GetMempoolTxRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompactTx], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[5], CompactTxStreamer_GetMempoolTx_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[7], CompactTxStreamer_GetMempoolTx_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -319,7 +398,7 @@ type CompactTxStreamer_GetMempoolTxClient = grpc.ServerStreamingClient[CompactTx
 
 func (c *compactTxStreamerClient) GetMempoolStream(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RawTransaction], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[6], CompactTxStreamer_GetMempoolStream_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[8], CompactTxStreamer_GetMempoolStream_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -358,7 +437,7 @@ func (c *compactTxStreamerClient) GetLatestTreeState(ctx context.Context, in *Em
 
 func (c *compactTxStreamerClient) GetSubtreeRoots(ctx context.Context, in *GetSubtreeRootsArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubtreeRoot], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[7], CompactTxStreamer_GetSubtreeRoots_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[9], CompactTxStreamer_GetSubtreeRoots_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -387,7 +466,7 @@ func (c *compactTxStreamerClient) GetAddressUtxos(ctx context.Context, in *GetAd
 
 func (c *compactTxStreamerClient) GetAddressUtxosStream(ctx context.Context, in *GetAddressUtxosArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetAddressUtxosReply], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[8], CompactTxStreamer_GetAddressUtxosStream_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[10], CompactTxStreamer_GetAddressUtxosStream_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -414,6 +493,25 @@ func (c *compactTxStreamerClient) GetLightdInfo(ctx context.Context, in *Empty,
 	return out, nil
 }
 
+func (c *compactTxStreamerClient) GetReorgHistory(ctx context.Context, in *GetReorgHistoryArg, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReorgEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompactTxStreamer_ServiceDesc.Streams[11], CompactTxStreamer_GetReorgHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetReorgHistoryArg, ReorgEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetReorgHistoryClient = grpc.ServerStreamingClient[ReorgEvent]
+
 func (c *compactTxStreamerClient) Ping(ctx context.Context, in *Duration, opts ...grpc.CallOption) (*PingResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PingResponse)
@@ -459,6 +557,21 @@ type CompactTxStreamerServer interface {
 	// If range.start <= range.end, blocks are returned increasing height order;
 	// otherwise blocks are returned in decreasing height order.
 	GetBlockRange(*BlockRange, grpc.ServerStreamingServer[CompactBlock]) error
+	// Return a list of consecutive block headers in the specified range, which
+	// is inclusive of `range.end`, in the same order as `GetBlockRange`.
+	//
+	// Each returned `CompactBlock` has its `header` field set to the full
+	// serialized block header (including the Equihash solution), along with
+	// `height`, `hash`, `prevHash`, and `time`; `vtx` is empty and
+	// `chainMetadata` is unset. This lets clients verify the chain's proof of
+	// work and hash linkage independently of compact block contents. The
+	// `poolTypes` field of the request is ignored.
+	//
+	// Headers aren't kept with the compact blocks; a server keeping full
+	// blocks (lightwalletd's `--raw-archive`) serves them locally, but
+	// otherwise each header costs the server two requests to its backend
+	// node, so long ranges may be slow.
+	GetBlockHeaderRange(*BlockRange, grpc.ServerStreamingServer[CompactBlock]) error
 	// Deprecated: Do not use.
 	// Return a stream of compact blocks for the specified range, where each
 	// block contains only nullifier information for the shielded pools
@@ -483,8 +596,18 @@ type CompactTxStreamerServer interface {
 	// Return the transactions corresponding to the given t-address within the given block range.
 	// Mempool transactions are not included in the results.
 	GetTaddressTransactions(*TransparentAddressBlockFilter, grpc.ServerStreamingServer[RawTransaction]) error
+	// Return the transactions that pay or spend from the given transparent
+	// address within the given block range, in block order, each with its
+	// block context and the value it moves, without the full transaction
+	// unless requested. Mempool transactions are not included.
+	GetTaddressHistory(*GetTaddressHistoryArg, grpc.ServerStreamingServer[TaddressTransaction]) error
 	GetTaddressBalance(context.Context, *AddressList) (*Balance, error)
 	GetTaddressBalanceStream(grpc.ClientStreamingServer[Address, Balance]) error
+	// Return the balance of each of the given transparent addresses as of the
+	// given block height (the chain tip if zero), in the order given, with
+	// duplicates removed. Unified and TEX addresses are replaced by their
+	// transparent receivers. Mempool transactions are not included.
+	GetTaddressBalanceAtHeight(context.Context, *GetTaddressBalanceAtHeightArg) (*TaddressBalanceList, error)
 	// Returns a stream of the compact transaction representation for transactions
 	// currently in the mempool. The results of this operation may be a few
 	// seconds out of date. If the `exclude_txid_suffixes` list is empty,
@@ -514,6 +637,8 @@ type CompactTxStreamerServer interface {
 	GetAddressUtxosStream(*GetAddressUtxosArg, grpc.ServerStreamingServer[GetAddressUtxosReply]) error
 	// Return information about this lightwalletd instance and the blockchain
 	GetLightdInfo(context.Context, *Empty) (*LightdInfo, error)
+	// Return the chain reorganizations this server has observed, oldest first.
+	GetReorgHistory(*GetReorgHistoryArg, grpc.ServerStreamingServer[ReorgEvent]) error
 	// Testing-only, requires lightwalletd --ping-very-insecure (do not enable in production)
 	Ping(context.Context, *Duration) (*PingResponse, error)
 	mustEmbedUnimplementedCompactTxStreamerServer()
@@ -538,6 +663,9 @@ func (UnimplementedCompactTxStreamerServer) GetBlockNullifiers(context.Context,
 func (UnimplementedCompactTxStreamerServer) GetBlockRange(*BlockRange, grpc.ServerStreamingServer[CompactBlock]) error {
 	return status.Error(codes.Unimplemented, "method GetBlockRange not implemented")
 }
+func (UnimplementedCompactTxStreamerServer) GetBlockHeaderRange(*BlockRange, grpc.ServerStreamingServer[CompactBlock]) error {
+	return status.Error(codes.Unimplemented, "method GetBlockHeaderRange not implemented")
+}
 func (UnimplementedCompactTxStreamerServer) GetBlockRangeNullifiers(*BlockRange, grpc.ServerStreamingServer[CompactBlock]) error {
 	return status.Error(codes.Unimplemented, "method GetBlockRangeNullifiers not implemented")
 }
@@ -553,12 +681,18 @@ func (UnimplementedCompactTxStreamerServer) GetTaddressTxids(*TransparentAddress
 func (UnimplementedCompactTxStreamerServer) GetTaddressTransactions(*TransparentAddressBlockFilter, grpc.ServerStreamingServer[RawTransaction]) error {
 	return status.Error(codes.Unimplemented, "method GetTaddressTransactions not implemented")
 }
+func (UnimplementedCompactTxStreamerServer) GetTaddressHistory(*GetTaddressHistoryArg, grpc.ServerStreamingServer[TaddressTransaction]) error {
+	return status.Error(codes.Unimplemented, "method GetTaddressHistory not implemented")
+}
 func (UnimplementedCompactTxStreamerServer) GetTaddressBalance(context.Context, *AddressList) (*Balance, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetTaddressBalance not implemented")
 }
 func (UnimplementedCompactTxStreamerServer) GetTaddressBalanceStream(grpc.ClientStreamingServer[Address, Balance]) error {
 	return status.Error(codes.Unimplemented, "method GetTaddressBalanceStream not implemented")
 }
+func (UnimplementedCompactTxStreamerServer) GetTaddressBalanceAtHeight(context.Context, *GetTaddressBalanceAtHeightArg) (*TaddressBalanceList, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTaddressBalanceAtHeight not implemented")
+}
 func (UnimplementedCompactTxStreamerServer) GetMempoolTx(*GetMempoolTxRequest, grpc.ServerStreamingServer[CompactTx]) error {
 	return status.Error(codes.Unimplemented, "method GetMempoolTx not implemented")
 }
@@ -583,6 +717,9 @@ func (UnimplementedCompactTxStreamerServer) GetAddressUtxosStream(*GetAddressUtx
 func (UnimplementedCompactTxStreamerServer) GetLightdInfo(context.Context, *Empty) (*LightdInfo, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetLightdInfo not implemented")
 }
+func (UnimplementedCompactTxStreamerServer) GetReorgHistory(*GetReorgHistoryArg, grpc.ServerStreamingServer[ReorgEvent]) error {
+	return status.Error(codes.Unimplemented, "method GetReorgHistory not implemented")
+}
 func (UnimplementedCompactTxStreamerServer) Ping(context.Context, *Duration) (*PingResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
 }
@@ -672,6 +809,17 @@ func _CompactTxStreamer_GetBlockRange_Handler(srv interface{}, stream grpc.Serve
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetBlockRangeServer = grpc.ServerStreamingServer[CompactBlock]
 
+func _CompactTxStreamer_GetBlockHeaderRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BlockRange)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompactTxStreamerServer).GetBlockHeaderRange(m, &grpc.GenericServerStream[BlockRange, CompactBlock]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetBlockHeaderRangeServer = grpc.ServerStreamingServer[CompactBlock]
+
 func _CompactTxStreamer_GetBlockRangeNullifiers_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(BlockRange)
 	if err := stream.RecvMsg(m); err != nil {
@@ -741,6 +889,17 @@ func _CompactTxStreamer_GetTaddressTransactions_Handler(srv interface{}, stream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetTaddressTransactionsServer = grpc.ServerStreamingServer[RawTransaction]
 
+func _CompactTxStreamer_GetTaddressHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetTaddressHistoryArg)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompactTxStreamerServer).GetTaddressHistory(m, &grpc.GenericServerStream[GetTaddressHistoryArg, TaddressTransaction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetTaddressHistoryServer = grpc.ServerStreamingServer[TaddressTransaction]
+
 func _CompactTxStreamer_GetTaddressBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AddressList)
 	if err := dec(in); err != nil {
@@ -766,6 +925,24 @@ func _CompactTxStreamer_GetTaddressBalanceStream_Handler(srv interface{}, stream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CompactTxStreamer_GetTaddressBalanceStreamServer = grpc.ClientStreamingServer[Address, Balance]
 
+func _CompactTxStreamer_GetTaddressBalanceAtHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaddressBalanceAtHeightArg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompactTxStreamerServer).GetTaddressBalanceAtHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompactTxStreamer_GetTaddressBalanceAtHeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompactTxStreamerServer).GetTaddressBalanceAtHeight(ctx, req.(*GetTaddressBalanceAtHeightArg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CompactTxStreamer_GetMempoolTx_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(GetMempoolTxRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -882,6 +1059,17 @@ func _CompactTxStreamer_GetLightdInfo_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CompactTxStreamer_GetReorgHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetReorgHistoryArg)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompactTxStreamerServer).GetReorgHistory(m, &grpc.GenericServerStream[GetReorgHistoryArg, ReorgEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompactTxStreamer_GetReorgHistoryServer = grpc.ServerStreamingServer[ReorgEvent]
+
 func _CompactTxStreamer_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Duration)
 	if err := dec(in); err != nil {
@@ -931,6 +1119,10 @@ var CompactTxStreamer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTaddressBalance",
 			Handler:    _CompactTxStreamer_GetTaddressBalance_Handler,
 		},
+		{
+			MethodName: "GetTaddressBalanceAtHeight",
+			Handler:    _CompactTxStreamer_GetTaddressBalanceAtHeight_Handler,
+		},
 		{
 			MethodName: "GetTreeState",
 			Handler:    _CompactTxStreamer_GetTreeState_Handler,
@@ -958,6 +1150,11 @@ var CompactTxStreamer_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CompactTxStreamer_GetBlockRange_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetBlockHeaderRange",
+			Handler:       _CompactTxStreamer_GetBlockHeaderRange_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetBlockRangeNullifiers",
 			Handler:       _CompactTxStreamer_GetBlockRangeNullifiers_Handler,
@@ -973,6 +1170,11 @@ var CompactTxStreamer_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CompactTxStreamer_GetTaddressTransactions_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetTaddressHistory",
+			Handler:       _CompactTxStreamer_GetTaddressHistory_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetTaddressBalanceStream",
 			Handler:       _CompactTxStreamer_GetTaddressBalanceStream_Handler,
@@ -998,6 +1200,11 @@ var CompactTxStreamer_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CompactTxStreamer_GetAddressUtxosStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetReorgHistory",
+			Handler:       _CompactTxStreamer_GetReorgHistory_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "service.proto",
 }